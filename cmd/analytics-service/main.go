@@ -10,9 +10,14 @@ import (
 
 	"github.com/Wuchinator/realtime-analytics/internal/analytics"
 	"github.com/Wuchinator/realtime-analytics/internal/config"
+	"github.com/Wuchinator/realtime-analytics/migrations"
 	"github.com/Wuchinator/realtime-analytics/pkg/kafka"
 	"github.com/Wuchinator/realtime-analytics/pkg/logger"
+	"github.com/Wuchinator/realtime-analytics/pkg/metrics"
+	"github.com/Wuchinator/realtime-analytics/pkg/migrate"
 	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
+	"github.com/Wuchinator/realtime-analytics/pkg/tracing"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
@@ -35,6 +40,26 @@ func main() {
 		zap.String("consumer_group", cfg.Kafka.Topic+"-analytics"),
 	)
 
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName:  "analytics-service",
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		FilePath:     cfg.Tracing.FilePath,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	}, log)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		// Отдельный context: оставшиеся spans выгружаются уже после остановки сервиса
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Warn("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	db, err := postgres.New(postgres.Config{
 		DSN:             cfg.Postgres.PostgresDSN(),
 		MaxOpenConns:    cfg.Postgres.MaxOpenConns,
@@ -46,18 +71,76 @@ func main() {
 	}
 	defer db.Close()
 
+	if err := db.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+		log.Fatal("Failed to register postgres metrics", zap.Error(err))
+	}
+
+	migrator, err := migrate.New(db, migrations.FS, log)
+	if err != nil {
+		log.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	if err := migrator.Prepare(context.Background(), cfg.Postgres.MigrateOnStart); err != nil {
+		log.Fatal("Database schema is not ready", zap.Error(err))
+	}
+
+	metricsServer := metrics.NewServer(cfg.Metrics.AnalyticsServicePort, log)
+	metricsServer.Start()
+
+	dimensions := make([]analytics.Dimension, len(cfg.Dimensions))
+	for i, dimension := range cfg.Dimensions {
+		dimensions[i] = analytics.Dimension{Name: dimension.Name, MaxValues: dimension.MaxValues}
+	}
+
 	analyticsRepo := analytics.NewRepository(db.DB, log)
-	analyticsService := analytics.NewService(analyticsRepo, log)
+	analyticsService := analytics.NewService(analyticsRepo, analytics.ServiceConfig{
+		SessionTimeout: cfg.Sessions.InactivityTimeout,
+		PageKey:        cfg.Sessions.PageKey,
+		Revenue: analytics.RevenueConfig{
+			EventTypes:   cfg.Revenue.EventTypes,
+			TotalKey:     cfg.Revenue.TotalKey,
+			PriceKey:     cfg.Revenue.PriceKey,
+			QuantityKey:  cfg.Revenue.QuantityKey,
+			CurrencyKey:  cfg.Revenue.CurrencyKey,
+			ItemsKey:     cfg.Revenue.ItemsKey,
+			ProductIDKey: cfg.Revenue.ProductIDKey,
+			CategoryKey:  cfg.Revenue.CategoryKey,
+			BaseCurrency: cfg.Revenue.BaseCurrency,
+		},
+		Dimensions: dimensions,
+		Rollup: analytics.RollupConfig{
+			MinuteRetention: cfg.Rollups.MinuteRetention,
+		},
+	}, log)
+
+	// Без курсов заказы в других валютах не попадут в выручку, поэтому до старта consumer'а
+	if err := analyticsService.RefreshCurrencyRates(context.Background()); err != nil {
+		log.Fatal("Failed to load currency rates", zap.Error(err))
+	}
+
+	if err := analyticsService.LoadDimensionValues(context.Background()); err != nil {
+		log.Fatal("Failed to load dimension values", zap.Error(err))
+	}
+
+	groupID := cfg.Kafka.Topic + "-analytics"
 
+	// Источник правды для offsets - processed_offsets в Postgres,
+	// commit в Kafka остаётся только для мониторинга lag
 	consumer, err := kafka.NewConsumer(kafka.ConsumerConfig{
 		Brokers:           cfg.Kafka.Brokers,
 		Topics:            []string{cfg.Kafka.Topic},
-		GroupID:           cfg.Kafka.Topic + "-analytics",
+		GroupID:           groupID,
 		AutoCommit:        true,
 		CommitInterval:    1 * time.Second,
 		SessionTimeout:    10 * time.Second,
 		RebalanceStrategy: "sticky",
-	}, analyticsService.CreateMessageHandler(), log)
+		OffsetStore:       analyticsService,
+		Retry: kafka.RetryPolicy{
+			MaxAttempts: cfg.Kafka.ConsumerRetries,
+			MinBackoff:  cfg.Kafka.ConsumerMinBackoff,
+			MaxBackoff:  cfg.Kafka.ConsumerMaxBackoff,
+		},
+		DeadLetterTopic: cfg.Kafka.DeadLetterTopic,
+	}, analyticsService.CreateMessageHandler(groupID), log)
 	if err != nil {
 		log.Fatal("Failed to create Kafka consumer", zap.Error(err))
 	}
@@ -76,13 +159,59 @@ func main() {
 	log.Info("Kafka consumer is ready and consuming messages")
 
 	go func() {
-		ticker := time.NewTicker(1 * time.Hour)
+		ticker := time.NewTicker(cfg.Cohorts.RefreshInterval)
 		defer ticker.Stop()
 
+		analyticsService.RefreshRetention(ctx, cfg.Cohorts.Lookback)
 		for {
 			select {
 			case <-ticker.C:
-				analyticsService.CleanupOldCache()
+				analyticsService.RefreshRetention(ctx, cfg.Cohorts.Lookback)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(cfg.Revenue.RatesRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := analyticsService.RefreshCurrencyRates(ctx); err != nil {
+					log.Error("Failed to refresh currency rates", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(cfg.Sessions.CloseInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				analyticsService.CloseIdleSessions(ctx)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(cfg.Rollups.Interval)
+		defer ticker.Stop()
+
+		analyticsService.Rollup(ctx)
+		for {
+			select {
+			case <-ticker.C:
+				analyticsService.Rollup(ctx)
 			case <-ctx.Done():
 				return
 			}
@@ -99,6 +228,10 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer shutdownCancel()
 
+	if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+		log.Warn("Failed to stop metrics server", zap.Error(err))
+	}
+
 	<-shutdownCtx.Done()
 
 	log.Info("Analytics Service stopped")
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/internal/config"
+	"github.com/Wuchinator/realtime-analytics/pkg/kafka"
+	"github.com/Wuchinator/realtime-analytics/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Просмотр и replay сообщений из DLQ:
+//
+//	dlq list   [-partition N] [-from-offset N] [-limit N] [-values]
+//	dlq replay [-partition N] [-from-offset N] [-limit N] [-dry-run]
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	partition := fs.Int("partition", -1, "DLQ partition, -1 for all")
+	fromOffset := fs.Int64("from-offset", 0, "start reading from this offset")
+	limit := fs.Int("limit", 100, "max messages, 0 for no limit")
+	showValues := fs.Bool("values", false, "print message values (list)")
+	dryRun := fs.Bool("dry-run", false, "print what would be replayed (replay)")
+	fs.Parse(os.Args[2:])
+
+	cfg, err := config.Load()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load config: %v", err))
+	}
+
+	log, err := logger.NewLogger(cfg.LogLevel, cfg.Environment)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create logger: %v", err))
+	}
+	defer log.Sync()
+
+	log = logger.WithService(log, "dlq")
+
+	dlq, err := kafka.NewDeadLetterQueue(cfg.Kafka.Brokers, cfg.Kafka.DeadLetterTopic, log)
+	if err != nil {
+		log.Fatal("Failed to connect to Kafka", zap.Error(err))
+	}
+	defer dlq.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	var handle func(*kafka.DeadLetter) error
+	switch command {
+	case "list":
+		handle = func(dl *kafka.DeadLetter) error {
+			printDeadLetter(dl, *showValues)
+			return nil
+		}
+	case "replay":
+		handle = func(dl *kafka.DeadLetter) error {
+			if *dryRun {
+				printDeadLetter(dl, false)
+				return nil
+			}
+			return dlq.Replay(ctx, dl, cfg.Kafka.Topic)
+		}
+	default:
+		usage()
+	}
+
+	count := 0
+	err = dlq.Read(ctx, int32(*partition), *fromOffset, *limit, func(dl *kafka.DeadLetter) error {
+		if err := handle(dl); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		log.Fatal("Failed to process dead letters",
+			zap.Error(err),
+			zap.Int("processed", count),
+		)
+	}
+
+	fmt.Printf("%s: %d messages from %s\n", command, count, cfg.Kafka.DeadLetterTopic)
+}
+
+func printDeadLetter(dl *kafka.DeadLetter, showValue bool) {
+	fmt.Printf("[%d:%d] %s[%d]@%d attempts=%d failed_at=%s key=%s\n    error: %s\n",
+		dl.Partition,
+		dl.Offset,
+		dl.OriginalTopic,
+		dl.OriginalPartition,
+		dl.OriginalOffset,
+		dl.Attempts,
+		dl.FailedAt.Format(time.RFC3339),
+		dl.Key,
+		dl.Error,
+	)
+	if showValue {
+		fmt.Printf("    value: %s\n", dl.Value)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: dlq <list|replay> [-partition N] [-from-offset N] [-limit N] [-values] [-dry-run]")
+	os.Exit(2)
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,10 +13,17 @@ import (
 
 	"github.com/Wuchinator/realtime-analytics/internal/config"
 	"github.com/Wuchinator/realtime-analytics/internal/event"
+	"github.com/Wuchinator/realtime-analytics/migrations"
+	"github.com/Wuchinator/realtime-analytics/pkg/archive"
 	"github.com/Wuchinator/realtime-analytics/pkg/kafka"
 	"github.com/Wuchinator/realtime-analytics/pkg/logger"
+	"github.com/Wuchinator/realtime-analytics/pkg/metrics"
+	"github.com/Wuchinator/realtime-analytics/pkg/migrate"
 	pb "github.com/Wuchinator/realtime-analytics/pkg/pb/events"
 	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
+	"github.com/Wuchinator/realtime-analytics/pkg/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
@@ -42,6 +51,26 @@ func main() {
 		zap.String("grpc_port", cfg.GRPCPort),
 	)
 
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName:  "event-service",
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		FilePath:     cfg.Tracing.FilePath,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	}, log)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		// Отдельный context: оставшиеся spans выгружаются уже после остановки сервиса
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Warn("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	db, err := postgres.New(postgres.Config{
 		DSN:             cfg.Postgres.PostgresDSN(),
 		MaxOpenConns:    cfg.Postgres.MaxOpenConns,
@@ -54,6 +83,21 @@ func main() {
 
 	defer db.Close()
 
+	if err := db.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+		log.Fatal("Failed to register postgres metrics", zap.Error(err))
+	}
+
+	migrator, err := migrate.New(db, migrations.FS, log)
+	if err != nil {
+		log.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	if err := migrator.Prepare(context.Background(), cfg.Postgres.MigrateOnStart); err != nil {
+		log.Fatal("Database schema is not ready", zap.Error(err))
+	}
+
+	metricsServer := metrics.NewServer(cfg.Metrics.EventServicePort, log)
+	metricsServer.Start()
+
 	kafka, err := kafka.NewProducer(kafka.ProducerConfig{
 		Brokers:          cfg.Kafka.Brokers,
 		Topic:            cfg.Kafka.Topic,
@@ -71,17 +115,136 @@ func main() {
 
 	defer kafka.Close()
 
+	registry := event.NewRegistry(event.NewSchemaRepository(db, log), log)
+	if err := registry.Refresh(context.Background()); err != nil {
+		log.Fatal("Failed to load event schemas", zap.Error(err))
+	}
+
+	registryCtx, registryCancel := context.WithCancel(context.Background())
+	defer registryCancel()
+	go registry.Run(registryCtx, cfg.Schemas.RefreshInterval)
+
+	var archiver *event.Archiver
+	if cfg.Archive.Enabled {
+		store, err := archive.NewStore(context.Background(), archive.Config{
+			Backend: cfg.Archive.Backend,
+			Dir:     cfg.Archive.Dir,
+			S3: archive.S3Config{
+				Endpoint:  cfg.Archive.S3Endpoint,
+				Bucket:    cfg.Archive.S3Bucket,
+				Region:    cfg.Archive.S3Region,
+				AccessKey: cfg.Archive.S3AccessKey,
+				SecretKey: cfg.Archive.S3SecretKey,
+				UseSSL:    cfg.Archive.S3UseSSL,
+			},
+		})
+		if err != nil {
+			log.Fatal("Failed to initialize event archive store", zap.Error(err))
+		}
+		archiver = event.NewArchiver(event.NewArchiveRepository(db, log), store, event.ArchiverConfig{
+			Prefix: cfg.Archive.Prefix,
+		}, log)
+	}
+
+	partitions, err := event.NewPartitionManager(event.NewPartitionRepository(db, log), archiver, event.PartitionConfig{
+		Interval:        cfg.Partitions.Interval,
+		Premake:         cfg.Partitions.Premake,
+		Retention:       cfg.Partitions.Retention,
+		RetentionByType: cfg.Partitions.RetentionByType,
+		Detach:          cfg.Partitions.Detach,
+	}, log)
+	if err != nil {
+		log.Fatal("Invalid events partition config", zap.Error(err))
+	}
+	// Без партиций события попадают в events_default, поэтому ошибка не фатальна
+	if err := partitions.Maintain(context.Background()); err != nil {
+		// Обычную events обслуживать нельзя: retention и архивация на ней не работают
+		if errors.Is(err, event.ErrEventsNotPartitioned) {
+			log.Fatal("Invalid events table", zap.Error(err))
+		}
+		log.Error("Failed to maintain event partitions", zap.Error(err))
+	}
+
+	partitionsCtx, partitionsCancel := context.WithCancel(context.Background())
+	defer partitionsCancel()
+	go partitions.Run(partitionsCtx, cfg.Partitions.MaintenanceInterval)
+
 	eventRepo := event.NewRepository(db, log)
-	eventService := event.NewService(eventRepo, kafka, log)
-	eventHandler := event.NewHandler(eventService, log)
+	eventService := event.NewService(eventRepo, registry, log)
+	eventHandler := event.NewHandler(eventService, event.HandlerConfig{
+		StreamBatchSize:     cfg.Stream.BatchSize,
+		StreamFlushInterval: cfg.Stream.FlushInterval,
+	}, log)
+
+	httpServer := &http.Server{
+		Addr: ":" + cfg.HTTP.Port,
+		Handler: event.NewHTTPHandler(eventHandler, event.HTTPConfig{
+			AllowedOrigins: cfg.HTTP.AllowedOrigins,
+			MaxBodyBytes:   int64(cfg.HTTP.MaxBodyBytes),
+		}, log),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	relay := event.NewRelay(eventRepo, kafka, event.RelayConfig{
+		PollInterval: cfg.Outbox.PollInterval,
+		BatchSize:    cfg.Outbox.BatchSize,
+		MinBackoff:   cfg.Outbox.MinBackoff,
+		MaxBackoff:   cfg.Outbox.MaxBackoff,
+		Retention:    cfg.Outbox.Retention,
+		Lease:        cfg.Outbox.Lease,
+	}, log)
+
+	relayCtx, relayCancel := context.WithCancel(context.Background())
+	defer relayCancel()
+
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(relayCtx)
+	}()
 
-	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
-		loggingInterceptor(log),
-		recoveryInterceptor(log)),
+	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			loggingInterceptor(log),
+			recoveryInterceptor(log),
+		),
+		grpc.ChainStreamInterceptor(
+			streamLoggingInterceptor(log),
+			streamRecoveryInterceptor(log),
+		),
 	)
 
 	pb.RegisterEventServiceServer(grpcServer, eventHandler)
 
+	// AdminService удаляет и выгружает данные пользователей, меняет схемы и
+	// восстанавливает архивы, поэтому живёт на отдельном порту и только по токенам
+	adminAuth := event.NewAdminAuth(log)
+	for _, token := range cfg.Admin.Tokens {
+		adminAuth.AddToken(token.Caller, token.Token, token.Scopes)
+	}
+	if len(cfg.Admin.Tokens) == 0 {
+		log.Warn("ADMIN_TOKENS is empty, all AdminService calls will be rejected")
+	}
+
+	adminServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			loggingInterceptor(log),
+			recoveryInterceptor(log),
+			adminAuth.UnaryInterceptor(),
+		),
+		grpc.ChainStreamInterceptor(
+			streamLoggingInterceptor(log),
+			streamRecoveryInterceptor(log),
+			adminAuth.StreamInterceptor(),
+		),
+	)
+
+	privacy := event.NewPrivacy(event.NewPrivacyRepository(db, log), log)
+	pb.RegisterAdminServiceServer(adminServer, event.NewAdminHandler(registry, privacy, archiver, log))
+	reflection.Register(adminServer)
+
 	// Checker for kuber
 	healthServer := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
@@ -90,7 +253,7 @@ func main() {
 	// Reflection для grpcurl и подобных инструментов
 	reflection.Register(grpcServer)
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":"+cfg.GRPCPort))
+	listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
 
 	if err != nil {
 		log.Fatal("Error initializing gRPC listener", zap.Error(err))
@@ -103,6 +266,25 @@ func main() {
 		}
 	}()
 
+	adminListener, err := net.Listen("tcp", ":"+cfg.Admin.Port)
+	if err != nil {
+		log.Fatal("Error initializing admin gRPC listener", zap.Error(err))
+	}
+
+	go func() {
+		log.Info("Starting admin gRPC server", zap.String("port", cfg.Admin.Port))
+		if err := adminServer.Serve(adminListener); err != nil {
+			log.Fatal("Error initializing admin gRPC server", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		log.Info("Starting HTTP gateway", zap.String("port", cfg.HTTP.Port))
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Error starting HTTP gateway", zap.Error(err))
+		}
+	}()
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -110,24 +292,38 @@ func main() {
 
 	log.Info("Shutting down gRPC server")
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+	defer cancel()
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Warn("shutdown HTTP gateway failed", zap.Error(err))
+	}
+
 	stopped := make(chan struct{})
 	go func() {
 		grpcServer.GracefulStop()
+		adminServer.GracefulStop()
 		close(stopped)
 	}()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-
-	defer cancel()
-
 	select {
 	case <-stopped:
 		log.Info("gRPC server stopped")
 	case <-ctx.Done():
 		log.Warn("shutdown gRPC server timed out")
 		grpcServer.Stop()
+		adminServer.Stop()
 	}
 	log.Info("gRPC server stopped")
+
+	// Relay останавливаем после gRPC, чтобы не закрыть producer посреди отправки
+	relayCancel()
+	<-relayDone
+
+	if err := metricsServer.Shutdown(ctx); err != nil {
+		log.Warn("Failed to stop metrics server", zap.Error(err))
+	}
 }
 
 func loggingInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
@@ -137,6 +333,7 @@ func loggingInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
 		resp, err := handler(ctx, req)
 
 		duration := time.Since(start)
+		metrics.ObserveGRPC(info.FullMethod, err, duration)
 		fields := []zap.Field{
 			zap.String("method", info.FullMethod),
 			zap.Duration("duration", duration),
@@ -168,3 +365,43 @@ func recoveryInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
 		return handler(ctx, req)
 	}
 }
+
+func streamLoggingInterceptor(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+
+		duration := time.Since(start)
+		metrics.ObserveGRPC(info.FullMethod, err, duration)
+		fields := []zap.Field{
+			zap.String("method", info.FullMethod),
+			zap.Duration("duration", duration),
+		}
+
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+			log.Error("gRPC stream failed", fields...)
+		} else {
+			log.Info("gRPC stream", fields...)
+		}
+
+		return err
+	}
+}
+
+func streamRecoveryInterceptor(log *zap.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("Panic recovered",
+					zap.String("method", info.FullMethod),
+					zap.Any("panic", r),
+				)
+				err = fmt.Errorf("internal server error")
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
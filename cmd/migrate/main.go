@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/internal/config"
+	"github.com/Wuchinator/realtime-analytics/migrations"
+	"github.com/Wuchinator/realtime-analytics/pkg/logger"
+	"github.com/Wuchinator/realtime-analytics/pkg/migrate"
+	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
+	"go.uber.org/zap"
+)
+
+// Миграции схемы базы:
+//
+//	migrate up      [-to N]
+//	migrate down    [-steps N]
+//	migrate status
+//	migrate version
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	to := fs.Int("to", 0, "apply migrations up to this version, 0 for latest (up)")
+	steps := fs.Int("steps", 1, "number of migrations to roll back (down)")
+	fs.Parse(os.Args[2:])
+
+	cfg, err := config.Load()
+	if err != nil {
+		panic(fmt.Sprintf("Failed to load config: %v", err))
+	}
+
+	log, err := logger.NewLogger(cfg.LogLevel, cfg.Environment)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create logger: %v", err))
+	}
+	defer log.Sync()
+
+	log = logger.WithService(log, "migrate")
+
+	db, err := postgres.New(postgres.Config{
+		DSN:             cfg.Postgres.PostgresDSN(),
+		MaxOpenConns:    cfg.Postgres.MaxOpenConns,
+		MaxIdleConns:    cfg.Postgres.MaxIdleConns,
+		ConnMaxLifetime: cfg.Postgres.ConnMaxLifetime,
+	}, log)
+	if err != nil {
+		log.Fatal("Failed to connect to PostgreSQL", zap.Error(err))
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, migrations.FS, log)
+	if err != nil {
+		log.Fatal("Failed to load migrations", zap.Error(err))
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	switch command {
+	case "up":
+		done, err := migrator.Up(ctx, *to)
+		if err != nil {
+			log.Fatal("Failed to apply migrations", zap.Error(err), zap.Int("applied", len(done)))
+		}
+		printMigrations("applied", done)
+	case "down":
+		done, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			log.Fatal("Failed to roll back migrations", zap.Error(err), zap.Int("rolled_back", len(done)))
+		}
+		printMigrations("rolled back", done)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal("Failed to get migration status", zap.Error(err))
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d %-40s %s\n", status.Version, status.Name, applied)
+		}
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			log.Fatal("Failed to get schema version", zap.Error(err))
+		}
+		fmt.Printf("schema version %d, latest %d\n", version, migrator.Latest())
+	default:
+		usage()
+	}
+}
+
+func printMigrations(action string, done []*migrate.Migration) {
+	for _, migration := range done {
+		fmt.Printf("%s %04d_%s\n", action, migration.Version, migration.Name)
+	}
+	fmt.Printf("%s: %d migrations\n", action, len(done))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate <up|down|status|version> [-to N] [-steps N]")
+	os.Exit(2)
+}
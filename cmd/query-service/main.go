@@ -12,11 +12,17 @@ import (
 	"github.com/Wuchinator/realtime-analytics/internal/analytics"
 	"github.com/Wuchinator/realtime-analytics/internal/config"
 	"github.com/Wuchinator/realtime-analytics/internal/query"
+	"github.com/Wuchinator/realtime-analytics/migrations"
 	"github.com/Wuchinator/realtime-analytics/pkg/logger"
+	"github.com/Wuchinator/realtime-analytics/pkg/metrics"
+	"github.com/Wuchinator/realtime-analytics/pkg/migrate"
 	pb "github.com/Wuchinator/realtime-analytics/pkg/pb/analytics"
 	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
-	_ "github.com/lib/pq"
+	"github.com/Wuchinator/realtime-analytics/pkg/tracing"
+	"github.com/lib/pq"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
@@ -42,6 +48,26 @@ func main() {
 		zap.String("grpc_port", "50052"),
 	)
 
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Config{
+		ServiceName:  "query-service",
+		Exporter:     cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+		OTLPInsecure: cfg.Tracing.OTLPInsecure,
+		FilePath:     cfg.Tracing.FilePath,
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	}, log)
+	if err != nil {
+		log.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		// Отдельный context: оставшиеся spans выгружаются уже после остановки сервиса
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Warn("Failed to flush traces", zap.Error(err))
+		}
+	}()
+
 	db, err := postgres.New(postgres.Config{
 		DSN:             cfg.Postgres.PostgresDSN(),
 		MaxOpenConns:    cfg.Postgres.MaxOpenConns,
@@ -53,12 +79,54 @@ func main() {
 	}
 	defer db.Close()
 
+	if err := db.RegisterMetrics(prometheus.DefaultRegisterer); err != nil {
+		log.Fatal("Failed to register postgres metrics", zap.Error(err))
+	}
+
+	migrator, err := migrate.New(db, migrations.FS, log)
+	if err != nil {
+		log.Fatal("Failed to load migrations", zap.Error(err))
+	}
+	if err := migrator.Prepare(context.Background(), cfg.Postgres.MigrateOnStart); err != nil {
+		log.Fatal("Database schema is not ready", zap.Error(err))
+	}
+
+	metricsServer := metrics.NewServer(cfg.Metrics.QueryServicePort, log)
+	metricsServer.Start()
+
+	// LISTEN держит отдельное соединение вне пула
+	listener := pq.NewListener(cfg.Postgres.PostgresDSN(), 10*time.Second, time.Minute,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Warn("Stats listener event", zap.Int("event", int(event)), zap.Error(err))
+			}
+		})
+	if err := listener.Listen(analytics.StatsChannel); err != nil {
+		log.Fatal("Failed to listen for stats updates", zap.Error(err))
+	}
+	defer listener.Close()
+
+	hubCtx, hubCancel := context.WithCancel(context.Background())
+	defer hubCancel()
+
+	statsHub := query.NewStatsHub(log)
+	go statsHub.Run(hubCtx, listener.Notify)
+
 	eventRepo := query.NewEventRepository(db.DB, log)
 	analyticsRepo := analytics.NewRepository(db.DB, log)
-	queryService := query.NewService(eventRepo, analyticsRepo, log)
+	dimensions := make([]string, len(cfg.Dimensions))
+	for i, dimension := range cfg.Dimensions {
+		dimensions[i] = dimension.Name
+	}
+
+	queryService := query.NewService(eventRepo, analyticsRepo, statsHub, query.ServiceConfig{
+		RevenueCurrency: cfg.Revenue.BaseCurrency,
+		Dimensions:      dimensions,
+	}, log)
 	queryHandler := query.NewHandler(queryService, log)
 
 	grpcServer := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 		grpc.ChainUnaryInterceptor(
 			loggingInterceptor(log),
 			recoveryInterceptor(log),
@@ -73,14 +141,14 @@ func main() {
 
 	reflection.Register(grpcServer)
 
-	listener, err := net.Listen("tcp", ":50053")
+	grpcListener, err := net.Listen("tcp", ":50053")
 	if err != nil {
 		log.Fatal("Failed to create listener", zap.Error(err))
 	}
 
 	go func() {
 		log.Info("gRPC server starting", zap.String("port", "50053"))
-		if err := grpcServer.Serve(listener); err != nil {
+		if err := grpcServer.Serve(grpcListener); err != nil {
 			log.Fatal("Failed to serve gRPC", zap.Error(err))
 		}
 	}()
@@ -91,6 +159,9 @@ func main() {
 
 	log.Info("Shutting down gracefully")
 
+	// Закрываем подписки, иначе GracefulStop будет ждать открытые streams
+	hubCancel()
+
 	stopped := make(chan struct{})
 	go func() {
 		grpcServer.GracefulStop()
@@ -108,6 +179,10 @@ func main() {
 		grpcServer.Stop()
 	}
 
+	if err := metricsServer.Shutdown(ctx); err != nil {
+		log.Warn("Failed to stop metrics server", zap.Error(err))
+	}
+
 	log.Info("Query Service stopped")
 }
 
@@ -116,6 +191,7 @@ func loggingInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
 		start := time.Now()
 		resp, err := handler(ctx, req)
 		duration := time.Since(start)
+		metrics.ObserveGRPC(info.FullMethod, err, duration)
 
 		fields := []zap.Field{
 			zap.String("method", info.FullMethod),
@@ -9,6 +9,7 @@ import (
 	pb "github.com/Wuchinator/realtime-analytics/pkg/pb/analytics"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -79,5 +80,48 @@ func main() {
 		)
 	}
 
+	fmt.Println("\nGetting funnel")
+	funnelResp, err := client.GetFunnel(context.Background(), &pb.GetFunnelRequest{
+		From:             timestamppb.New(from),
+		To:               timestamppb.New(now),
+		Steps:            []string{"product_view", "add_to_cart", "purchase"},
+		ConversionWindow: durationpb.New(time.Hour),
+	})
+	if err != nil {
+		log.Fatalf("Failed to get funnel: %v", err)
+	}
+
+	for _, step := range funnelResp.Steps {
+		fmt.Printf("   - %s: %d users, %.1f%% from previous, median %s\n",
+			step.EventType,
+			step.Users,
+			step.ConversionRate*100,
+			step.MedianTimeFromPrevious.AsDuration(),
+		)
+	}
+
+	fmt.Println("\nGetting revenue")
+	revenueResp, err := client.GetRevenueStats(context.Background(), &pb.GetRevenueStatsRequest{
+		From:           timestamppb.New(from),
+		To:             timestamppb.New(now),
+		Granularity:    "hour",
+		BreakdownLimit: 5,
+	})
+	if err != nil {
+		log.Fatalf("Failed to get revenue stats: %v", err)
+	}
+
+	total := revenueResp.Total
+	fmt.Printf("   Total: %.2f %s, %d orders, AOV %.2f, %.2f per buyer\n",
+		total.Revenue,
+		revenueResp.Currency,
+		total.Orders,
+		total.AverageOrderValue,
+		total.RevenuePerUser,
+	)
+	for _, category := range revenueResp.Categories {
+		fmt.Printf("   - %s: %.2f (%.1f%%)\n", category.Key, category.Revenue, category.RevenueShare*100)
+	}
+
 	fmt.Println("\nAll queries completed successfully!")
 }
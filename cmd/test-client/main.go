@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	_ "time"
 
@@ -11,6 +12,7 @@ import (
 	_ "github.com/lib/pq"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -40,11 +42,13 @@ func main() {
 		UserId:    uuid.New().String(),
 		SessionId: uuid.New().String(),
 		ProductId: uuid.New().String(),
-		Metadata: map[string]string{
+		Properties: mustStruct(map[string]any{
 			"product_name": "MacBook Pro",
 			"category":     "laptops",
-			"price":        "2499.99",
-		},
+			"price":        2499.99,
+			"in_stock":     true,
+			"tags":         []any{"apple", "m3"},
+		}),
 		Timestamp: timestamppb.Now(),
 	}
 
@@ -88,10 +92,10 @@ func main() {
 			UserId:    userID,
 			SessionId: sessionID,
 			ProductId: uuid.New().String(),
-			Metadata: map[string]string{
+			Properties: mustStruct(map[string]any{
 				"product_name": "AirPods Pro",
-				"price":        "249.99",
-			},
+				"price":        249.99,
+			}),
 			Timestamp: timestamppb.Now(),
 		},
 		{
@@ -100,10 +104,10 @@ func main() {
 			UserId:    userID,
 			SessionId: sessionID,
 			ProductId: uuid.New().String(),
-			Metadata: map[string]string{
+			Properties: mustStruct(map[string]any{
 				"product_name": "AirPods Pro",
-				"quantity":     "1",
-			},
+				"quantity":     1,
+			}),
 			Timestamp: timestamppb.Now(),
 		},
 	}
@@ -120,5 +124,77 @@ func main() {
 		fmt.Printf("Failed IDs: %v\n", batchResp.FailedEventIds)
 	}
 
+	fmt.Println("\nStreaming events")
+	stream, err := client.TrackEventStream(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to open event stream: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		err := stream.Send(&pb.TrackEventStreamRequest{
+			Events: []*pb.Event{{
+				EventId:   uuid.New().String(),
+				EventType: pb.EventType_EVENT_TYPE_PAGE_VIEW,
+				UserId:    userID,
+				SessionId: sessionID,
+				Metadata: map[string]string{
+					"page": fmt.Sprintf("/catalog?page=%d", i+1),
+				},
+				Timestamp: timestamppb.Now(),
+			}},
+		})
+		if err != nil {
+			log.Fatalf("Failed to send to stream: %v", err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		log.Fatalf("Failed to close stream: %v", err)
+	}
+
+	for {
+		ack, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatalf("Failed to receive ack: %v", err)
+		}
+		fmt.Printf("Ack: %d accepted, %d rejected, %d total\n",
+			len(ack.AcceptedEventIds), len(ack.Rejected), ack.TotalAccepted)
+	}
+
+	fmt.Println("\nTracking anonymous visitor and identifying")
+	anonymousID := "anon-" + uuid.New().String()
+	_, err = client.TrackEvent(context.Background(), &pb.TrackEventRequest{
+		Event: &pb.Event{
+			EventId:     uuid.New().String(),
+			EventType:   pb.EventType_EVENT_TYPE_PAGE_VIEW,
+			AnonymousId: anonymousID,
+			SessionId:   sessionID,
+			Metadata:    map[string]string{"page": "/"},
+			Timestamp:   timestamppb.Now(),
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to track anonymous event: %v", err)
+	}
+
+	identifyResp, err := client.Identify(context.Background(), &pb.IdentifyRequest{
+		AnonymousId: anonymousID,
+		UserId:      userID,
+	})
+	if err != nil {
+		log.Fatalf("Failed to identify: %v", err)
+	}
+	fmt.Printf("Identified %s as %s (created: %v)\n", identifyResp.AnonymousId, identifyResp.UserId, identifyResp.Created)
+
 	fmt.Println("\nAll tests passed")
 }
+
+func mustStruct(values map[string]any) *structpb.Struct {
+	s, err := structpb.NewStruct(values)
+	if err != nil {
+		log.Fatalf("Invalid properties: %v", err)
+	}
+	return s
+}
@@ -0,0 +1,177 @@
+package analytics
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OtherDimensionValue заменяет значения измерения сверх лимита кардинальности
+const OtherDimensionValue = "__other__"
+
+// Значения длиннее обрезаются, чтобы ключ строки не рос бесконечно
+const maxDimensionValueLength = 100
+
+// Dimension - ключ data, по которому analytics-service агрегирует события.
+// Вложенные поля задаются через точку: "context.device".
+type Dimension struct {
+	Name string
+	// Сколько разных значений хранится, остальные попадают в OtherDimensionValue
+	MaxValues int
+}
+
+// Dimensions - значения измерений события, в БД это JSONB объект
+type Dimensions map[string]string
+
+func (d Dimensions) Value() (driver.Value, error) {
+	if d == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(d)
+}
+
+func (d *Dimensions) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Dimensions{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("unsupported type for dimensions: %T", src)
+	}
+}
+
+// DimensionSummary - счётчики часового бакета для одной комбинации значений измерений
+type DimensionSummary struct {
+	Date        time.Time  `db:"date" json:"date"`
+	Hour        int        `db:"hour" json:"hour"`
+	EventType   string     `db:"event_type" json:"event_type"`
+	Dimensions  Dimensions `db:"dimensions" json:"dimensions"`
+	TotalEvents int64      `db:"total_events" json:"total_events"`
+	UniqueUsers int64      `db:"unique_users" json:"unique_users"`
+	UsersSketch []byte     `db:"users_sketch" json:"-"`
+}
+
+// DimensionUpdate - вклад события в analytics_dimensions
+type DimensionUpdate struct {
+	Date       time.Time
+	Hour       int
+	EventType  string
+	Dimensions Dimensions
+	UserID     string
+}
+
+// DimensionLimiter следит за кардинальностью измерений. Известные значения
+// хранятся в dimension_values; новое значение регистрируется, пока не
+// достигнут лимит. Несколько реплик могут немного превысить лимит.
+type DimensionLimiter struct {
+	repo       Repository
+	dimensions []Dimension
+	logger     *zap.Logger
+
+	mu     sync.Mutex
+	values map[string]map[string]bool
+}
+
+func NewDimensionLimiter(repo Repository, dimensions []Dimension, logger *zap.Logger) *DimensionLimiter {
+	return &DimensionLimiter{
+		repo:       repo,
+		dimensions: dimensions,
+		logger:     logger,
+		values:     make(map[string]map[string]bool),
+	}
+}
+
+// Load читает уже зарегистрированные значения измерений
+func (l *DimensionLimiter) Load(ctx context.Context) error {
+	if len(l.dimensions) == 0 {
+		return nil
+	}
+
+	stored, err := l.repo.GetDimensionValues(ctx)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]map[string]bool, len(stored))
+	for dimension, dimensionValues := range stored {
+		set := make(map[string]bool, len(dimensionValues))
+		for _, value := range dimensionValues {
+			set[value] = true
+		}
+		values[dimension] = set
+	}
+
+	l.mu.Lock()
+	l.values = values
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Resolve достаёт значения измерений из data события. Отсутствующие
+// измерения в результат не попадают.
+func (l *DimensionLimiter) Resolve(ctx context.Context, data map[string]any) Dimensions {
+	dims := make(Dimensions, len(l.dimensions))
+	for _, dimension := range l.dimensions {
+		value := stringValue(lookupPath(data, dimension.Name))
+		if value == "" {
+			continue
+		}
+		if runes := []rune(value); len(runes) > maxDimensionValueLength {
+			value = string(runes[:maxDimensionValueLength])
+		}
+		dims[dimension.Name] = l.admit(ctx, dimension, value)
+	}
+	return dims
+}
+
+func (l *DimensionLimiter) admit(ctx context.Context, dimension Dimension, value string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	set, ok := l.values[dimension.Name]
+	if !ok {
+		set = make(map[string]bool)
+		l.values[dimension.Name] = set
+	}
+	if set[value] {
+		return value
+	}
+
+	if len(set) >= dimension.MaxValues {
+		dimensionOverflow.WithLabelValues(dimension.Name).Inc()
+		return OtherDimensionValue
+	}
+
+	if err := l.repo.AddDimensionValue(ctx, dimension.Name, value); err != nil {
+		// Значение всё равно учитываем, после перезапуска оно пропадёт из кэша
+		l.logger.Warn("Failed to register dimension value",
+			zap.Error(err),
+			zap.String("dimension", dimension.Name),
+		)
+	}
+	set[value] = true
+	return value
+}
+
+func lookupPath(data map[string]any, path string) any {
+	var current any = data
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = obj[key]
+	}
+	return current
+}
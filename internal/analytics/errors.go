@@ -0,0 +1,15 @@
+package analytics
+
+import "errors"
+
+var (
+	ErrOffsetAlreadyProcessed = errors.New("offset already processed")
+
+	ErrInvalidRankBy = errors.New("invalid rank_by")
+
+	ErrInvalidPeriod = errors.New("invalid retention period")
+
+	ErrInvalidBreakdown = errors.New("invalid revenue breakdown")
+
+	ErrInvalidGranularity = errors.New("invalid stats granularity")
+)
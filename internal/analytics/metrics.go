@@ -0,0 +1,33 @@
+package analytics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	revenueSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "analytics_revenue_skipped_total",
+		Help: "Order events not counted in revenue by reason",
+	}, []string{"reason"})
+
+	dimensionOverflow = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "analytics_dimension_overflow_total",
+		Help: "Dimension values replaced with " + OtherDimensionValue + " because of the cardinality limit",
+	}, []string{"dimension"})
+)
+
+func revenueSkipReason(err error) string {
+	switch {
+	case errors.Is(err, errNoAmount):
+		return "no_amount"
+	case errors.Is(err, errInvalidAmount):
+		return "invalid_amount"
+	case errors.Is(err, errUnknownCurrency):
+		return "unknown_currency"
+	default:
+		return "other"
+	}
+}
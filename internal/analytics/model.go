@@ -3,6 +3,8 @@ package analytics
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/hll"
 )
 
 type Summary struct {
@@ -12,6 +14,7 @@ type Summary struct {
 	EventType   string          `db:"event_type" json:"event_type"`
 	TotalEvents int64           `db:"total_events" json:"total_events"`
 	UniqueUsers int64           `db:"unique_users" json:"unique_users"`
+	UsersSketch []byte          `db:"users_sketch" json:"-"`
 	Metadata    json.RawMessage `db:"metadata" json:"metadata,omitempty"`
 	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
 }
@@ -37,12 +40,158 @@ func (s *Summary) SetUniqueUsers(count int64) {
 	s.UpdatedAt = time.Now().UTC()
 }
 
+// AddUser добавляет пользователя в HLL sketch бакета
+func (s *Summary) AddUser(userID string) error {
+	sketch, err := hll.FromBytes(s.UsersSketch)
+	if err != nil {
+		return err
+	}
+	sketch.Add(userID)
+	return s.setSketch(sketch)
+}
+
+// MergeUsers объединяет sketch бакета с другим sketch того же бакета
+func (s *Summary) MergeUsers(other []byte) error {
+	sketch, err := hll.FromBytes(s.UsersSketch)
+	if err != nil {
+		return err
+	}
+
+	otherSketch, err := hll.FromBytes(other)
+	if err != nil {
+		return err
+	}
+
+	if err := sketch.Merge(otherSketch); err != nil {
+		return err
+	}
+	return s.setSketch(sketch)
+}
+
+func (s *Summary) setSketch(sketch *hll.Sketch) error {
+	data, err := sketch.Bytes()
+	if err != nil {
+		return err
+	}
+
+	s.UsersSketch = data
+	s.SetUniqueUsers(sketch.Estimate())
+	return nil
+}
+
+// addToSketch добавляет пользователя в сохранённый sketch и возвращает новый
+// sketch с оценкой числа уникальных пользователей
+func addToSketch(stored []byte, userID string) ([]byte, int64, error) {
+	sketch, err := hll.FromBytes(stored)
+	if err != nil {
+		return nil, 0, err
+	}
+	sketch.Add(userID)
+
+	data, err := sketch.Bytes()
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, sketch.Estimate(), nil
+}
+
+// EventUpdate - всё, что одно событие меняет в агрегатах
+type EventUpdate struct {
+	Summary *Summary
+	Minute  *MinuteUpdate
+	Session *SessionEvent
+	Revenue *RevenueUpdate
+	// nil, если измерения не настроены
+	Dimensions *DimensionUpdate
+}
+
+// SessionEvent - вклад события в сессию
+type SessionEvent struct {
+	SessionID string
+	UserID    string
+	At        time.Time
+	EventType string
+	Page      *string
+	Converted bool
+
+	// Открытая сессия продолжается, только если её последнее событие не раньше IdleCutoff
+	IdleCutoff time.Time
+}
+
+func (e *SessionEvent) PageViews() int {
+	if e.EventType == EventTypePageView {
+		return 1
+	}
+	return 0
+}
+
+// SessionStats - метрики сессий за бакет
+type SessionStats struct {
+	Bucket             time.Time `db:"bucket" json:"bucket"`
+	Sessions           int64     `db:"sessions" json:"sessions"`
+	Users              int64     `db:"users" json:"users"`
+	AvgDurationSeconds float64   `db:"avg_duration_seconds" json:"avg_duration_seconds"`
+	BounceRate         float64   `db:"bounce_rate" json:"bounce_rate"`
+	ConversionRate     float64   `db:"conversion_rate" json:"conversion_rate"`
+	AvgEvents          float64   `db:"avg_events" json:"avg_events"`
+}
+
+const (
+	EventTypePageView = "page_view"
+	EventTypePurchase = "purchase"
+)
+
+// StatsChannel - канал LISTEN/NOTIFY, в который уходит каждое обновление бакета
+const StatsChannel = "event_stats"
+
+// StatsNotification - payload NOTIFY с абсолютными значениями бакета после обновления.
+// Часовой бакет задают Date и Hour, минутный - Bucket.
+type StatsNotification struct {
+	Granularity string    `json:"granularity"`
+	Date        time.Time `json:"date"`
+	Hour        int       `json:"hour"`
+	Bucket      time.Time `json:"bucket"`
+	EventType   string    `json:"event_type"`
+	TotalEvents int64     `json:"total_events"`
+	UniqueUsers int64     `json:"unique_users"`
+}
+
+// PartitionOffset - последний offset партиции, учтённый в analytics_summary
+type PartitionOffset struct {
+	Topic         string    `db:"topic" json:"topic"`
+	Partition     int32     `db:"partition" json:"partition"`
+	Offset        int64     `db:"offset" json:"offset"`
+	ConsumerGroup string    `db:"consumer_group" json:"consumer_group"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// RetentionQuery описывает cohort-анализ: cohort - пользователи, у которых первое
+// событие (или первое событие CohortEventType) попало в [From, To]
+type RetentionQuery struct {
+	From            time.Time
+	To              time.Time
+	Period          string
+	Periods         int
+	CohortEventType string
+	ReturnEventType string
+}
+
+// RetentionRow - одна ячейка матрицы retention
+type RetentionRow struct {
+	CohortStart  time.Time `db:"cohort_start" json:"cohort_start"`
+	PeriodNumber int       `db:"period_number" json:"period_number"`
+	CohortSize   int64     `db:"cohort_size" json:"cohort_size"`
+	Users        int64     `db:"users" json:"users"`
+}
+
 type EventData struct {
-	ID        string                 `json:"id"`
-	EventType string                 `json:"event_type"`
-	UserID    string                 `json:"user_id"`
-	SessionID string                 `json:"session_id"`
-	ProductID *string                `json:"product_id,omitempty"`
-	Data      map[string]interface{} `json:"data"`
-	CreatedAt time.Time              `json:"created_at"`
+	ID        string `json:"id"`
+	EventType string `json:"event_type"`
+	UserID    string `json:"user_id"`
+	// Id посетителя до логина, события с ним без user_id лежат под анонимным UUID
+	AnonymousID string                 `json:"anonymous_id,omitempty"`
+	SessionID   string                 `json:"session_id"`
+	ProductID   *string                `json:"product_id,omitempty"`
+	Data        map[string]interface{} `json:"data"`
+	CreatedAt   time.Time              `json:"created_at"`
 }
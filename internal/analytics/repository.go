@@ -2,18 +2,46 @@ package analytics
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Wuchinator/realtime-analytics/pkg/hll"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
 type Repository interface {
 	UpsertSummary(ctx context.Context, summary *Summary) error
+	ApplyEvent(ctx context.Context, update *EventUpdate, offset *PartitionOffset) error
+	CloseIdleSessions(ctx context.Context, idleBefore time.Time) (int64, error)
+	GetSessionStats(ctx context.Context, from, to time.Time, granularity string) ([]*SessionStats, error)
+	SaveOffset(ctx context.Context, offset *PartitionOffset) error
+	GetOffsets(ctx context.Context, topic, consumerGroup string) (map[int32]int64, error)
 	GetSummary(ctx context.Context, date time.Time, hour int, eventType string) (*Summary, error)
 	GetSummariesByDateRange(ctx context.Context, from, to time.Time, eventType string) ([]*Summary, error)
-	GetTopProducts(ctx context.Context, from, to time.Time, limit int) ([]*ProductStats, error)
+	GetHourSummaries(ctx context.Context, date time.Time, hour int) ([]*Summary, error)
+	GetTopProducts(ctx context.Context, from, to time.Time, limit int, eventType, rankBy string) ([]*ProductStats, error)
+	GetRetention(ctx context.Context, q *RetentionQuery) ([]*RetentionRow, error)
+	GetCachedRetention(ctx context.Context, q *RetentionQuery) ([]*RetentionRow, error)
+	RefreshRetention(ctx context.Context, q *RetentionQuery) error
+	GetCurrencyRates(ctx context.Context) (map[string]float64, error)
+	GetRevenueSummaries(ctx context.Context, from, to time.Time) ([]*RevenueSummary, error)
+	GetRevenueBreakdown(ctx context.Context, from, to time.Time, breakdown string, limit int) ([]*RevenueBreakdown, error)
+	GetDimensionValues(ctx context.Context) (map[string][]string, error)
+	AddDimensionValue(ctx context.Context, dimension, value string) error
+	GetDimensionSummaries(ctx context.Context, from, to time.Time, eventType string, filters Dimensions) ([]*DimensionSummary, error)
+	GetStatsBuckets(ctx context.Context, granularity string, from, to time.Time, eventType string) ([]*StatsBucket, error)
+	GetUpdatedSummaries(ctx context.Context, granularity string, since time.Time) ([]*Summary, error)
+	GetRollupWatermark(ctx context.Context, granularity string) (time.Time, error)
+	SaveRollup(ctx context.Context, granularity string, buckets []*StatsBucket, watermark time.Time) error
+	DeleteMinuteBuckets(ctx context.Context, before time.Time) (int64, error)
+	ResolveUserID(ctx context.Context, userID string) (string, error)
+	DropUserSketches(ctx context.Context, userID string) (int64, error)
+	PurgeUser(ctx context.Context, userID string, offset *PartitionOffset) (int64, error)
 }
 
 type ProductStats struct {
@@ -21,6 +49,39 @@ type ProductStats struct {
 	EventCount     int64   `db:"event_count" json:"event_count"`
 	UniqueUsers    int64   `db:"unique_users" json:"unique_users"`
 	ConversionRate float64 `db:"conversion_rate" json:"conversion_rate"`
+	Views          int64   `db:"views" json:"views"`
+	AddToCarts     int64   `db:"add_to_carts" json:"add_to_carts"`
+	Purchases      int64   `db:"purchases" json:"purchases"`
+	AddToCartRate  float64 `db:"add_to_cart_rate" json:"add_to_cart_rate"`
+	Revenue        float64 `db:"revenue" json:"revenue"`
+}
+
+const (
+	RankByEvents        = "events"
+	RankByPurchases     = "purchases"
+	RankByAddToCartRate = "add_to_cart_rate"
+	RankByRevenue       = "revenue"
+)
+
+// Колонки сортировки для GetTopProducts, в SQL подставляются только отсюда
+var productRankColumns = map[string]string{
+	RankByEvents:        "event_count",
+	RankByPurchases:     "purchases",
+	RankByAddToCartRate: "add_to_cart_rate",
+	RankByRevenue:       "revenue",
+}
+
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Номер периода события относительно начала cohort, ts - время события в UTC
+var retentionPeriodNumbers = map[string]string{
+	PeriodDay:   "(date_trunc('day', ts)::date - cohort_start)",
+	PeriodWeek:  "(date_trunc('week', ts)::date - cohort_start) / 7",
+	PeriodMonth: "((EXTRACT(YEAR FROM ts)::int - EXTRACT(YEAR FROM cohort_start)::int) * 12 + EXTRACT(MONTH FROM ts)::int - EXTRACT(MONTH FROM cohort_start)::int)",
 }
 
 type repository struct {
@@ -36,19 +97,431 @@ func NewRepository(db *sqlx.DB, logger *zap.Logger) Repository {
 }
 
 func (r *repository) UpsertSummary(ctx context.Context, summary *Summary) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	if err := r.upsertSummary(ctx, tx, summary); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	r.logger.Debug("Summary upserted",
+		zap.String("date", summary.Date.Format("2006-01-02")),
+		zap.Int("hour", summary.Hour),
+		zap.String("event_type", summary.EventType),
+		zap.Int64("total_events", summary.TotalEvents),
+	)
+
+	return nil
+}
+
+// ApplyEvent применяет все изменения агрегатов от одного события в одной транзакции.
+// Если передан offset, он сдвигается в той же транзакции; уже учтённый offset
+// даёт ErrOffsetAlreadyProcessed без изменений.
+func (r *repository) ApplyEvent(ctx context.Context, update *EventUpdate, offset *PartitionOffset) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	if offset != nil {
+		if err := r.saveOffset(ctx, tx, offset); err != nil {
+			return err
+		}
+	}
+
+	if err := r.upsertSummary(ctx, tx, update.Summary); err != nil {
+		return err
+	}
+
+	if update.Minute != nil {
+		if err := r.applyMinute(ctx, tx, update.Minute); err != nil {
+			return err
+		}
+	}
+
+	if update.Session != nil {
+		if err := r.applySession(ctx, tx, update.Session); err != nil {
+			return err
+		}
+	}
+
+	if update.Revenue != nil {
+		if err := r.applyRevenue(ctx, tx, update.Revenue); err != nil {
+			return err
+		}
+	}
+
+	if update.Dimensions != nil {
+		if err := r.applyDimensions(ctx, tx, update.Dimensions); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	fields := []zap.Field{
+		zap.String("date", update.Summary.Date.Format("2006-01-02")),
+		zap.Int("hour", update.Summary.Hour),
+		zap.String("event_type", update.Summary.EventType),
+	}
+	if offset != nil {
+		fields = append(fields,
+			zap.Int32("partition", offset.Partition),
+			zap.Int64("offset", offset.Offset),
+		)
+	}
+	r.logger.Debug("Event applied", fields...)
+
+	return nil
+}
+
+func (r *repository) saveOffset(ctx context.Context, tx *sqlx.Tx, offset *PartitionOffset) error {
+	// Строка offset блокируется до конца транзакции, условие WHERE отсекает повторы
 	query := `
-		INSERT INTO analytics_summary (date, hour, event_type, total_events, unique_users, metadata, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (date, hour, event_type) 
+		INSERT INTO processed_offsets (topic, partition, "offset", consumer_group, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (topic, partition, consumer_group)
 		DO UPDATE SET
-			total_events = analytics_summary.total_events + EXCLUDED.total_events,
-			unique_users = EXCLUDED.unique_users,
-			metadata = EXCLUDED.metadata,
+			"offset" = EXCLUDED."offset",
 			updated_at = EXCLUDED.updated_at
-		RETURNING id
+		WHERE processed_offsets."offset" < EXCLUDED."offset"
 	`
 
-	err := r.db.QueryRowContext(
+	result, err := tx.ExecContext(
+		ctx,
+		query,
+		offset.Topic,
+		offset.Partition,
+		offset.Offset,
+		offset.ConsumerGroup,
+		offset.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save offset: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrOffsetAlreadyProcessed
+	}
+
+	return nil
+}
+
+// applySession продлевает открытую сессию или начинает новую, если прошлая
+// простаивала дольше таймаута. Закрытие по таймауту делает CloseIdleSessions.
+func (r *repository) applySession(ctx context.Context, tx *sqlx.Tx, event *SessionEvent) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE sessions
+		SET
+			started_at = LEAST(started_at, $2),
+			last_event_at = GREATEST(last_event_at, $2),
+			event_count = event_count + 1,
+			page_views = page_views + $3,
+			landing_page = CASE
+				WHEN $4::text IS NOT NULL AND (landing_page IS NULL OR $2 < started_at) THEN $4::text
+				ELSE landing_page END,
+			exit_page = CASE
+				WHEN $4::text IS NOT NULL AND (exit_page IS NULL OR $2 >= last_event_at) THEN $4::text
+				ELSE exit_page END,
+			converted = converted OR $5
+		WHERE session_id = $1
+		  AND ended_at IS NULL
+		  AND last_event_at >= $6
+	`, event.SessionID, event.At, event.PageViews(), event.Page, event.Converted, event.IdleCutoff)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	// Открытая сессия с этим session_id простаивала дольше таймаута - закрываем её
+	_, err = tx.ExecContext(ctx, `
+		UPDATE sessions
+		SET
+			ended_at = last_event_at,
+			duration_seconds = EXTRACT(EPOCH FROM last_event_at - started_at)::bigint,
+			bounced = event_count <= 1
+		WHERE session_id = $1 AND ended_at IS NULL
+	`, event.SessionID)
+	if err != nil {
+		return fmt.Errorf("failed to close session: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO sessions
+			(session_id, user_id, started_at, last_event_at, event_count, page_views, landing_page, exit_page, converted)
+		VALUES ($1, $2, $3, $3, 1, $4, $5, $5, $6)
+	`, event.SessionID, event.UserID, event.At, event.PageViews(), event.Page, event.Converted)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveUserID возвращает user_id, с которым связан анонимный посетитель
+// userID, или сам userID, если связи нет
+func (r *repository) ResolveUserID(ctx context.Context, userID string) (string, error) {
+	var resolved string
+	err := r.db.GetContext(ctx, &resolved, `
+		SELECT user_id::text FROM identity_links WHERE anonymous_user_id = $1::uuid
+	`, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return userID, nil
+		}
+		return "", fmt.Errorf("failed to resolve user id: %w", err)
+	}
+
+	return resolved, nil
+}
+
+// PurgeUser удаляет сессии пользователя. Offset tombstone сохраняется в той же
+// транзакции, как в ApplyEvent.
+func (r *repository) PurgeUser(ctx context.Context, userID string, offset *PartitionOffset) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	if offset != nil {
+		if err := r.saveOffset(ctx, tx, offset); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM sessions WHERE user_id = $1::uuid
+	`, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// userSketches - HLL sketches, в которые попадает user_id
+var userSketches = []struct {
+	table  string
+	column string
+}{
+	{"analytics_summary", "users_sketch"},
+	{"analytics_minute", "users_sketch"},
+	{"analytics_dimensions", "users_sketch"},
+	{"analytics_daily", "users_sketch"},
+	{"analytics_weekly", "users_sketch"},
+	{"analytics_monthly", "users_sketch"},
+	{"revenue_summary", "buyers_sketch"},
+}
+
+// DropUserSketches обнуляет sketches, в которых может быть userID, и
+// возвращает их количество. Удалить значение из HLL нельзя, поэтому бакет
+// теряет sketch целиком, а unique_users сохраняет последнюю оценку.
+// Каждая таблица обрабатывается в своей транзакции под блокировкой от записи,
+// чтобы строки не менялись между проверкой и обнулением.
+func (r *repository) DropUserSketches(ctx context.Context, userID string) (int64, error) {
+	var dropped int64
+	for _, s := range userSketches {
+		n, err := r.dropUserSketches(ctx, s.table, s.column, userID)
+		if err != nil {
+			return dropped, err
+		}
+		dropped += n
+	}
+	return dropped, nil
+}
+
+func (r *repository) dropUserSketches(ctx context.Context, table, column, userID string) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`LOCK TABLE %s IN SHARE ROW EXCLUSIVE MODE`, table)); err != nil {
+		return 0, fmt.Errorf("failed to lock %s: %w", table, err)
+	}
+
+	rows, err := tx.QueryxContext(ctx, fmt.Sprintf(`
+		SELECT ctid::text, %s FROM %s WHERE %s IS NOT NULL
+	`, column, table, column))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s sketches: %w", table, err)
+	}
+	defer rows.Close()
+
+	var matched []string
+	for rows.Next() {
+		var (
+			ctid   string
+			stored []byte
+		)
+		if err := rows.Scan(&ctid, &stored); err != nil {
+			return 0, fmt.Errorf("failed to scan %s sketch: %w", table, err)
+		}
+
+		sketch, err := hll.FromBytes(stored)
+		if err != nil {
+			return 0, fmt.Errorf("failed to decode %s sketch: %w", table, err)
+		}
+		if sketch.MayContain(userID) {
+			matched = append(matched, ctid)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate %s sketches: %w", table, err)
+	}
+	rows.Close()
+
+	if len(matched) == 0 {
+		return 0, nil
+	}
+
+	// updated_at сдвигается, чтобы rollup пересобрал периоды без этих sketches
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE %s SET %s = NULL, updated_at = NOW() WHERE ctid = ANY($1::tid[])
+	`, table, column), pq.StringArray(matched))
+	if err != nil {
+		return 0, fmt.Errorf("failed to drop %s sketches: %w", table, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(matched)), nil
+}
+
+// CloseIdleSessions закрывает сессии без событий с idleBefore
+func (r *repository) CloseIdleSessions(ctx context.Context, idleBefore time.Time) (int64, error) {
+	query := `
+		UPDATE sessions
+		SET
+			ended_at = last_event_at,
+			duration_seconds = EXTRACT(EPOCH FROM last_event_at - started_at)::bigint,
+			bounced = event_count <= 1
+		WHERE ended_at IS NULL AND last_event_at < $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, idleBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to close idle sessions: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// GetSessionStats считает метрики закрытых сессий по бакетам начала сессии
+func (r *repository) GetSessionStats(
+	ctx context.Context,
+	from, to time.Time,
+	granularity string) ([]*SessionStats, error) {
+	query := `
+		SELECT
+			date_trunc($3::text, started_at AT TIME ZONE 'UTC') AS bucket,
+			COUNT(*) AS sessions,
+			COUNT(DISTINCT COALESCE(l.user_id, s.user_id)) AS users,
+			COALESCE(AVG(duration_seconds), 0)::float8 AS avg_duration_seconds,
+			COALESCE(AVG(CASE WHEN bounced THEN 1 ELSE 0 END), 0)::float8 AS bounce_rate,
+			COALESCE(AVG(CASE WHEN converted THEN 1 ELSE 0 END), 0)::float8 AS conversion_rate,
+			COALESCE(AVG(event_count), 0)::float8 AS avg_events
+		FROM sessions s
+		-- Сессии до логина принадлежат тому же пользователю, что и после
+		LEFT JOIN identity_links l ON l.anonymous_user_id = s.user_id
+		WHERE ended_at IS NOT NULL
+		  AND started_at >= $1
+		  AND started_at <= $2
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+
+	var stats []*SessionStats
+	if err := r.db.SelectContext(ctx, &stats, query, from, to, granularity); err != nil {
+		return nil, fmt.Errorf("failed to get session stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// upsertSummary прибавляет счётчики summary к бакету и сливает HLL sketch
+// уникальных пользователей с уже сохранённым. Строка бакета блокируется
+// до конца транзакции, поэтому несколько consumer'ов не затрут sketch друг друга.
+func (r *repository) upsertSummary(ctx context.Context, tx *sqlx.Tx, summary *Summary) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO analytics_summary (date, hour, event_type, total_events, unique_users, updated_at)
+		VALUES ($1, $2, $3, 0, 0, $4)
+		ON CONFLICT (date, hour, event_type) DO NOTHING
+	`, summary.Date, summary.Hour, summary.EventType, summary.UpdatedAt)
+	if err != nil {
+		r.logger.Error("Failed to upsert summary", zap.Error(err))
+		return fmt.Errorf("failed to upsert summary: %w", err)
+	}
+
+	var stored []byte
+	err = tx.QueryRowxContext(ctx, `
+		SELECT users_sketch
+		FROM analytics_summary
+		WHERE date = $1 AND hour = $2 AND event_type = $3
+		FOR UPDATE
+	`, summary.Date, summary.Hour, summary.EventType).Scan(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to lock summary: %w", err)
+	}
+
+	if err := summary.MergeUsers(stored); err != nil {
+		return fmt.Errorf("failed to merge users sketch: %w", err)
+	}
+
+	query := `
+		UPDATE analytics_summary
+		SET
+			total_events = total_events + $4,
+			unique_users = GREATEST(unique_users, $5),
+			users_sketch = $6,
+			metadata = COALESCE($7, metadata),
+			updated_at = $8
+		WHERE date = $1 AND hour = $2 AND event_type = $3
+		RETURNING id, total_events
+	`
+
+	var totalEvents int64
+	err = tx.QueryRowxContext(
 		ctx,
 		query,
 		summary.Date,
@@ -56,32 +529,84 @@ func (r *repository) UpsertSummary(ctx context.Context, summary *Summary) error
 		summary.EventType,
 		summary.TotalEvents,
 		summary.UniqueUsers,
+		summary.UsersSketch,
 		summary.Metadata,
 		summary.UpdatedAt,
-	).Scan(&summary.ID)
+	).Scan(&summary.ID, &totalEvents)
 
 	if err != nil {
 		r.logger.Error("Failed to upsert summary", zap.Error(err))
 		return fmt.Errorf("failed to upsert summary: %w", err)
 	}
 
-	r.logger.Debug("Summary upserted",
-		zap.String("date", summary.Date.Format("2006-01-02")),
-		zap.Int("hour", summary.Hour),
-		zap.String("event_type", summary.EventType),
-		zap.Int64("total_events", summary.TotalEvents),
-	)
+	// NOTIFY доставляется подписчикам только после commit транзакции
+	payload, err := json.Marshal(&StatsNotification{
+		Granularity: GranularityHour,
+		Date:        summary.Date,
+		Hour:        summary.Hour,
+		EventType:   summary.EventType,
+		TotalEvents: totalEvents,
+		UniqueUsers: summary.UniqueUsers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats notification: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", StatsChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to notify stats update: %w", err)
+	}
 
 	return nil
 }
 
+// SaveOffset сдвигает offset без учёта события (сообщение ушло в DLQ)
+func (r *repository) SaveOffset(ctx context.Context, offset *PartitionOffset) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.saveOffset(ctx, tx, offset); err != nil {
+		if errors.Is(err, ErrOffsetAlreadyProcessed) {
+			return nil
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) GetOffsets(ctx context.Context, topic, consumerGroup string) (map[int32]int64, error) {
+	query := `
+		SELECT topic, partition, "offset", consumer_group, updated_at
+		FROM processed_offsets
+		WHERE topic = $1 AND consumer_group = $2
+	`
+
+	var rows []*PartitionOffset
+	if err := r.db.SelectContext(ctx, &rows, query, topic, consumerGroup); err != nil {
+		return nil, fmt.Errorf("failed to get offsets: %w", err)
+	}
+
+	offsets := make(map[int32]int64, len(rows))
+	for _, row := range rows {
+		offsets[row.Partition] = row.Offset
+	}
+
+	return offsets, nil
+}
+
 func (r *repository) GetSummary(
 	ctx context.Context,
 	date time.Time,
 	hour int,
 	eventType string) (*Summary, error) {
 	query := `
-		SELECT id, date, hour, event_type, total_events, unique_users, metadata, updated_at
+		SELECT id, date, hour, event_type, total_events, unique_users, users_sketch, metadata, updated_at
 		FROM analytics_summary
 		WHERE date = $1 AND hour = $2 AND event_type = $3
 	`
@@ -100,7 +625,7 @@ func (r *repository) GetSummariesByDateRange(
 	from, to time.Time,
 	eventType string) ([]*Summary, error) {
 	query := `
-		SELECT id, date, hour, event_type, total_events, unique_users, metadata, updated_at
+		SELECT id, date, hour, event_type, total_events, unique_users, users_sketch, metadata, updated_at
 		FROM analytics_summary
 		WHERE date >= $1 AND date <= $2
 	`
@@ -122,47 +647,721 @@ func (r *repository) GetSummariesByDateRange(
 	return summaries, nil
 }
 
+// GetHourSummaries возвращает бакеты одного часа по всем типам событий
+func (r *repository) GetHourSummaries(ctx context.Context, date time.Time, hour int) ([]*Summary, error) {
+	var summaries []*Summary
+	err := r.db.SelectContext(ctx, &summaries, `
+		SELECT id, date, hour, event_type, total_events, unique_users, users_sketch, metadata, updated_at
+		FROM analytics_summary
+		WHERE date = $1 AND hour = $2
+		ORDER BY event_type
+	`, date, hour)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hour summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// GetTopProducts ранжирует товары. Фильтр eventType ограничивает event_count
+// и unique_users, конверсия всегда считается по всем событиям товара:
+// купившие / просмотревшие (уникальные пользователи). Выручка берётся из
+// revenue_items с точностью до часа, как в GetRevenueBreakdown.
 func (r *repository) GetTopProducts(
 	ctx context.Context,
 	from, to time.Time,
-	limit int) ([]*ProductStats, error) {
-	query := `
+	limit int,
+	eventType, rankBy string) ([]*ProductStats, error) {
+	if rankBy == "" {
+		rankBy = RankByEvents
+	}
+
+	rankColumn, ok := productRankColumns[rankBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidRankBy, rankBy)
+	}
+
+	query := fmt.Sprintf(`
 		WITH product_events AS (
 			SELECT 
-				product_id,
+				product_id::text AS product_id,
 				event_type,
-				user_id,
-				COUNT(*) as event_count
-			FROM events
+				user_id
+			FROM resolved_events
 			WHERE 
 				product_id IS NOT NULL
 				AND created_at >= $1 
 				AND created_at <= $2
-			GROUP BY product_id, event_type, user_id
+		),
+		-- Выручка из revenue_items: ключи цены и количества из RevenueConfig,
+		-- суммы уже переведены в базовую валюту по currency_rates
+		product_revenue AS (
+			SELECT product_id, SUM(revenue) AS revenue
+			FROM revenue_items
+			WHERE bucket >= date_trunc('hour', $1::timestamptz) AND bucket <= $2
+			  AND product_id <> ''
+			GROUP BY product_id
 		),
 		product_stats AS (
 			SELECT
 				product_id,
-				SUM(event_count) as total_events,
-				COUNT(DISTINCT user_id) as unique_users
+				COUNT(*) FILTER (WHERE $3::text = '' OR event_type = $3::text) AS event_count,
+				COUNT(DISTINCT user_id) FILTER (WHERE $3::text = '' OR event_type = $3::text) AS unique_users,
+				COUNT(DISTINCT user_id) FILTER (WHERE event_type = 'product_view') AS viewers,
+				COUNT(DISTINCT user_id) FILTER (WHERE event_type = 'add_to_cart') AS cart_users,
+				COUNT(DISTINCT user_id) FILTER (WHERE event_type = 'purchase') AS purchasers,
+				COUNT(*) FILTER (WHERE event_type = 'product_view') AS views,
+				COUNT(*) FILTER (WHERE event_type = 'add_to_cart') AS add_to_carts,
+				COUNT(*) FILTER (WHERE event_type = 'purchase') AS purchases
 			FROM product_events
 			GROUP BY product_id
+		),
+		ranked AS (
+			SELECT
+				product_id,
+				event_count,
+				unique_users,
+				CASE WHEN viewers > 0 THEN purchasers::float8 / viewers ELSE 0 END AS conversion_rate,
+				views,
+				add_to_carts,
+				purchases,
+				CASE WHEN viewers > 0 THEN cart_users::float8 / viewers ELSE 0 END AS add_to_cart_rate,
+				COALESCE(pr.revenue, 0)::float8 AS revenue
+			FROM product_stats ps
+			LEFT JOIN product_revenue pr USING (product_id)
+			WHERE event_count > 0
 		)
 		SELECT 
 			product_id,
-			total_events as event_count,
+			event_count,
 			unique_users,
-			0.0 as conversion_rate
-		FROM product_stats
-		ORDER BY total_events DESC
-		LIMIT $3
-	`
+			conversion_rate,
+			views,
+			add_to_carts,
+			purchases,
+			add_to_cart_rate,
+			revenue
+		FROM ranked
+		ORDER BY %s DESC, event_count DESC
+		LIMIT $4
+	`, rankColumn)
 
 	var stats []*ProductStats
-	err := r.db.SelectContext(ctx, &stats, query, from, to, limit)
+	err := r.db.SelectContext(ctx, &stats, query, from, to, eventType, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get top products: %w", err)
 	}
 
 	return stats, nil
 }
+
+// retentionQuery собирает SQL матрицы retention. Аргументы:
+// $1, $2 - диапазон cohort, $3 - cohort event type, $4 - return event type, $5 - число периодов
+func retentionQuery(period string) (string, error) {
+	periodNumber, ok := retentionPeriodNumbers[period]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPeriod, period)
+	}
+
+	return fmt.Sprintf(`
+		WITH cohort_users AS (
+			SELECT
+				user_id,
+				MIN(created_at) AS first_at,
+				date_trunc('%[1]s', MIN(created_at) AT TIME ZONE 'UTC')::date AS cohort_start
+			FROM resolved_events
+			WHERE $3::text = '' OR event_type = $3::text
+			GROUP BY user_id
+			HAVING MIN(created_at) >= $1 AND MIN(created_at) <= $2
+		),
+		cohort_sizes AS (
+			SELECT cohort_start, COUNT(*) AS cohort_size
+			FROM cohort_users
+			GROUP BY cohort_start
+		),
+		activity AS (
+			SELECT DISTINCT
+				c.user_id,
+				c.cohort_start,
+				%[2]s AS period_number
+			FROM resolved_events e
+			JOIN cohort_users c ON c.user_id = e.user_id
+			CROSS JOIN LATERAL (SELECT e.created_at AT TIME ZONE 'UTC' AS ts) t
+			WHERE ($4::text = '' OR e.event_type = $4::text)
+			  AND e.created_at >= c.first_at
+		)
+		SELECT
+			s.cohort_start,
+			COALESCE(a.period_number, 0) AS period_number,
+			s.cohort_size,
+			COUNT(a.user_id) AS users
+		FROM cohort_sizes s
+		LEFT JOIN activity a
+			ON a.cohort_start = s.cohort_start
+			AND a.period_number BETWEEN 0 AND $5
+		GROUP BY s.cohort_start, COALESCE(a.period_number, 0), s.cohort_size
+	`, period, periodNumber), nil
+}
+
+func (r *repository) GetRetention(ctx context.Context, q *RetentionQuery) ([]*RetentionRow, error) {
+	query, err := retentionQuery(q.Period)
+	if err != nil {
+		return nil, err
+	}
+	query += " ORDER BY cohort_start, period_number"
+
+	var rows []*RetentionRow
+	err = r.db.SelectContext(
+		ctx,
+		&rows,
+		query,
+		q.From,
+		q.To,
+		q.CohortEventType,
+		q.ReturnEventType,
+		q.Periods,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetCachedRetention читает кеш, только если последний пересчёт покрыл все
+// cohorts и все периоды запроса. Иначе возвращает пустой результат.
+func (r *repository) GetCachedRetention(ctx context.Context, q *RetentionQuery) ([]*RetentionRow, error) {
+	query := `
+		SELECT cohort_start, period_number, cohort_size, users
+		FROM retention_cohorts
+		WHERE period = $1
+		  AND cohort_event_type = $2
+		  AND return_event_type = $3
+		  AND cohort_start >= date_trunc($1::text, $4::timestamptz AT TIME ZONE 'UTC')::date
+		  AND cohort_start <= ($5::timestamptz AT TIME ZONE 'UTC')::date
+		  AND period_number <= $6
+		  AND EXISTS (
+			SELECT 1
+			FROM retention_refreshes f
+			WHERE f.period = $1
+			  AND f.cohort_event_type = $2
+			  AND f.return_event_type = $3
+			  AND f.covered_from <= date_trunc($1::text, $4::timestamptz AT TIME ZONE 'UTC')::date
+			  AND f.periods >= $6
+		  )
+		ORDER BY cohort_start, period_number
+	`
+
+	var rows []*RetentionRow
+	err := r.db.SelectContext(
+		ctx,
+		&rows,
+		query,
+		q.Period,
+		q.CohortEventType,
+		q.ReturnEventType,
+		q.From,
+		q.To,
+		q.Periods,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cached retention: %w", err)
+	}
+
+	return rows, nil
+}
+
+// RefreshRetention заменяет кеш cohorts, начавшимися в [q.From, q.To], и
+// запоминает покрытый диапазон. q.From должен быть началом периода, иначе
+// первая cohort посчитается не по всем пользователям.
+func (r *repository) RefreshRetention(ctx context.Context, q *RetentionQuery) error {
+	query, err := retentionQuery(q.Period)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM retention_cohorts
+		WHERE period = $1
+		  AND cohort_event_type = $2
+		  AND return_event_type = $3
+	`, q.Period, q.CohortEventType, q.ReturnEventType)
+	if err != nil {
+		return fmt.Errorf("failed to clear retention cache: %w", err)
+	}
+
+	insert := fmt.Sprintf(`
+		INSERT INTO retention_cohorts
+			(period, cohort_event_type, return_event_type, cohort_start, period_number, cohort_size, users, refreshed_at)
+		SELECT $6, $3, $4, cohort_start, period_number, cohort_size, users, NOW()
+		FROM (%s) retention
+	`, query)
+
+	_, err = tx.ExecContext(
+		ctx,
+		insert,
+		q.From,
+		q.To,
+		q.CohortEventType,
+		q.ReturnEventType,
+		q.Periods,
+		q.Period,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to refresh retention cache: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO retention_refreshes (period, cohort_event_type, return_event_type, covered_from, periods, refreshed_at)
+		VALUES ($1, $2, $3, ($4::timestamptz AT TIME ZONE 'UTC')::date, $5, NOW())
+		ON CONFLICT (period, cohort_event_type, return_event_type) DO UPDATE SET
+			covered_from = EXCLUDED.covered_from,
+			periods = EXCLUDED.periods,
+			refreshed_at = EXCLUDED.refreshed_at
+	`, q.Period, q.CohortEventType, q.ReturnEventType, q.From, q.Periods)
+	if err != nil {
+		return fmt.Errorf("failed to save retention refresh: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// applyRevenue прибавляет заказ к часовому бакету выручки и к строкам товаров.
+// Sketch покупателей сливается под блокировкой строки, как в upsertSummary.
+func (r *repository) applyRevenue(ctx context.Context, tx *sqlx.Tx, update *RevenueUpdate) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO revenue_summary (bucket)
+		VALUES ($1)
+		ON CONFLICT (bucket) DO NOTHING
+	`, update.Bucket)
+	if err != nil {
+		return fmt.Errorf("failed to upsert revenue summary: %w", err)
+	}
+
+	var stored []byte
+	err = tx.QueryRowxContext(ctx, `
+		SELECT buyers_sketch FROM revenue_summary WHERE bucket = $1 FOR UPDATE
+	`, update.Bucket).Scan(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to lock revenue summary: %w", err)
+	}
+
+	sketch, buyers, err := addToSketch(stored, update.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to merge buyers sketch: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE revenue_summary
+		SET
+			revenue = revenue + $2,
+			orders = orders + 1,
+			items = items + $3,
+			buyers = GREATEST(buyers, $4),
+			buyers_sketch = $5,
+			updated_at = NOW()
+		WHERE bucket = $1
+	`, update.Bucket, update.Revenue, update.Items, buyers, sketch)
+	if err != nil {
+		return fmt.Errorf("failed to update revenue summary: %w", err)
+	}
+
+	for _, line := range update.Lines {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO revenue_items (bucket, product_id, category, revenue, orders, items)
+			VALUES ($1, $2, $3, $4, 1, $5)
+			ON CONFLICT (bucket, product_id, category) DO UPDATE SET
+				revenue = revenue_items.revenue + EXCLUDED.revenue,
+				orders = revenue_items.orders + 1,
+				items = revenue_items.items + EXCLUDED.items
+		`, update.Bucket, line.ProductID, line.Category, line.Revenue, line.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to upsert revenue item: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *repository) GetCurrencyRates(ctx context.Context) (map[string]float64, error) {
+	var rows []struct {
+		Currency string  `db:"currency"`
+		Rate     float64 `db:"rate"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT currency, rate::float8 AS rate FROM currency_rates`); err != nil {
+		return nil, fmt.Errorf("failed to get currency rates: %w", err)
+	}
+
+	rates := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		rates[row.Currency] = row.Rate
+	}
+	return rates, nil
+}
+
+// GetRevenueSummaries возвращает часовые бакеты выручки вместе со sketch'ами
+// покупателей, чтобы их можно было слить в более крупные бакеты
+func (r *repository) GetRevenueSummaries(ctx context.Context, from, to time.Time) ([]*RevenueSummary, error) {
+	query := `
+		SELECT bucket, revenue::float8 AS revenue, orders, items, buyers, buyers_sketch
+		FROM revenue_summary
+		WHERE bucket >= date_trunc('hour', $1::timestamptz) AND bucket <= $2
+		ORDER BY bucket
+	`
+
+	var summaries []*RevenueSummary
+	if err := r.db.SelectContext(ctx, &summaries, query, from, to); err != nil {
+		return nil, fmt.Errorf("failed to get revenue summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// GetRevenueBreakdown возвращает top limit товаров или категорий по выручке.
+// Заказы без товара или категории в разбивку не попадают.
+func (r *repository) GetRevenueBreakdown(
+	ctx context.Context,
+	from, to time.Time,
+	breakdown string,
+	limit int) ([]*RevenueBreakdown, error) {
+	column, ok := breakdownColumns[breakdown]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidBreakdown, breakdown)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%[1]s AS key,
+			SUM(revenue)::float8 AS revenue,
+			SUM(orders) AS orders,
+			SUM(items) AS items
+		FROM revenue_items
+		WHERE bucket >= date_trunc('hour', $1::timestamptz) AND bucket <= $2
+		  AND %[1]s <> ''
+		GROUP BY %[1]s
+		ORDER BY revenue DESC, key
+		LIMIT $3
+	`, column)
+
+	var rows []*RevenueBreakdown
+	if err := r.db.SelectContext(ctx, &rows, query, from, to, limit); err != nil {
+		return nil, fmt.Errorf("failed to get revenue breakdown: %w", err)
+	}
+
+	return rows, nil
+}
+
+// applyDimensions прибавляет событие к строке его комбинации измерений,
+// sketch пользователей сливается под блокировкой строки
+func (r *repository) applyDimensions(ctx context.Context, tx *sqlx.Tx, update *DimensionUpdate) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO analytics_dimensions (date, hour, event_type, dimensions)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (date, hour, event_type, dimensions) DO NOTHING
+	`, update.Date, update.Hour, update.EventType, update.Dimensions)
+	if err != nil {
+		return fmt.Errorf("failed to upsert dimensions summary: %w", err)
+	}
+
+	var stored []byte
+	err = tx.QueryRowxContext(ctx, `
+		SELECT users_sketch
+		FROM analytics_dimensions
+		WHERE date = $1 AND hour = $2 AND event_type = $3 AND dimensions = $4
+		FOR UPDATE
+	`, update.Date, update.Hour, update.EventType, update.Dimensions).Scan(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to lock dimensions summary: %w", err)
+	}
+
+	sketch, users, err := addToSketch(stored, update.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to merge users sketch: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE analytics_dimensions
+		SET
+			total_events = total_events + 1,
+			unique_users = GREATEST(unique_users, $5),
+			users_sketch = $6,
+			updated_at = NOW()
+		WHERE date = $1 AND hour = $2 AND event_type = $3 AND dimensions = $4
+	`, update.Date, update.Hour, update.EventType, update.Dimensions, users, sketch)
+	if err != nil {
+		return fmt.Errorf("failed to update dimensions summary: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) GetDimensionValues(ctx context.Context) (map[string][]string, error) {
+	var rows []struct {
+		Dimension string `db:"dimension"`
+		Value     string `db:"value"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, `SELECT dimension, value FROM dimension_values`); err != nil {
+		return nil, fmt.Errorf("failed to get dimension values: %w", err)
+	}
+
+	values := make(map[string][]string)
+	for _, row := range rows {
+		values[row.Dimension] = append(values[row.Dimension], row.Value)
+	}
+	return values, nil
+}
+
+func (r *repository) AddDimensionValue(ctx context.Context, dimension, value string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO dimension_values (dimension, value)
+		VALUES ($1, $2)
+		ON CONFLICT (dimension, value) DO NOTHING
+	`, dimension, value)
+	if err != nil {
+		return fmt.Errorf("failed to add dimension value: %w", err)
+	}
+	return nil
+}
+
+// GetDimensionSummaries возвращает часовые строки analytics_dimensions, у
+// которых значения измерений совпадают со всеми filters
+func (r *repository) GetDimensionSummaries(
+	ctx context.Context,
+	from, to time.Time,
+	eventType string,
+	filters Dimensions) ([]*DimensionSummary, error) {
+	query := `
+		SELECT date, hour, event_type, dimensions, total_events, unique_users, users_sketch
+		FROM analytics_dimensions
+		WHERE date >= $1 AND date <= $2
+		  AND dimensions @> $3
+	`
+	args := []interface{}{from, to, filters}
+
+	if eventType != "" {
+		query += " AND event_type = $4"
+		args = append(args, eventType)
+	}
+
+	query += " ORDER BY date, hour"
+
+	var summaries []*DimensionSummary
+	if err := r.db.SelectContext(ctx, &summaries, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get dimension summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// applyMinute прибавляет событие к минутному бакету, sketch пользователей
+// сливается под блокировкой строки, как в upsertSummary
+func (r *repository) applyMinute(ctx context.Context, tx *sqlx.Tx, update *MinuteUpdate) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO analytics_minute (bucket, event_type)
+		VALUES ($1, $2)
+		ON CONFLICT (bucket, event_type) DO NOTHING
+	`, update.Bucket, update.EventType)
+	if err != nil {
+		return fmt.Errorf("failed to upsert minute bucket: %w", err)
+	}
+
+	var stored []byte
+	err = tx.QueryRowxContext(ctx, `
+		SELECT users_sketch
+		FROM analytics_minute
+		WHERE bucket = $1 AND event_type = $2
+		FOR UPDATE
+	`, update.Bucket, update.EventType).Scan(&stored)
+	if err != nil {
+		return fmt.Errorf("failed to lock minute bucket: %w", err)
+	}
+
+	sketch, users, err := addToSketch(stored, update.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to merge users sketch: %w", err)
+	}
+
+	var totalEvents int64
+	err = tx.QueryRowxContext(ctx, `
+		UPDATE analytics_minute
+		SET
+			total_events = total_events + 1,
+			unique_users = GREATEST(unique_users, $3),
+			users_sketch = $4,
+			updated_at = NOW()
+		WHERE bucket = $1 AND event_type = $2
+		RETURNING total_events
+	`, update.Bucket, update.EventType, users, sketch).Scan(&totalEvents)
+	if err != nil {
+		return fmt.Errorf("failed to update minute bucket: %w", err)
+	}
+
+	payload, err := json.Marshal(&StatsNotification{
+		Granularity: GranularityMinute,
+		Bucket:      update.Bucket,
+		EventType:   update.EventType,
+		TotalEvents: totalEvents,
+		UniqueUsers: users,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal stats notification: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", StatsChannel, string(payload)); err != nil {
+		return fmt.Errorf("failed to notify stats update: %w", err)
+	}
+
+	return nil
+}
+
+// GetStatsBuckets читает бакеты минутной или rollup таблицы granularity.
+// Бакеты day/week/month - даты, как date в analytics_summary.
+func (r *repository) GetStatsBuckets(
+	ctx context.Context,
+	granularity string,
+	from, to time.Time,
+	eventType string) ([]*StatsBucket, error) {
+	table, ok := statsTables[granularity]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidGranularity, granularity)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT bucket, event_type, total_events, unique_users, users_sketch
+		FROM %s
+		WHERE bucket >= $1 AND bucket <= $2
+	`, table)
+	args := []interface{}{from, to}
+
+	if eventType != "" {
+		query += " AND event_type = $3"
+		args = append(args, eventType)
+	}
+
+	query += " ORDER BY bucket"
+
+	var buckets []*StatsBucket
+	if err := r.db.SelectContext(ctx, &buckets, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get %s stats: %w", granularity, err)
+	}
+
+	return buckets, nil
+}
+
+// GetUpdatedSummaries возвращает все часы тех бакетов granularity, в которых
+// хотя бы один час обновлялся начиная с since
+func (r *repository) GetUpdatedSummaries(ctx context.Context, granularity string, since time.Time) ([]*Summary, error) {
+	query := `
+		WITH touched AS (
+			SELECT DISTINCT date_trunc($1, date::timestamp)::date AS bucket
+			FROM analytics_summary
+			WHERE updated_at >= $2
+		)
+		SELECT s.id, s.date, s.hour, s.event_type, s.total_events, s.unique_users, s.users_sketch, s.metadata, s.updated_at
+		FROM analytics_summary s
+		JOIN touched t ON date_trunc($1, s.date::timestamp)::date = t.bucket
+		ORDER BY s.date, s.hour
+	`
+
+	var summaries []*Summary
+	if err := r.db.SelectContext(ctx, &summaries, query, granularity, since); err != nil {
+		return nil, fmt.Errorf("failed to get updated summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// GetRollupWatermark возвращает время, до которого изменения часов уже учтены
+// в rollup granularity. Нулевое время - rollup ещё не запускался.
+func (r *repository) GetRollupWatermark(ctx context.Context, granularity string) (time.Time, error) {
+	var watermark time.Time
+	err := r.db.GetContext(ctx, &watermark, `
+		SELECT rolled_up_to FROM rollup_state WHERE granularity = $1
+	`, granularity)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to get rollup watermark: %w", err)
+	}
+	return watermark, nil
+}
+
+// SaveRollup заменяет бакеты rollup таблицы и сдвигает watermark в одной транзакции
+func (r *repository) SaveRollup(
+	ctx context.Context,
+	granularity string,
+	buckets []*StatsBucket,
+	watermark time.Time) error {
+	table, ok := statsTables[granularity]
+	if !ok || granularity == GranularityMinute {
+		return fmt.Errorf("%w: %s", ErrInvalidGranularity, granularity)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		INSERT INTO %s (bucket, event_type, total_events, unique_users, users_sketch, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (bucket, event_type) DO UPDATE SET
+			total_events = EXCLUDED.total_events,
+			unique_users = EXCLUDED.unique_users,
+			users_sketch = EXCLUDED.users_sketch,
+			updated_at = EXCLUDED.updated_at
+	`, table)
+
+	for _, bucket := range buckets {
+		_, err := tx.ExecContext(ctx, query,
+			bucket.Bucket,
+			bucket.EventType,
+			bucket.TotalEvents,
+			bucket.UniqueUsers,
+			bucket.UsersSketch,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save %s rollup: %w", granularity, err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rollup_state (granularity, rolled_up_to)
+		VALUES ($1, $2)
+		ON CONFLICT (granularity) DO UPDATE SET rolled_up_to = EXCLUDED.rolled_up_to
+	`, granularity, watermark)
+	if err != nil {
+		return fmt.Errorf("failed to save rollup watermark: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *repository) DeleteMinuteBuckets(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM analytics_minute WHERE bucket < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete minute buckets: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
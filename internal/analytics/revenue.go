@@ -0,0 +1,291 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RevenueConfig - где в data события лежат суммы заказа
+type RevenueConfig struct {
+	// Типы событий, которые считаются заказами
+	EventTypes []string
+	// Сумма заказа целиком. Если её нет, выручка - сумма price * quantity позиций
+	TotalKey    string
+	PriceKey    string
+	QuantityKey string
+	CurrencyKey string
+	// Список позиций заказа (как products в Segment "Order Completed").
+	// Без него событие считается одной позицией.
+	ItemsKey     string
+	ProductIDKey string
+	CategoryKey  string
+	// Валюта, в которой хранится выручка. Заказы без валюты считаются в ней же.
+	BaseCurrency string
+}
+
+func (c RevenueConfig) isOrder(eventType string) bool {
+	return slices.Contains(c.EventTypes, eventType)
+}
+
+var (
+	errNoAmount        = errors.New("order has no amount")
+	errInvalidAmount   = errors.New("invalid order amount")
+	errUnknownCurrency = errors.New("unknown currency")
+)
+
+// OrderLine - позиция заказа в валюте заказа
+type OrderLine struct {
+	ProductID string
+	Category  string
+	Revenue   float64
+	Quantity  int64
+}
+
+type Order struct {
+	Currency string
+	Revenue  float64
+	Items    int64
+	Lines    []OrderLine
+}
+
+// parseOrder достаёт из data события сумму, валюту и позиции заказа. Числа
+// принимаются и как JSON числа, и как строки от клиентов со старой metadata.
+func (c RevenueConfig) parseOrder(eventData *EventData) (*Order, error) {
+	order := &Order{Currency: c.BaseCurrency}
+	if currency, ok := eventData.Data[c.CurrencyKey].(string); ok && currency != "" {
+		order.Currency = strings.ToUpper(strings.TrimSpace(currency))
+	}
+
+	var rawLines []map[string]any
+	if items, ok := eventData.Data[c.ItemsKey].([]any); ok {
+		for _, item := range items {
+			if line, ok := item.(map[string]any); ok {
+				rawLines = append(rawLines, line)
+			}
+		}
+	}
+
+	hasPrice := false
+	if len(rawLines) == 0 {
+		// Заказ из одного товара: product_id берётся из самого события
+		line := map[string]any{}
+		for _, key := range []string{c.PriceKey, c.QuantityKey, c.ProductIDKey, c.CategoryKey} {
+			if v, ok := eventData.Data[key]; ok {
+				line[key] = v
+			}
+		}
+		if _, ok := line[c.ProductIDKey]; !ok && eventData.ProductID != nil {
+			line[c.ProductIDKey] = *eventData.ProductID
+		}
+		rawLines = append(rawLines, line)
+	}
+
+	for _, raw := range rawLines {
+		line := OrderLine{Quantity: 1}
+
+		if v, ok := raw[c.QuantityKey]; ok {
+			quantity, ok := numberValue(v)
+			if !ok || quantity <= 0 || quantity != math.Trunc(quantity) {
+				return nil, fmt.Errorf("%w: quantity %v", errInvalidAmount, v)
+			}
+			line.Quantity = int64(quantity)
+		}
+
+		if v, ok := raw[c.PriceKey]; ok {
+			price, ok := numberValue(v)
+			if !ok || price < 0 {
+				return nil, fmt.Errorf("%w: price %v", errInvalidAmount, v)
+			}
+			line.Revenue = price * float64(line.Quantity)
+			hasPrice = true
+		}
+
+		line.ProductID = stringValue(raw[c.ProductIDKey])
+		line.Category = stringValue(raw[c.CategoryKey])
+
+		order.Lines = append(order.Lines, line)
+		order.Revenue += line.Revenue
+		order.Items += line.Quantity
+	}
+
+	if v, ok := eventData.Data[c.TotalKey]; ok {
+		total, ok := numberValue(v)
+		if !ok || total < 0 {
+			return nil, fmt.Errorf("%w: total %v", errInvalidAmount, v)
+		}
+		order.Revenue = total
+	} else if !hasPrice {
+		return nil, errNoAmount
+	}
+
+	return order, nil
+}
+
+func numberValue(v any) (float64, bool) {
+	var f float64
+	switch n := v.(type) {
+	case float64:
+		f = n
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, false
+		}
+		f = parsed
+	default:
+		return 0, false
+	}
+	return f, !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+func stringValue(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(s)
+	default:
+		return ""
+	}
+}
+
+// CurrencyRates - курсы из таблицы currency_rates. Курс - цена единицы валюты
+// в общей опорной валюте, поэтому базовую валюту можно менять без правки таблицы.
+type CurrencyRates struct {
+	mu    sync.RWMutex
+	rates map[string]float64
+}
+
+func NewCurrencyRates() *CurrencyRates {
+	return &CurrencyRates{rates: make(map[string]float64)}
+}
+
+func (r *CurrencyRates) Set(rates map[string]float64) {
+	r.mu.Lock()
+	r.rates = rates
+	r.mu.Unlock()
+}
+
+// Convert переводит amount из валюты from в to
+func (r *CurrencyRates) Convert(amount float64, from, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+
+	r.mu.RLock()
+	fromRate, fromOK := r.rates[from]
+	toRate, toOK := r.rates[to]
+	r.mu.RUnlock()
+
+	if !fromOK {
+		return 0, fmt.Errorf("%w: %s", errUnknownCurrency, from)
+	}
+	if !toOK {
+		return 0, fmt.Errorf("%w: %s", errUnknownCurrency, to)
+	}
+	return amount * fromRate / toRate, nil
+}
+
+// RevenueUpdate - вклад одного заказа в часовой бакет выручки, суммы уже в базовой валюте
+type RevenueUpdate struct {
+	Bucket  time.Time
+	UserID  string
+	Revenue float64
+	Items   int64
+	Lines   []OrderLine
+}
+
+// revenueUpdate конвертирует заказ в базовую валюту. Позиции с одинаковым
+// товаром и категорией складываются, чтобы заказ учитывался в товаре один раз.
+func (s *Service) revenueUpdate(eventData *EventData, order *Order) (*RevenueUpdate, error) {
+	base := s.cfg.Revenue.BaseCurrency
+
+	revenue, err := s.rates.Convert(order.Revenue, order.Currency, base)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &RevenueUpdate{
+		Bucket:  eventData.CreatedAt.UTC().Truncate(time.Hour),
+		UserID:  eventData.UserID,
+		Revenue: revenue,
+		Items:   order.Items,
+	}
+
+	index := make(map[[2]string]int, len(order.Lines))
+	for _, line := range order.Lines {
+		lineRevenue, err := s.rates.Convert(line.Revenue, order.Currency, base)
+		if err != nil {
+			return nil, err
+		}
+
+		key := [2]string{line.ProductID, line.Category}
+		if i, ok := index[key]; ok {
+			update.Lines[i].Revenue += lineRevenue
+			update.Lines[i].Quantity += line.Quantity
+			continue
+		}
+		index[key] = len(update.Lines)
+		update.Lines = append(update.Lines, OrderLine{
+			ProductID: line.ProductID,
+			Category:  line.Category,
+			Revenue:   lineRevenue,
+			Quantity:  line.Quantity,
+		})
+	}
+
+	return update, nil
+}
+
+// RefreshCurrencyRates перечитывает таблицу currency_rates
+func (s *Service) RefreshCurrencyRates(ctx context.Context) error {
+	rates, err := s.repo.GetCurrencyRates(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := rates[s.cfg.Revenue.BaseCurrency]; !ok && len(rates) > 0 {
+		return fmt.Errorf("%w: base currency %s has no rate", errUnknownCurrency, s.cfg.Revenue.BaseCurrency)
+	}
+
+	s.rates.Set(rates)
+	return nil
+}
+
+// RevenueSummary - часовой бакет выручки
+type RevenueSummary struct {
+	Bucket       time.Time `db:"bucket" json:"bucket"`
+	Revenue      float64   `db:"revenue" json:"revenue"`
+	Orders       int64     `db:"orders" json:"orders"`
+	Items        int64     `db:"items" json:"items"`
+	Buyers       int64     `db:"buyers" json:"buyers"`
+	BuyersSketch []byte    `db:"buyers_sketch" json:"-"`
+}
+
+// RevenueBreakdown - выручка за период по товару или категории
+type RevenueBreakdown struct {
+	Key     string  `db:"key" json:"key"`
+	Revenue float64 `db:"revenue" json:"revenue"`
+	Orders  int64   `db:"orders" json:"orders"`
+	Items   int64   `db:"items" json:"items"`
+}
+
+const (
+	BreakdownProduct  = "product"
+	BreakdownCategory = "category"
+)
+
+// Колонки revenue_items для разбивки, в SQL подставляются только отсюда
+var breakdownColumns = map[string]string{
+	BreakdownProduct:  "product_id",
+	BreakdownCategory: "category",
+}
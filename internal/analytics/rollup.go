@@ -0,0 +1,172 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/hll"
+	"go.uber.org/zap"
+)
+
+const (
+	GranularityMinute = "minute"
+	GranularityHour   = "hour"
+	GranularityDay    = "day"
+	GranularityWeek   = "week"
+	GranularityMonth  = "month"
+)
+
+// Таблицы бакетов статистики, в SQL подставляются только отсюда. Часовые
+// бакеты лежат в analytics_summary и читаются через GetSummariesByDateRange.
+var statsTables = map[string]string{
+	GranularityMinute: "analytics_minute",
+	GranularityDay:    "analytics_daily",
+	GranularityWeek:   "analytics_weekly",
+	GranularityMonth:  "analytics_monthly",
+}
+
+// Гранулярности, которые Rollup собирает из часовых бакетов
+var rollupGranularities = []string{GranularityDay, GranularityWeek, GranularityMonth}
+
+// Запас для транзакций, которые записали updated_at до прогона rollup,
+// а закоммитились уже после него
+const rollupLag = time.Minute
+
+type RollupConfig struct {
+	// Сколько хранятся минутные бакеты
+	MinuteRetention time.Duration
+}
+
+// StatsBucket - строка минутной таблицы или rollup таблицы
+type StatsBucket struct {
+	Bucket      time.Time `db:"bucket" json:"bucket"`
+	EventType   string    `db:"event_type" json:"event_type"`
+	TotalEvents int64     `db:"total_events" json:"total_events"`
+	UniqueUsers int64     `db:"unique_users" json:"unique_users"`
+	UsersSketch []byte    `db:"users_sketch" json:"-"`
+}
+
+// MinuteUpdate - вклад события в минутный бакет
+type MinuteUpdate struct {
+	Bucket    time.Time
+	EventType string
+	UserID    string
+}
+
+// Rollup пересобирает бакеты day/week/month, в которых с прошлого прогона
+// менялся хотя бы один час, и удаляет минутные бакеты старше MinuteRetention.
+// Бакеты пересчитываются целиком, поэтому повторный прогон ничего не портит.
+func (s *Service) Rollup(ctx context.Context) {
+	for _, granularity := range rollupGranularities {
+		start := time.Now()
+		buckets, err := s.rollup(ctx, granularity)
+		if err != nil {
+			s.logger.Error("Failed to roll up stats",
+				zap.Error(err),
+				zap.String("granularity", granularity),
+			)
+			continue
+		}
+
+		s.logger.Debug("Stats rolled up",
+			zap.String("granularity", granularity),
+			zap.Int("buckets", buckets),
+			zap.Duration("duration", time.Since(start)),
+		)
+	}
+
+	deleted, err := s.repo.DeleteMinuteBuckets(ctx, time.Now().UTC().Add(-s.cfg.Rollup.MinuteRetention))
+	if err != nil {
+		s.logger.Error("Failed to delete old minute buckets", zap.Error(err))
+		return
+	}
+
+	if deleted > 0 {
+		s.logger.Debug("Old minute buckets deleted", zap.Int64("deleted", deleted))
+	}
+}
+
+func (s *Service) rollup(ctx context.Context, granularity string) (int, error) {
+	since, err := s.repo.GetRollupWatermark(ctx, granularity)
+	if err != nil {
+		return 0, err
+	}
+	next := time.Now().UTC().Add(-rollupLag)
+
+	summaries, err := s.repo.GetUpdatedSummaries(ctx, granularity, since)
+	if err != nil {
+		return 0, err
+	}
+
+	buckets, err := rollupSummaries(summaries, granularity)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.repo.SaveRollup(ctx, granularity, buckets, next); err != nil {
+		return 0, err
+	}
+
+	return len(buckets), nil
+}
+
+// rollupSummaries складывает часовые бакеты в бакеты granularity.
+// Для часов без sketch (записанных до HLL) остаётся максимум unique_users.
+func rollupSummaries(summaries []*Summary, granularity string) ([]*StatsBucket, error) {
+	type key struct {
+		bucket    time.Time
+		eventType string
+	}
+
+	buckets := make([]*StatsBucket, 0)
+	index := make(map[key]int)
+	sketches := make([]*hll.Sketch, 0)
+
+	for _, summary := range summaries {
+		k := key{bucket: truncateDate(summary.Date, granularity), eventType: summary.EventType}
+		i, ok := index[k]
+		if !ok {
+			i = len(buckets)
+			index[k] = i
+			buckets = append(buckets, &StatsBucket{Bucket: k.bucket, EventType: k.eventType})
+			sketches = append(sketches, hll.New())
+		}
+
+		bucket := buckets[i]
+		bucket.TotalEvents += summary.TotalEvents
+		bucket.UniqueUsers = max(bucket.UniqueUsers, summary.UniqueUsers)
+
+		sketch, err := hll.FromBytes(summary.UsersSketch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode users sketch: %w", err)
+		}
+		if err := sketches[i].Merge(sketch); err != nil {
+			return nil, fmt.Errorf("failed to merge users sketch: %w", err)
+		}
+	}
+
+	for i, bucket := range buckets {
+		data, err := sketches[i].Bytes()
+		if err != nil {
+			return nil, err
+		}
+		bucket.UsersSketch = data
+		bucket.UniqueUsers = max(bucket.UniqueUsers, sketches[i].Estimate())
+	}
+
+	return buckets, nil
+}
+
+// truncateDate повторяет date_trunc Postgres для даты: неделя начинается с понедельника
+func truncateDate(date time.Time, granularity string) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	switch granularity {
+	case GranularityWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case GranularityMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
@@ -3,45 +3,123 @@ package analytics
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/Wuchinator/realtime-analytics/pkg/kafka"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-type Service struct {
-	repo   Repository
-	logger *zap.Logger
+var tracer = otel.Tracer("github.com/Wuchinator/realtime-analytics/internal/analytics")
 
-	// In-memory кеш
-	uniqueUsers map[string]map[string]bool
+type ServiceConfig struct {
+	// Сессия закрывается, если в ней не было событий дольше таймаута
+	SessionTimeout time.Duration
+	// Ключ в data, где лежит страница для landing/exit page
+	PageKey string
+	Revenue RevenueConfig
+	// Ключи data, по которым события дополнительно агрегируются в analytics_dimensions
+	Dimensions []Dimension
+	Rollup     RollupConfig
 }
 
-func NewService(repo Repository, logger *zap.Logger) *Service {
+type Service struct {
+	repo       Repository
+	cfg        ServiceConfig
+	rates      *CurrencyRates
+	dimensions *DimensionLimiter
+	logger     *zap.Logger
+}
+
+func NewService(repo Repository, cfg ServiceConfig, logger *zap.Logger) *Service {
 	return &Service{
-		repo:        repo,
-		logger:      logger,
-		uniqueUsers: make(map[string]map[string]bool),
+		repo:       repo,
+		cfg:        cfg,
+		rates:      NewCurrencyRates(),
+		dimensions: NewDimensionLimiter(repo, cfg.Dimensions, logger),
+		logger:     logger,
 	}
 }
 
-func (s *Service) ProcessEvent(ctx context.Context, eventData *EventData) error {
-	date := eventData.CreatedAt.Truncate(24 * time.Hour)
-	hour := eventData.CreatedAt.Hour()
+// LoadDimensionValues читает известные значения измерений для лимитов кардинальности
+func (s *Service) LoadDimensionValues(ctx context.Context) error {
+	return s.dimensions.Load(ctx)
+}
 
-	key := fmt.Sprintf("%s-%d-%s", date.Format("2006-01-02"), hour, eventData.EventType)
+// ProcessEvent учитывает событие в summary и сессии. Если передан offset, он
+// сохраняется в той же транзакции, а уже учтённые offsets пропускаются.
+func (s *Service) ProcessEvent(ctx context.Context, eventData *EventData, offset *PartitionOffset) (err error) {
+	ctx, span := tracer.Start(ctx, "analytics.ProcessEvent", trace.WithAttributes(
+		attribute.String("event.id", eventData.ID),
+		attribute.String("event.type", eventData.EventType),
+		// Задержка от создания события до учёта в статистике
+		attribute.Float64("event.lag_seconds", time.Since(eventData.CreatedAt).Seconds()),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "process event failed")
+		}
+		span.End()
+	}()
 
-	if s.uniqueUsers[key] == nil {
-		s.uniqueUsers[key] = make(map[string]bool)
+	// Анонимный посетитель, уже связанный с пользователем, считается этим
+	// пользователем. События до связывания остаются в агрегатах под анонимным id.
+	if eventData.AnonymousID != "" {
+		userID, err := s.repo.ResolveUserID(ctx, eventData.UserID)
+		if err != nil {
+			return err
+		}
+		eventData.UserID = userID
 	}
-	s.uniqueUsers[key][eventData.UserID] = true
 
+	date := eventData.CreatedAt.Truncate(24 * time.Hour)
+	hour := eventData.CreatedAt.Hour()
+
+	// Уникальные пользователи считаются через HLL sketch, который сливается с сохранённым в БД
 	summary := NewSummary(date, hour, eventData.EventType)
 	summary.IncrementEvents(1)
-	summary.SetUniqueUsers(int64(len(s.uniqueUsers[key])))
+	if err := summary.AddUser(eventData.UserID); err != nil {
+		return fmt.Errorf("failed to add user to sketch: %w", err)
+	}
 
-	if err := s.repo.UpsertSummary(ctx, summary); err != nil {
-		return fmt.Errorf("failed to upsert summary: %w", err)
+	update := &EventUpdate{
+		Summary: summary,
+		Minute: &MinuteUpdate{
+			Bucket:    eventData.CreatedAt.UTC().Truncate(time.Minute),
+			EventType: eventData.EventType,
+			UserID:    eventData.UserID,
+		},
+		Session: s.sessionEvent(eventData),
+		Revenue: s.orderRevenue(eventData),
+	}
+
+	if len(s.cfg.Dimensions) > 0 {
+		update.Dimensions = &DimensionUpdate{
+			Date:       date,
+			Hour:       hour,
+			EventType:  eventData.EventType,
+			Dimensions: s.dimensions.Resolve(ctx, eventData.Data),
+			UserID:     eventData.UserID,
+		}
+	}
+
+	if err := s.repo.ApplyEvent(ctx, update, offset); err != nil {
+		if errors.Is(err, ErrOffsetAlreadyProcessed) {
+			s.logger.Debug("Event already counted, skipping",
+				zap.String("event_id", eventData.ID),
+				zap.Int32("partition", offset.Partition),
+				zap.Int64("offset", offset.Offset),
+			)
+			return nil
+		}
+		return fmt.Errorf("failed to apply event: %w", err)
 	}
 
 	s.logger.Debug("Event processed",
@@ -54,9 +132,97 @@ func (s *Service) ProcessEvent(ctx context.Context, eventData *EventData) error
 	return nil
 }
 
+func (s *Service) sessionEvent(eventData *EventData) *SessionEvent {
+	if eventData.SessionID == "" {
+		return nil
+	}
+
+	session := &SessionEvent{
+		SessionID:  eventData.SessionID,
+		UserID:     eventData.UserID,
+		At:         eventData.CreatedAt,
+		EventType:  eventData.EventType,
+		Converted:  eventData.EventType == EventTypePurchase,
+		IdleCutoff: eventData.CreatedAt.Add(-s.cfg.SessionTimeout),
+	}
+
+	if page, ok := eventData.Data[s.cfg.PageKey].(string); ok && page != "" {
+		session.Page = &page
+	}
+
+	return session
+}
+
+// orderRevenue возвращает nil для событий, которые не являются заказом или из
+// которых не удалось достать сумму. Такое событие всё равно учитывается в summary.
+func (s *Service) orderRevenue(eventData *EventData) *RevenueUpdate {
+	if !s.cfg.Revenue.isOrder(eventData.EventType) {
+		return nil
+	}
+
+	order, err := s.cfg.Revenue.parseOrder(eventData)
+	if err == nil {
+		var update *RevenueUpdate
+		update, err = s.revenueUpdate(eventData, order)
+		if err == nil {
+			return update
+		}
+	}
+
+	revenueSkipped.WithLabelValues(revenueSkipReason(err)).Inc()
+	s.logger.Warn("Order is not counted in revenue",
+		zap.Error(err),
+		zap.String("event_id", eventData.ID),
+	)
+	return nil
+}
+
+// PurgeUser удаляет данные удалённого пользователя: HLL sketches бакетов, в
+// которые он мог попасть, и его сессии. Счётчики событий и unique_users
+// остаются. Offset сохраняется последним, поэтому при повторе tombstone
+// sketches проверяются заново.
+func (s *Service) PurgeUser(ctx context.Context, userID string, offset *PartitionOffset) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return kafka.Permanent(fmt.Errorf("invalid tombstone key %q: %w", userID, err))
+	}
+
+	sketches, err := s.repo.DropUserSketches(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to drop user sketches: %w", err)
+	}
+
+	sessions, err := s.repo.PurgeUser(ctx, userID, offset)
+	if err != nil {
+		if errors.Is(err, ErrOffsetAlreadyProcessed) {
+			return nil
+		}
+		return fmt.Errorf("failed to purge user: %w", err)
+	}
+
+	s.logger.Info("User data purged",
+		zap.String("user_id", userID),
+		zap.Int64("sketches", sketches),
+		zap.Int64("sessions", sessions),
+	)
+	return nil
+}
+
+// CloseIdleSessions закрывает сессии, простаивающие дольше таймаута
+func (s *Service) CloseIdleSessions(ctx context.Context) {
+	closed, err := s.repo.CloseIdleSessions(ctx, time.Now().UTC().Add(-s.cfg.SessionTimeout))
+	if err != nil {
+		s.logger.Error("Failed to close idle sessions", zap.Error(err))
+		return
+	}
+
+	if closed > 0 {
+		s.logger.Debug("Idle sessions closed", zap.Int64("closed", closed))
+	}
+}
+
 func (s *Service) ProcessEventBatch(ctx context.Context, events []*EventData) error {
 	for _, event := range events {
-		if err := s.ProcessEvent(ctx, event); err != nil {
+		if err := s.ProcessEvent(ctx, event, nil); err != nil {
 			s.logger.Error("Failed to process event in batch",
 				zap.Error(err),
 				zap.String("event_id", event.ID),
@@ -72,35 +238,83 @@ func (s *Service) GetSummaries(ctx context.Context, from, to time.Time, eventTyp
 	return s.repo.GetSummariesByDateRange(ctx, from, to, eventType)
 }
 
-func (s *Service) GetTopProducts(ctx context.Context, from, to time.Time, limit int) ([]*ProductStats, error) {
-	return s.repo.GetTopProducts(ctx, from, to, limit)
+func (s *Service) GetTopProducts(ctx context.Context, from, to time.Time, limit int, eventType, rankBy string) ([]*ProductStats, error) {
+	return s.repo.GetTopProducts(ctx, from, to, limit, eventType, rankBy)
 }
 
-// CreateMessageHandler создаёт handler для Kafka consumer
-func (s *Service) CreateMessageHandler() func(ctx context.Context, key, value []byte) error {
-	return func(ctx context.Context, key, value []byte) error {
-		var eventData EventData
-		if err := json.Unmarshal(value, &eventData); err != nil {
-			s.logger.Error("Failed to unmarshal event",
+// Сколько периодов хранится в кеше retention для каждой гранулярности
+var cachedRetentionPeriods = map[string]int{
+	PeriodDay:   30,
+	PeriodWeek:  12,
+	PeriodMonth: 12,
+}
+
+// RefreshRetention пересчитывает кеш retention (любое событие -> любое событие)
+// для cohorts за последние lookback. Начало окна выравнивается на начало периода,
+// чтобы первая cohort считалась по всем своим пользователям.
+func (s *Service) RefreshRetention(ctx context.Context, lookback time.Duration) {
+	now := time.Now().UTC()
+
+	for period, periods := range cachedRetentionPeriods {
+		start := time.Now()
+		err := s.repo.RefreshRetention(ctx, &RetentionQuery{
+			From:    truncateDate(now.Add(-lookback), period),
+			To:      now,
+			Period:  period,
+			Periods: periods,
+		})
+		if err != nil {
+			s.logger.Error("Failed to refresh retention cache",
 				zap.Error(err),
-				zap.String("value", string(value)),
+				zap.String("period", period),
 			)
-			return err
+			continue
 		}
 
-		return s.ProcessEvent(ctx, &eventData)
+		s.logger.Debug("Retention cache refreshed",
+			zap.String("period", period),
+			zap.Duration("duration", time.Since(start)),
+		)
 	}
 }
 
-// CleanupOldCache желательно вытащить в отдельную горутину
-func (s *Service) CleanupOldCache() {
-	cutoff := time.Now().Add(-24 * time.Hour).Format("2006-01-02")
+// GetOffsets нужен consumer'у, чтобы после rebalance продолжить с сохранённого offset
+func (s *Service) GetOffsets(ctx context.Context, topic, consumerGroup string) (map[int32]int64, error) {
+	return s.repo.GetOffsets(ctx, topic, consumerGroup)
+}
+
+func (s *Service) SaveOffset(ctx context.Context, topic, consumerGroup string, partition int32, offset int64) error {
+	return s.repo.SaveOffset(ctx, &PartitionOffset{
+		Topic:         topic,
+		Partition:     partition,
+		Offset:        offset,
+		ConsumerGroup: consumerGroup,
+		UpdatedAt:     time.Now().UTC(),
+	})
+}
 
-	for key := range s.uniqueUsers {
-		if key < cutoff {
-			delete(s.uniqueUsers, key)
+// CreateMessageHandler создаёт handler для Kafka consumer
+func (s *Service) CreateMessageHandler(consumerGroup string) kafka.MessageHandler {
+	return func(ctx context.Context, msg *kafka.Message) error {
+		offset := &PartitionOffset{
+			Topic:         msg.Topic,
+			Partition:     msg.Partition,
+			Offset:        msg.Offset,
+			ConsumerGroup: consumerGroup,
+			UpdatedAt:     time.Now().UTC(),
+		}
+
+		// Tombstone от event-service: пользователь с ключом сообщения удалён
+		if len(msg.Value) == 0 {
+			return s.PurgeUser(ctx, string(msg.Key), offset)
 		}
-	}
 
-	s.logger.Debug("Cache cleanup completed")
+		var eventData EventData
+		if err := json.Unmarshal(msg.Value, &eventData); err != nil {
+			// Повтор не поможет, поэтому битое сообщение сразу уходит в DLQ
+			return kafka.Permanent(fmt.Errorf("failed to unmarshal event: %w", err))
+		}
+
+		return s.ProcessEvent(ctx, &eventData, offset)
+	}
 }
@@ -16,6 +16,20 @@ type Config struct {
 	GRPCPort    string
 	Postgres    PostgresConfig
 	Kafka       KafkaConfig
+	Outbox      OutboxConfig
+	Cohorts     CohortConfig
+	Sessions    SessionConfig
+	Metrics     MetricsConfig
+	Tracing     TracingConfig
+	HTTP        HTTPConfig
+	Stream      StreamConfig
+	Schemas     SchemasConfig
+	Revenue     RevenueConfig
+	Dimensions  []DimensionConfig
+	Rollups     RollupConfig
+	Partitions  PartitionConfig
+	Archive     ArchiveConfig
+	Admin       AdminConfig
 }
 
 type PostgresConfig struct {
@@ -28,6 +42,8 @@ type PostgresConfig struct {
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	SSLMode         string
+	// Применять миграции при старте сервиса, иначе только проверять версию схемы
+	MigrateOnStart bool
 }
 
 type KafkaConfig struct {
@@ -39,6 +55,136 @@ type KafkaConfig struct {
 	CompressionType  string
 	MaxMessageBytes  int
 	IdempotentWrites bool
+
+	DeadLetterTopic    string
+	ConsumerRetries    int
+	ConsumerMinBackoff time.Duration
+	ConsumerMaxBackoff time.Duration
+}
+
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	Retention    time.Duration
+	Lease        time.Duration
+}
+
+type CohortConfig struct {
+	RefreshInterval time.Duration
+	Lookback        time.Duration
+}
+
+type SessionConfig struct {
+	InactivityTimeout time.Duration
+	CloseInterval     time.Duration
+	PageKey           string
+}
+
+// Порты HTTP /metrics, у каждого сервиса свой
+type MetricsConfig struct {
+	EventServicePort     string
+	AnalyticsServicePort string
+	QueryServicePort     string
+}
+
+type TracingConfig struct {
+	// none, otlp, stdout или file
+	Exporter     string
+	OTLPEndpoint string
+	OTLPInsecure bool
+	FilePath     string
+	SampleRatio  float64
+}
+
+// HTTP/JSON шлюз event-service
+type HTTPConfig struct {
+	Port           string
+	AllowedOrigins []string
+	MaxBodyBytes   int
+}
+
+// Батчинг в TrackEventStream
+type StreamConfig struct {
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// Реестр схем событий
+type SchemasConfig struct {
+	// Как часто event-service перечитывает схемы, изменённые другими репликами
+	RefreshInterval time.Duration
+}
+
+// Ключи data, из которых analytics-service берёт выручку заказов
+type RevenueConfig struct {
+	EventTypes   []string
+	TotalKey     string
+	PriceKey     string
+	QuantityKey  string
+	CurrencyKey  string
+	ItemsKey     string
+	ProductIDKey string
+	CategoryKey  string
+	BaseCurrency string
+	// Как часто перечитывать таблицу currency_rates
+	RatesRefreshInterval time.Duration
+}
+
+// Минутные бакеты и их сворачивание в day/week/month
+type RollupConfig struct {
+	Interval        time.Duration
+	MinuteRetention time.Duration
+}
+
+// Партиции events и сроки хранения событий
+type PartitionConfig struct {
+	// day или month
+	Interval string
+	// Сколько партиций держать созданными наперёд, считая текущую
+	Premake             int
+	MaintenanceInterval time.Duration
+	// 0 - хранить всегда
+	Retention       time.Duration
+	RetentionByType map[string]time.Duration
+	// Отключать просроченные партиции вместо удаления
+	Detach bool
+}
+
+// Архивация просроченных партиций events в холодное хранилище
+type ArchiveConfig struct {
+	Enabled bool
+	// local или s3
+	Backend string
+	Dir     string
+	// Префикс ключей файлов в хранилище
+	Prefix      string
+	S3Endpoint  string
+	S3Bucket    string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+}
+
+// Отдельный gRPC listener AdminService, доступ только по токенам
+type AdminConfig struct {
+	Port   string
+	Tokens []AdminToken
+}
+
+// AdminToken - токен администратора: кто им пользуется и какие права у него есть
+type AdminToken struct {
+	Caller string
+	Token  string
+	Scopes []string
+}
+
+// Измерение для агрегации: ключ data и лимит числа разных значений
+type DimensionConfig struct {
+	Name      string
+	MaxValues int
 }
 
 func Load() (*Config, error) {
@@ -59,6 +205,7 @@ func Load() (*Config, error) {
 		MaxIdleConns:    getEnvAsInt("POSTGRES_MAX_IDLE_CONNS", 5),
 		ConnMaxLifetime: getEnvAsDuration("POSTGRES_CONN_MAX_LIFETIME", 5*time.Minute),
 		SSLMode:         getEnv("POSTGRES_SSL_MODE", "disable"),
+		MigrateOnStart:  getEnvAsBool("POSTGRES_MIGRATE_ON_START", false),
 	}
 
 	brokers := getEnv("KAFKA_BROKERS", "localhost:9092")
@@ -71,11 +218,217 @@ func Load() (*Config, error) {
 		CompressionType:  getEnv("KAFKA_COMPRESSION", "snappy"),
 		IdempotentWrites: getEnvAsBool("KAFKA_IDEMPOTENT", true),
 		MaxMessageBytes:  getEnvAsInt("KAFKA_MAX_MESSAGE_BYTES", 1000000), // 1MB
+
+		DeadLetterTopic:    getEnv("KAFKA_TOPIC_DLQ", "user-events-dlq"),
+		ConsumerRetries:    getEnvAsInt("KAFKA_CONSUMER_RETRIES", 5), // всего попыток, включая первую
+		ConsumerMinBackoff: getEnvAsDuration("KAFKA_CONSUMER_MIN_BACKOFF", 200*time.Millisecond),
+		ConsumerMaxBackoff: getEnvAsDuration("KAFKA_CONSUMER_MAX_BACKOFF", 10*time.Second),
+	}
+
+	cfg.Outbox = OutboxConfig{
+		PollInterval: getEnvAsDuration("OUTBOX_POLL_INTERVAL", 500*time.Millisecond),
+		BatchSize:    getEnvAsInt("OUTBOX_BATCH_SIZE", 100),
+		MinBackoff:   getEnvAsDuration("OUTBOX_MIN_BACKOFF", 1*time.Second),
+		MaxBackoff:   getEnvAsDuration("OUTBOX_MAX_BACKOFF", 5*time.Minute),
+		Retention:    getEnvAsDuration("OUTBOX_RETENTION", 24*time.Hour),
+		Lease:        getEnvAsDuration("OUTBOX_LEASE", 30*time.Second),
+	}
+
+	cfg.Cohorts = CohortConfig{
+		RefreshInterval: getEnvAsDuration("COHORT_REFRESH_INTERVAL", 1*time.Hour),
+		Lookback:        getEnvAsDuration("COHORT_LOOKBACK", 90*24*time.Hour),
+	}
+
+	cfg.Sessions = SessionConfig{
+		InactivityTimeout: getEnvAsDuration("SESSION_INACTIVITY_TIMEOUT", 30*time.Minute),
+		CloseInterval:     getEnvAsDuration("SESSION_CLOSE_INTERVAL", 1*time.Minute),
+		PageKey:           getEnv("SESSION_PAGE_KEY", "page"),
+	}
+
+	cfg.Metrics = MetricsConfig{
+		EventServicePort:     getEnv("EVENT_SERVICE_METRICS_PORT", "2112"),
+		AnalyticsServicePort: getEnv("ANALYTICS_SERVICE_METRICS_PORT", "2113"),
+		QueryServicePort:     getEnv("QUERY_SERVICE_METRICS_PORT", "2114"),
+	}
+
+	cfg.Tracing = TracingConfig{
+		Exporter:     getEnv("TRACING_EXPORTER", "none"),
+		OTLPEndpoint: getEnv("TRACING_OTLP_ENDPOINT", "localhost:4317"),
+		OTLPInsecure: getEnvAsBool("TRACING_OTLP_INSECURE", true),
+		FilePath:     getEnv("TRACING_FILE", "traces.json"),
+		SampleRatio:  getEnvAsFloat("TRACING_SAMPLE_RATIO", 1.0),
+	}
+
+	cfg.HTTP = HTTPConfig{
+		Port:           getEnv("EVENT_SERVICE_HTTP_PORT", "8080"),
+		AllowedOrigins: strings.Split(getEnv("HTTP_CORS_ALLOWED_ORIGINS", "*"), ","),
+		MaxBodyBytes:   getEnvAsInt("HTTP_MAX_BODY_BYTES", 1<<20), // 1MB
+	}
+
+	cfg.Stream = StreamConfig{
+		BatchSize:     getEnvAsInt("STREAM_BATCH_SIZE", 500),
+		FlushInterval: getEnvAsDuration("STREAM_FLUSH_INTERVAL", 200*time.Millisecond),
+	}
+
+	cfg.Schemas = SchemasConfig{
+		RefreshInterval: getEnvAsDuration("SCHEMA_REFRESH_INTERVAL", 30*time.Second),
+	}
+
+	cfg.Revenue = RevenueConfig{
+		EventTypes:           strings.Split(getEnv("REVENUE_EVENT_TYPES", "purchase"), ","),
+		TotalKey:             getEnv("REVENUE_TOTAL_KEY", "revenue"),
+		PriceKey:             getEnv("REVENUE_PRICE_KEY", "price"),
+		QuantityKey:          getEnv("REVENUE_QUANTITY_KEY", "quantity"),
+		CurrencyKey:          getEnv("REVENUE_CURRENCY_KEY", "currency"),
+		ItemsKey:             getEnv("REVENUE_ITEMS_KEY", "products"),
+		ProductIDKey:         getEnv("REVENUE_PRODUCT_ID_KEY", "product_id"),
+		CategoryKey:          getEnv("REVENUE_CATEGORY_KEY", "category"),
+		BaseCurrency:         strings.ToUpper(getEnv("REVENUE_BASE_CURRENCY", "USD")),
+		RatesRefreshInterval: getEnvAsDuration("CURRENCY_RATES_REFRESH_INTERVAL", 5*time.Minute),
+	}
+
+	cfg.Rollups = RollupConfig{
+		Interval:        getEnvAsDuration("ROLLUP_INTERVAL", 1*time.Minute),
+		MinuteRetention: getEnvAsDuration("MINUTE_BUCKET_RETENTION", 48*time.Hour),
+	}
+
+	// Формат: "page_view:720h,search:168h", 0 - хранить этот тип всегда
+	retentionByType, err := parseRetentions(getEnv("EVENTS_RETENTION_BY_TYPE", ""))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Partitions = PartitionConfig{
+		Interval:            getEnv("EVENTS_PARTITION_INTERVAL", "day"),
+		Premake:             getEnvAsInt("EVENTS_PARTITION_PREMAKE", 7),
+		MaintenanceInterval: getEnvAsDuration("EVENTS_PARTITION_MAINTENANCE_INTERVAL", 1*time.Hour),
+		Retention:           getEnvAsDuration("EVENTS_RETENTION", 0),
+		RetentionByType:     retentionByType,
+		Detach:              getEnvAsBool("EVENTS_RETENTION_DETACH", false),
+	}
+
+	cfg.Archive = ArchiveConfig{
+		Enabled:     getEnvAsBool("ARCHIVE_ENABLED", false),
+		Backend:     getEnv("ARCHIVE_BACKEND", "local"),
+		Dir:         getEnv("ARCHIVE_DIR", "archive"),
+		Prefix:      getEnv("ARCHIVE_PREFIX", "events"),
+		S3Endpoint:  getEnv("ARCHIVE_S3_ENDPOINT", "localhost:9000"),
+		S3Bucket:    getEnv("ARCHIVE_S3_BUCKET", "events-archive"),
+		S3Region:    getEnv("ARCHIVE_S3_REGION", ""),
+		S3AccessKey: getEnv("ARCHIVE_S3_ACCESS_KEY", ""),
+		S3SecretKey: getEnv("ARCHIVE_S3_SECRET_KEY", ""),
+		S3UseSSL:    getEnvAsBool("ARCHIVE_S3_USE_SSL", false),
+	}
+
+	// Формат: "category:500,country,device", без лимита берётся DIMENSION_MAX_VALUES
+	dimensions, err := parseDimensions(
+		getEnv("ANALYTICS_DIMENSIONS", "category,country,device,utm_source"),
+		getEnvAsInt("DIMENSION_MAX_VALUES", 1000),
+	)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Dimensions = dimensions
+
+	// Формат: "dpo:secret1:privacy,ops:secret2:schemas+archives"
+	adminTokens, err := parseAdminTokens(getEnv("ADMIN_TOKENS", ""))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Admin = AdminConfig{
+		Port:   getEnv("EVENT_SERVICE_ADMIN_PORT", "50054"),
+		Tokens: adminTokens,
 	}
 
 	return cfg, nil
 }
 
+func parseDimensions(spec string, defaultMaxValues int) ([]DimensionConfig, error) {
+	var dimensions []DimensionConfig
+	seen := make(map[string]bool)
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, limit, hasLimit := strings.Cut(item, ":")
+		dimension := DimensionConfig{Name: strings.TrimSpace(name), MaxValues: defaultMaxValues}
+		if hasLimit {
+			maxValues, err := strconv.Atoi(strings.TrimSpace(limit))
+			if err != nil || maxValues <= 0 {
+				return nil, fmt.Errorf("invalid ANALYTICS_DIMENSIONS limit for %s: %q", dimension.Name, limit)
+			}
+			dimension.MaxValues = maxValues
+		}
+
+		if dimension.Name == "" || seen[dimension.Name] {
+			return nil, fmt.Errorf("invalid ANALYTICS_DIMENSIONS: empty or duplicate dimension %q", dimension.Name)
+		}
+		seen[dimension.Name] = true
+		dimensions = append(dimensions, dimension)
+	}
+
+	return dimensions, nil
+}
+
+func parseRetentions(spec string) (map[string]time.Duration, error) {
+	retentions := make(map[string]time.Duration)
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		eventType, value, ok := strings.Cut(item, ":")
+		eventType = strings.TrimSpace(eventType)
+		if !ok || eventType == "" {
+			return nil, fmt.Errorf("invalid EVENTS_RETENTION_BY_TYPE item %q, expected type:duration", item)
+		}
+		if _, seen := retentions[eventType]; seen {
+			return nil, fmt.Errorf("invalid EVENTS_RETENTION_BY_TYPE: duplicate event type %q", eventType)
+		}
+
+		retention, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || retention < 0 {
+			return nil, fmt.Errorf("invalid EVENTS_RETENTION_BY_TYPE duration for %s: %q", eventType, value)
+		}
+		retentions[eventType] = retention
+	}
+
+	return retentions, nil
+}
+
+func parseAdminTokens(spec string) ([]AdminToken, error) {
+	var tokens []AdminToken
+	callers := make(map[string]bool)
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid ADMIN_TOKENS item for %q, expected caller:token[:scope+scope]", parts[0])
+		}
+		if callers[parts[0]] {
+			return nil, fmt.Errorf("invalid ADMIN_TOKENS: duplicate caller %q", parts[0])
+		}
+		callers[parts[0]] = true
+
+		token := AdminToken{Caller: parts[0], Token: parts[1]}
+		if len(parts) == 3 && parts[2] != "" {
+			token.Scopes = strings.Split(parts[2], "+")
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
 func (c *PostgresConfig) PostgresDSN() string {
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
@@ -112,3 +465,11 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if value, err := strconv.ParseFloat(valueStr, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
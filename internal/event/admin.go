@@ -0,0 +1,365 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/archive"
+	pb "github.com/Wuchinator/realtime-analytics/pkg/pb/events"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Размер куска выгрузки ExportUserData
+const exportChunkBytes = 64 * 1024
+
+// Таблица RestoreArchive, если клиент её не указал
+const defaultRestoreTable = restoreTablePrefix + "events"
+
+// AdminHandler - gRPC AdminService: реестр схем событий, запросы на удаление
+// и выгрузку данных пользователя и архивы событий
+type AdminHandler struct {
+	pb.UnimplementedAdminServiceServer
+	registry *Registry
+	privacy  *Privacy
+	// nil, если архивация выключена
+	archiver *Archiver
+	logger   *zap.Logger
+}
+
+func NewAdminHandler(registry *Registry, privacy *Privacy, archiver *Archiver, logger *zap.Logger) *AdminHandler {
+	return &AdminHandler{
+		registry: registry,
+		privacy:  privacy,
+		archiver: archiver,
+		logger:   logger,
+	}
+}
+
+func (h *AdminHandler) RegisterEventSchema(ctx context.Context, req *pb.RegisterEventSchemaRequest) (*pb.RegisterEventSchemaResponse, error) {
+	if req.Schema == nil {
+		return nil, status.Error(codes.InvalidArgument, "schema is required")
+	}
+
+	schema, err := schemaFromProto(req.Schema)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "can't register schema: %v", err)
+	}
+
+	stored, err := h.registry.Register(ctx, schema)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSchema) {
+			return nil, status.Errorf(codes.InvalidArgument, "can't register schema: %v", err)
+		}
+		h.logger.Error("Failed to register event schema", zap.Error(err), zap.String("name", schema.Name))
+		return nil, status.Errorf(codes.Internal, "can't register schema: %v", err)
+	}
+
+	h.logger.Info("Event schema registered",
+		zap.String("name", stored.Name),
+		zap.String("caller", callerForLog(ctx)),
+	)
+
+	return &pb.RegisterEventSchemaResponse{Schema: schemaToProto(stored)}, nil
+}
+
+func (h *AdminHandler) GetEventSchema(ctx context.Context, req *pb.GetEventSchemaRequest) (*pb.GetEventSchemaResponse, error) {
+	schema := h.registry.Get(req.Name)
+	if schema == nil {
+		return nil, status.Errorf(codes.NotFound, "%v: %s", ErrSchemaNotFound, req.Name)
+	}
+
+	return &pb.GetEventSchemaResponse{Schema: schemaToProto(schema)}, nil
+}
+
+func (h *AdminHandler) ListEventSchemas(ctx context.Context, req *pb.ListEventSchemasRequest) (*pb.ListEventSchemasResponse, error) {
+	schemas := h.registry.List()
+
+	resp := &pb.ListEventSchemasResponse{
+		Schemas: make([]*pb.EventSchema, len(schemas)),
+	}
+	for i, schema := range schemas {
+		resp.Schemas[i] = schemaToProto(schema)
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) DeleteEventSchema(ctx context.Context, req *pb.DeleteEventSchemaRequest) (*pb.DeleteEventSchemaResponse, error) {
+	if err := h.registry.Delete(ctx, req.Name); err != nil {
+		switch {
+		case errors.Is(err, ErrSchemaNotFound):
+			return nil, status.Errorf(codes.NotFound, "%v: %s", err, req.Name)
+		case errors.Is(err, ErrBuiltInSchema):
+			return nil, status.Errorf(codes.FailedPrecondition, "%v: %s", err, req.Name)
+		default:
+			h.logger.Error("Failed to delete event schema", zap.Error(err), zap.String("name", req.Name))
+			return nil, status.Errorf(codes.Internal, "can't delete schema: %v", err)
+		}
+	}
+
+	h.logger.Info("Event schema deleted",
+		zap.String("name", req.Name),
+		zap.String("caller", callerForLog(ctx)),
+	)
+
+	return &pb.DeleteEventSchemaResponse{}, nil
+}
+
+func (h *AdminHandler) DeleteUserData(ctx context.Context, req *pb.DeleteUserDataRequest) (*pb.DeleteUserDataResponse, error) {
+	caller, err := adminCallerName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	request, err := userDataRequestFromProto(UserDataRequestDelete, req.UserId, req.RequestId, caller, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, alreadyProcessed, err := h.privacy.DeleteUserData(ctx, request)
+	if err != nil {
+		return nil, userDataStatus("can't delete user data", err)
+	}
+
+	resp := &pb.DeleteUserDataResponse{
+		RequestId:         stored.ID.String(),
+		DeletedEvents:     stored.EventsAffected,
+		DeletedIdentities: stored.IdentitiesAffected,
+		AlreadyProcessed:  alreadyProcessed,
+	}
+	if stored.CompletedAt != nil {
+		resp.CompletedAt = timestamppb.New(*stored.CompletedAt)
+	}
+
+	return resp, nil
+}
+
+func (h *AdminHandler) ExportUserData(req *pb.ExportUserDataRequest, stream pb.AdminService_ExportUserDataServer) error {
+	caller, err := adminCallerName(stream.Context())
+	if err != nil {
+		return err
+	}
+	request, err := userDataRequestFromProto(UserDataRequestExport, req.UserId, req.RequestId, caller, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	// Строки копятся до exportChunkBytes, чтобы не отправлять сообщение на каждое событие
+	chunk := make([]byte, 0, exportChunkBytes)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if err := stream.Send(&pb.ExportUserDataChunk{Data: chunk}); err != nil {
+			return err
+		}
+		chunk = make([]byte, 0, exportChunkBytes)
+		return nil
+	}
+
+	_, err = h.privacy.ExportUserData(stream.Context(), request, func(line []byte) error {
+		if len(chunk)+len(line) > exportChunkBytes {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		chunk = append(chunk, line...)
+		return nil
+	})
+	if err != nil {
+		return userDataStatus("can't export user data", err)
+	}
+
+	return flush()
+}
+
+func (h *AdminHandler) ListArchives(ctx context.Context, req *pb.ListArchivesRequest) (*pb.ListArchivesResponse, error) {
+	if h.archiver == nil {
+		return nil, status.Error(codes.FailedPrecondition, "event archiving is disabled")
+	}
+
+	archives, err := h.archiver.ListArchives(ctx, optionalTime(req.From), optionalTime(req.To))
+	if err != nil {
+		return nil, archiveStatus("can't list archives", err)
+	}
+
+	return &pb.ListArchivesResponse{Archives: archivesToProto(archives)}, nil
+}
+
+func (h *AdminHandler) RestoreArchive(ctx context.Context, req *pb.RestoreArchiveRequest) (*pb.RestoreArchiveResponse, error) {
+	if h.archiver == nil {
+		return nil, status.Error(codes.FailedPrecondition, "event archiving is disabled")
+	}
+
+	table := req.Table
+	if table == "" {
+		table = defaultRestoreTable
+	}
+
+	result, err := h.archiver.RestoreArchive(ctx, optionalTime(req.From), optionalTime(req.To), table)
+	if err != nil {
+		return nil, archiveStatus("can't restore archive", err)
+	}
+
+	h.logger.Info("Event archives restored",
+		zap.String("table", result.Table),
+		zap.Int64("events", result.Events),
+		zap.Int("archives", len(result.Archives)),
+		zap.String("caller", callerForLog(ctx)),
+	)
+
+	return &pb.RestoreArchiveResponse{
+		Table:          result.Table,
+		RestoredEvents: result.Events,
+		Archives:       archivesToProto(result.Archives),
+	}, nil
+}
+
+func archiveStatus(message string, err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidArchiveRange), errors.Is(err, ErrInvalidRestoreTable):
+		return status.Errorf(codes.InvalidArgument, "%s: %v", message, err)
+	case errors.Is(err, ErrArchiveNotFound), errors.Is(err, archive.ErrNotFound):
+		return status.Errorf(codes.NotFound, "%s: %v", message, err)
+	case errors.Is(err, ErrArchiveCorrupted):
+		return status.Errorf(codes.DataLoss, "%s: %v", message, err)
+	case errors.Is(err, context.Canceled):
+		return status.Errorf(codes.Canceled, "%s: %v", message, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", message, err)
+	}
+}
+
+func archivesToProto(archives []*Archive) []*pb.EventArchive {
+	result := make([]*pb.EventArchive, len(archives))
+	for i, a := range archives {
+		result[i] = &pb.EventArchive{
+			Partition:  a.PartitionName,
+			From:       timestamppb.New(a.From),
+			To:         timestamppb.New(a.To),
+			ObjectKey:  a.ObjectKey,
+			Format:     a.Format,
+			Events:     a.Events,
+			Bytes:      a.Bytes,
+			ArchivedAt: timestamppb.New(a.ArchivedAt),
+		}
+	}
+	return result
+}
+
+// optionalTime - нулевое время для незаданного timestamp
+func optionalTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// adminCallerName - кто выполняет запрос, по токену из AdminAuth. В журнал
+// пишется он, а не requested_by из запроса.
+func adminCallerName(ctx context.Context) (string, error) {
+	caller, ok := AdminCallerFromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "admin caller is unknown")
+	}
+	return caller.Name, nil
+}
+
+func callerForLog(ctx context.Context) string {
+	if caller, ok := AdminCallerFromContext(ctx); ok {
+		return caller.Name
+	}
+	return ""
+}
+
+func userDataRequestFromProto(kind, userID, requestID, requestedBy, reason string) (*UserDataRequest, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	}
+
+	request, err := NewUserDataRequest(kind, requestID, id, requestedBy, reason)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	return request, nil
+}
+
+func userDataStatus(message string, err error) error {
+	switch {
+	case errors.Is(err, ErrRequestConflict):
+		return status.Errorf(codes.AlreadyExists, "%s: %v", message, err)
+	case errors.Is(err, context.Canceled):
+		return status.Errorf(codes.Canceled, "%s: %v", message, err)
+	default:
+		return status.Errorf(codes.Internal, "%s: %v", message, err)
+	}
+}
+
+func schemaFromProto(s *pb.EventSchema) (*EventSchema, error) {
+	schema := &EventSchema{
+		Name:        s.Name,
+		Description: s.Description,
+		Fields:      make(SchemaFields, len(s.Fields)),
+	}
+
+	for i, field := range s.Fields {
+		fieldType, ok := fieldTypeFromProto[field.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: field %q has no type", ErrInvalidSchema, field.Name)
+		}
+		schema.Fields[i] = SchemaField{
+			Name:          field.Name,
+			Type:          fieldType,
+			Required:      field.Required,
+			AllowedValues: field.AllowedValues,
+		}
+	}
+
+	return schema, nil
+}
+
+func schemaToProto(s *EventSchema) *pb.EventSchema {
+	schema := &pb.EventSchema{
+		Name:        s.Name,
+		Description: s.Description,
+		Fields:      make([]*pb.SchemaField, len(s.Fields)),
+		Version:     int32(s.Version),
+		BuiltIn:     s.BuiltIn,
+		CreatedAt:   timestamppb.New(s.CreatedAt),
+		UpdatedAt:   timestamppb.New(s.UpdatedAt),
+	}
+
+	for i, field := range s.Fields {
+		schema.Fields[i] = &pb.SchemaField{
+			Name:          field.Name,
+			Type:          fieldTypeToProto[field.Type],
+			Required:      field.Required,
+			AllowedValues: field.AllowedValues,
+		}
+	}
+
+	return schema
+}
+
+var fieldTypeFromProto = map[pb.FieldType]FieldType{
+	pb.FieldType_FIELD_TYPE_STRING:    FieldTypeString,
+	pb.FieldType_FIELD_TYPE_NUMBER:    FieldTypeNumber,
+	pb.FieldType_FIELD_TYPE_BOOLEAN:   FieldTypeBoolean,
+	pb.FieldType_FIELD_TYPE_UUID:      FieldTypeUUID,
+	pb.FieldType_FIELD_TYPE_TIMESTAMP: FieldTypeTimestamp,
+}
+
+var fieldTypeToProto = map[FieldType]pb.FieldType{
+	FieldTypeString:    pb.FieldType_FIELD_TYPE_STRING,
+	FieldTypeNumber:    pb.FieldType_FIELD_TYPE_NUMBER,
+	FieldTypeBoolean:   pb.FieldType_FIELD_TYPE_BOOLEAN,
+	FieldTypeUUID:      pb.FieldType_FIELD_TYPE_UUID,
+	FieldTypeTimestamp: pb.FieldType_FIELD_TYPE_TIMESTAMP,
+}
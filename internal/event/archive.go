@@ -0,0 +1,686 @@
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/archive"
+	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
+	"github.com/klauspost/compress/zstd"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Формат архива: JSON lines, по событию на строку, сжатые zstd
+const (
+	ArchiveFormatNDJSONZstd = "ndjson+zstd"
+
+	archiveExtension = ".ndjson.zst"
+)
+
+const (
+	// Архив восстанавливается только в таблицы с этим префиксом, чтобы
+	// RestoreArchive не мог писать в events или служебные таблицы
+	restoreTablePrefix = "restored_"
+	// Размер пачки строк в одном INSERT при восстановлении
+	restoreBatchSize = 1000
+	// Ключ pg_advisory_xact_lock, которым восстановление архива и
+	// DeleteUserData исключают друг друга
+	erasureLockKey = 7_240_019_232
+)
+
+var restoreTableName = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// Условие для строки r с колонкой user_id: её пользователь не удалён через
+// DeleteUserData. Такие события не выгружаются в архив и не восстанавливаются.
+const notErasedCondition = `NOT EXISTS (
+	SELECT 1 FROM user_data_requests d
+	WHERE d.kind = 'delete' AND (d.user_id = r.user_id OR r.user_id = ANY(d.erased_user_ids))
+)`
+
+// Запас для запросов на удаление, которые получили completed_at до выгрузки
+// или чистки архива, а закоммитились уже после неё
+const purgeLag = time.Minute
+
+// Archive - запись манифеста event_archives: партиция [From, To) и объект в хранилище
+type Archive struct {
+	PartitionName string    `db:"partition_name" json:"partition_name"`
+	From          time.Time `db:"range_from" json:"from"`
+	To            time.Time `db:"range_to" json:"to"`
+	ObjectKey     string    `db:"object_key" json:"object_key"`
+	Format        string    `db:"format" json:"format"`
+	Events        int64     `db:"events" json:"events"`
+	Bytes         int64     `db:"bytes" json:"bytes"`
+	// sha256 сжатого файла
+	Checksum   string    `db:"sha256" json:"sha256"`
+	ArchivedAt time.Time `db:"archived_at" json:"archived_at"`
+	// Когда из архива последний раз вычищены удалённые пользователи
+	PurgedAt *time.Time `db:"purged_at" json:"purged_at"`
+}
+
+// RestoreResult - итог RestoreArchive
+type RestoreResult struct {
+	Table    string
+	Archives []*Archive
+	// Сколько событий вставлено. Уже восстановленные ранее не считаются.
+	Events int64
+}
+
+type ArchiveRepository interface {
+	// GetArchive возвращает ErrArchiveNotFound, если партиция не архивировалась
+	GetArchive(ctx context.Context, partitionName string) (*Archive, error)
+	// ListArchives возвращает архивы, пересекающиеся с [from, to).
+	// Нулевое время - без ограничения с этой стороны.
+	ListArchives(ctx context.Context, from, to time.Time) ([]*Archive, error)
+	SaveArchive(ctx context.Context, archived *Archive) error
+	// ListUnpurgedArchives возвращает архивы, выгруженные или вычищенные раньше,
+	// чем выполнен какой-то запрос на удаление пользователя
+	ListUnpurgedArchives(ctx context.Context) ([]*Archive, error)
+	// ErasedUserIDs возвращает все id, удалённые через DeleteUserData
+	ErasedUserIDs(ctx context.Context) ([]string, error)
+	// ExportPartition передаёт в write все события таблицы партиции
+	ExportPartition(ctx context.Context, partition *Partition, write func(event *Event) error) (int64, error)
+	// RestoreEvents в одной транзакции создаёт таблицу table, если её нет, и
+	// вставляет строки JSON lines, которые read передаёт в yield, с created_at
+	// из [from, to). Транзакция фиксируется, только если read вернул nil.
+	RestoreEvents(ctx context.Context, table string, from, to time.Time, read func(yield func(line []byte) error) error) (int64, error)
+}
+
+type archiveRepository struct {
+	db     *postgres.DB
+	logger *zap.Logger
+}
+
+func NewArchiveRepository(db *postgres.DB, logger *zap.Logger) ArchiveRepository {
+	return &archiveRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *archiveRepository) GetArchive(ctx context.Context, partitionName string) (*Archive, error) {
+	var stored Archive
+	err := r.db.GetContext(ctx, &stored, `
+		SELECT partition_name, range_from, range_to, object_key, format, events, bytes, sha256, archived_at, purged_at
+		FROM event_archives
+		WHERE partition_name = $1
+	`, partitionName)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrArchiveNotFound
+		}
+		return nil, fmt.Errorf("failed to get archive: %w", err)
+	}
+
+	return &stored, nil
+}
+
+func (r *archiveRepository) ListArchives(ctx context.Context, from, to time.Time) ([]*Archive, error) {
+	archives := []*Archive{}
+	err := r.db.SelectContext(ctx, &archives, `
+		SELECT partition_name, range_from, range_to, object_key, format, events, bytes, sha256, archived_at, purged_at
+		FROM event_archives
+		WHERE ($1::timestamptz IS NULL OR range_to > $1)
+		  AND ($2::timestamptz IS NULL OR range_from < $2)
+		ORDER BY range_from
+	`, nullTime(from), nullTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+
+	return archives, nil
+}
+
+func (r *archiveRepository) SaveArchive(ctx context.Context, archived *Archive) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO event_archives (partition_name, range_from, range_to, object_key, format, events, bytes, sha256, archived_at, purged_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (partition_name) DO UPDATE SET
+			range_from = EXCLUDED.range_from,
+			range_to = EXCLUDED.range_to,
+			object_key = EXCLUDED.object_key,
+			format = EXCLUDED.format,
+			events = EXCLUDED.events,
+			bytes = EXCLUDED.bytes,
+			sha256 = EXCLUDED.sha256,
+			archived_at = EXCLUDED.archived_at,
+			purged_at = EXCLUDED.purged_at
+	`,
+		archived.PartitionName,
+		archived.From,
+		archived.To,
+		archived.ObjectKey,
+		archived.Format,
+		archived.Events,
+		archived.Bytes,
+		archived.Checksum,
+		archived.ArchivedAt,
+		archived.PurgedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save archive: %w", err)
+	}
+
+	return nil
+}
+
+func (r *archiveRepository) ListUnpurgedArchives(ctx context.Context) ([]*Archive, error) {
+	archives := []*Archive{}
+	err := r.db.SelectContext(ctx, &archives, `
+		SELECT partition_name, range_from, range_to, object_key, format, events, bytes, sha256, archived_at, purged_at
+		FROM event_archives a
+		WHERE EXISTS (
+			SELECT 1 FROM user_data_requests d
+			WHERE d.kind = 'delete'
+			  AND d.completed_at > COALESCE(a.purged_at, a.archived_at) - make_interval(secs => $1)
+		)
+		ORDER BY range_from
+	`, purgeLag.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unpurged archives: %w", err)
+	}
+
+	return archives, nil
+}
+
+func (r *archiveRepository) ErasedUserIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT DISTINCT id::text
+		FROM user_data_requests d
+		CROSS JOIN LATERAL unnest(array_append(d.erased_user_ids, d.user_id)) AS id
+		WHERE d.kind = 'delete'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get erased user ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *archiveRepository) ExportPartition(ctx context.Context, partition *Partition, write func(event *Event) error) (int64, error) {
+	// События, пришедшие уже после удаления пользователя, в архив не попадают
+	rows, err := r.db.QueryxContext(ctx, fmt.Sprintf(`
+		SELECT id, event_type, user_id, anonymous_id, session_id, product_id, data, created_at, processed_at
+		FROM %s r
+		WHERE %s
+		ORDER BY created_at, id
+	`, pq.QuoteIdentifier(partition.Name), notErasedCondition))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read partition %s: %w", partition.Name, err)
+	}
+	defer rows.Close()
+
+	var count int64
+	for rows.Next() {
+		var event Event
+		if err := rows.StructScan(&event); err != nil {
+			return count, fmt.Errorf("failed to scan event: %w", err)
+		}
+		if err := write(&event); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("failed to read partition %s: %w", partition.Name, err)
+	}
+
+	return count, nil
+}
+
+func (r *archiveRepository) RestoreEvents(
+	ctx context.Context,
+	table string,
+	from, to time.Time,
+	read func(yield func(line []byte) error) error) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	// Иначе DeleteUserData может закоммититься между проверкой notErasedCondition
+	// и коммитом восстановления и не увидеть восстановленные строки
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, erasureLockKey); err != nil {
+		return 0, fmt.Errorf("failed to lock erasure: %w", err)
+	}
+
+	name := pq.QuoteIdentifier(table)
+
+	// Обычная таблица с теми же колонками и primary key, что у events:
+	// повторное восстановление того же диапазона не создаёт дубликатов
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (LIKE events INCLUDING DEFAULTS INCLUDING CONSTRAINTS INCLUDING INDEXES)
+	`, name)); err != nil {
+		return 0, fmt.Errorf("failed to create restore table %s: %w", table, err)
+	}
+
+	// Ключи JSON совпадают с колонками events. Пользователи, данные которых
+	// удалены через DeleteUserData, обратно не восстанавливаются, даже если
+	// архив ещё не успели вычистить.
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s
+		SELECT r.*
+		FROM jsonb_populate_recordset(NULL::%[1]s, $1::jsonb) r
+		WHERE r.created_at >= $2 AND r.created_at < $3
+		  AND %[2]s
+		ON CONFLICT DO NOTHING
+	`, name, notErasedCondition)
+
+	var (
+		restored int64
+		batch    bytes.Buffer
+		pending  int
+	)
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		batch.WriteByte(']')
+
+		result, err := tx.ExecContext(ctx, query, batch.String(), from, to)
+		if err != nil {
+			return fmt.Errorf("failed to restore events: %w", err)
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		restored += inserted
+		batch.Reset()
+		pending = 0
+		return nil
+	}
+
+	err = read(func(line []byte) error {
+		if pending == 0 {
+			batch.WriteByte('[')
+		} else {
+			batch.WriteByte(',')
+		}
+		batch.Write(line)
+		pending++
+
+		if pending >= restoreBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return restored, nil
+}
+
+// nullTime - NULL для нулевого времени
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+type ArchiverConfig struct {
+	// Префикс ключей в хранилище, например "events"
+	Prefix string
+}
+
+// Archiver выгружает партиции events в холодное хранилище перед удалением
+// и загружает их обратно в Postgres для разового анализа
+type Archiver struct {
+	repo   ArchiveRepository
+	store  archive.Store
+	cfg    ArchiverConfig
+	logger *zap.Logger
+}
+
+func NewArchiver(repo ArchiveRepository, store archive.Store, cfg ArchiverConfig, logger *zap.Logger) *Archiver {
+	return &Archiver{
+		repo:   repo,
+		store:  store,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// ArchivePartition выгружает партицию в хранилище и записывает её в манифест.
+// Уже архивированная партиция повторно не выгружается: так удаление после
+// упавшей попытки не пишет архив заново.
+func (a *Archiver) ArchivePartition(ctx context.Context, partition *Partition) (*Archive, error) {
+	existing, err := a.repo.GetArchive(ctx, partition.Name)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, ErrArchiveNotFound) {
+		return nil, err
+	}
+
+	var events int64
+	compressed, err := compressLines(func(emit func(line []byte) error) error {
+		var err error
+		events, err = a.repo.ExportPartition(ctx, partition, func(event *Event) error {
+			line, err := json.Marshal(event)
+			if err != nil {
+				return fmt.Errorf("failed to marshal event: %w", err)
+			}
+			return emit(line)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer compressed.remove()
+
+	archived := &Archive{
+		PartitionName: partition.Name,
+		From:          partition.From,
+		To:            partition.To,
+		ObjectKey:     a.objectKey(partition.Name, partition.From, ""),
+		Format:        ArchiveFormatNDJSONZstd,
+		Events:        events,
+		Bytes:         compressed.size,
+		Checksum:      compressed.checksum,
+		ArchivedAt:    time.Now().UTC(),
+	}
+
+	if err := a.store.Put(ctx, archived.ObjectKey, compressed.file, compressed.size); err != nil {
+		return nil, err
+	}
+	// Манифест пишется после загрузки: запись в нём значит, что файл точно есть
+	if err := a.repo.SaveArchive(ctx, archived); err != nil {
+		return nil, err
+	}
+
+	a.logger.Info("Event partition archived",
+		zap.String("partition", partition.Name),
+		zap.String("object_key", archived.ObjectKey),
+		zap.Int64("events", archived.Events),
+		zap.Int64("bytes", archived.Bytes),
+	)
+	return archived, nil
+}
+
+// objectKey - <prefix>/<год>/<партиция>.ndjson.zst. У вычищенного архива к
+// имени добавляется version, чтобы файл не подменялся под ключом из манифеста.
+func (a *Archiver) objectKey(partitionName string, from time.Time, version string) string {
+	name := partitionName
+	if version != "" {
+		name += "." + version
+	}
+	return path.Join(a.cfg.Prefix, from.Format("2006"), name+archiveExtension)
+}
+
+// PurgeErased перезаписывает архивы, выгруженные до удаления какого-то
+// пользователя через DeleteUserData, без событий удалённых пользователей.
+// Новый файл пишется под новым ключом, старый удаляется после записи в манифест.
+func (a *Archiver) PurgeErased(ctx context.Context) error {
+	archives, err := a.repo.ListUnpurgedArchives(ctx)
+	if err != nil {
+		return err
+	}
+	if len(archives) == 0 {
+		return nil
+	}
+
+	ids, err := a.repo.ErasedUserIDs(ctx)
+	if err != nil {
+		return err
+	}
+	erased := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		erased[id] = true
+	}
+
+	for _, archived := range archives {
+		if err := a.purgeArchive(ctx, archived, erased); err != nil {
+			return fmt.Errorf("failed to purge archive %s: %w", archived.PartitionName, err)
+		}
+	}
+	return nil
+}
+
+func (a *Archiver) purgeArchive(ctx context.Context, archived *Archive, erased map[string]bool) error {
+	var kept, removed int64
+	compressed, err := compressLines(func(emit func(line []byte) error) error {
+		return a.readArchive(ctx, archived, func(line []byte) error {
+			var event struct {
+				UserID string `json:"user_id"`
+			}
+			if err := json.Unmarshal(line, &event); err != nil {
+				return fmt.Errorf("failed to parse archived event: %w", err)
+			}
+			if erased[event.UserID] {
+				removed++
+				return nil
+			}
+			kept++
+			// Строку копируем: bufio переиспользует буфер
+			return emit(bytes.Clone(line))
+		})
+	})
+	if err != nil {
+		return err
+	}
+	defer compressed.remove()
+
+	now := time.Now().UTC()
+	purged := *archived
+	purged.PurgedAt = &now
+
+	// Ничего не удалено - только отмечаем, что архив проверен
+	if removed == 0 {
+		return a.repo.SaveArchive(ctx, &purged)
+	}
+
+	purged.ObjectKey = a.objectKey(archived.PartitionName, archived.From, now.Format("20060102T150405"))
+	purged.Events = kept
+	purged.Bytes = compressed.size
+	purged.Checksum = compressed.checksum
+
+	if err := a.store.Put(ctx, purged.ObjectKey, compressed.file, compressed.size); err != nil {
+		return err
+	}
+	if err := a.repo.SaveArchive(ctx, &purged); err != nil {
+		return err
+	}
+	if err := a.store.Delete(ctx, archived.ObjectKey); err != nil {
+		return err
+	}
+
+	a.logger.Info("Erased users purged from event archive",
+		zap.String("partition", archived.PartitionName),
+		zap.String("object_key", purged.ObjectKey),
+		zap.Int64("removed", removed),
+		zap.Int64("events", kept),
+	)
+	return nil
+}
+
+// compressedFile - сжатый архив во временном файле, перемотанном в начало
+type compressedFile struct {
+	file     *os.File
+	size     int64
+	checksum string
+}
+
+func (f *compressedFile) remove() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
+
+// compressLines сжимает строки, которые fill передаёт в emit, во временный
+// файл: размер и checksum нужны до загрузки, а архив может не поместиться в память
+func compressLines(fill func(emit func(line []byte) error) error) (*compressedFile, error) {
+	file, err := os.CreateTemp("", "events-archive-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	compressed := &compressedFile{file: file}
+
+	hash := sha256.New()
+	encoder, err := zstd.NewWriter(io.MultiWriter(file, hash), zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		compressed.remove()
+		return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+	}
+
+	err = fill(func(line []byte) error {
+		if _, err := encoder.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to compress event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		encoder.Close()
+		compressed.remove()
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		compressed.remove()
+		return nil, fmt.Errorf("failed to compress archive: %w", err)
+	}
+
+	if compressed.size, err = file.Seek(0, io.SeekCurrent); err != nil {
+		compressed.remove()
+		return nil, fmt.Errorf("failed to get archive size: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		compressed.remove()
+		return nil, fmt.Errorf("failed to rewind archive: %w", err)
+	}
+	compressed.checksum = hex.EncodeToString(hash.Sum(nil))
+
+	return compressed, nil
+}
+
+func (a *Archiver) ListArchives(ctx context.Context, from, to time.Time) ([]*Archive, error) {
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidArchiveRange)
+	}
+	return a.repo.ListArchives(ctx, from, to)
+}
+
+// RestoreArchive загружает события за [from, to) из всех пересекающихся
+// архивов в таблицу table. Каждый архив восстанавливается в своей транзакции
+// и только если его checksum совпал с манифестом.
+func (a *Archiver) RestoreArchive(ctx context.Context, from, to time.Time, table string) (*RestoreResult, error) {
+	if from.IsZero() || to.IsZero() || !from.Before(to) {
+		return nil, fmt.Errorf("%w: from and to are required, from must be before to", ErrInvalidArchiveRange)
+	}
+	if err := validateRestoreTable(table); err != nil {
+		return nil, err
+	}
+
+	archives, err := a.repo.ListArchives(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(archives) == 0 {
+		return nil, fmt.Errorf("%w: no archives for %s - %s", ErrArchiveNotFound,
+			from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	result := &RestoreResult{
+		Table:    table,
+		Archives: archives,
+	}
+	for _, archived := range archives {
+		restored, err := a.repo.RestoreEvents(ctx, table, from, to, func(yield func(line []byte) error) error {
+			return a.readArchive(ctx, archived, yield)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", archived.PartitionName, err)
+		}
+		result.Events += restored
+
+		a.logger.Info("Event archive restored",
+			zap.String("partition", archived.PartitionName),
+			zap.String("table", table),
+			zap.Int64("events", restored),
+		)
+	}
+
+	return result, nil
+}
+
+// readArchive передаёт в yield строки архива и в конце сверяет checksum
+func (a *Archiver) readArchive(ctx context.Context, archived *Archive, yield func(line []byte) error) error {
+	if archived.Format != ArchiveFormatNDJSONZstd {
+		return fmt.Errorf("unsupported archive format %q", archived.Format)
+	}
+
+	object, err := a.store.Get(ctx, archived.ObjectKey)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	hash := sha256.New()
+	compressed := io.TeeReader(object, hash)
+
+	decoder, err := zstd.NewReader(compressed)
+	if err != nil {
+		return fmt.Errorf("failed to create zstd decoder: %w", err)
+	}
+	defer decoder.Close()
+
+	reader := bufio.NewReader(decoder)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if yieldErr := yield(line); yieldErr != nil {
+				return yieldErr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive %s: %w", archived.ObjectKey, err)
+		}
+	}
+
+	// Хвост после последнего zstd фрейма тоже входит в checksum
+	if _, err := io.Copy(io.Discard, compressed); err != nil {
+		return fmt.Errorf("failed to read archive %s: %w", archived.ObjectKey, err)
+	}
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != archived.Checksum {
+		return fmt.Errorf("%w: %s", ErrArchiveCorrupted, archived.ObjectKey)
+	}
+
+	return nil
+}
+
+func validateRestoreTable(table string) error {
+	if !restoreTableName.MatchString(table) {
+		return fmt.Errorf("%w: %q is not a valid table name", ErrInvalidRestoreTable, table)
+	}
+	if !strings.HasPrefix(table, restoreTablePrefix) || table == restoreTablePrefix {
+		return fmt.Errorf("%w: table name must start with %q", ErrInvalidRestoreTable, restoreTablePrefix)
+	}
+	return nil
+}
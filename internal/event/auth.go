@@ -0,0 +1,150 @@
+package event
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	pb "github.com/Wuchinator/realtime-analytics/pkg/pb/events"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Права токенов AdminService
+const (
+	// Удаление и выгрузка данных пользователя
+	AdminScopePrivacy = "privacy"
+	// Регистрация и удаление схем событий, которые проверяет Validate
+	AdminScopeSchemas = "schemas"
+	// Восстановление архивов событий в таблицы restored_*
+	AdminScopeArchives = "archives"
+)
+
+// Методы AdminService, которым нужно право. Остальные доступны любому
+// аутентифицированному администратору.
+var adminMethodScopes = map[string]string{
+	pb.AdminService_DeleteUserData_FullMethodName: AdminScopePrivacy,
+	pb.AdminService_ExportUserData_FullMethodName: AdminScopePrivacy,
+
+	pb.AdminService_RegisterEventSchema_FullMethodName: AdminScopeSchemas,
+	pb.AdminService_DeleteEventSchema_FullMethodName:   AdminScopeSchemas,
+
+	pb.AdminService_RestoreArchive_FullMethodName: AdminScopeArchives,
+}
+
+// AdminCaller - администратор, которому принадлежит токен запроса
+type AdminCaller struct {
+	Name   string
+	Scopes map[string]bool
+	token  []byte
+}
+
+type adminCallerKey struct{}
+
+// AdminCallerFromContext возвращает администратора, которого проверил AdminAuth
+func AdminCallerFromContext(ctx context.Context) (*AdminCaller, bool) {
+	caller, ok := ctx.Value(adminCallerKey{}).(*AdminCaller)
+	return caller, ok
+}
+
+// AdminAuth проверяет bearer токен в metadata authorization и права на метод
+type AdminAuth struct {
+	callers []*AdminCaller
+	logger  *zap.Logger
+}
+
+// NewAdminAuth создаёт проверку без токенов: пока не добавлен ни один токен
+// через AddToken, все вызовы отклоняются
+func NewAdminAuth(logger *zap.Logger) *AdminAuth {
+	return &AdminAuth{logger: logger}
+}
+
+func (a *AdminAuth) AddToken(caller, token string, scopes []string) {
+	granted := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		granted[scope] = true
+	}
+
+	a.callers = append(a.callers, &AdminCaller{
+		Name:   caller,
+		Scopes: granted,
+		token:  []byte(token),
+	})
+}
+
+func (a *AdminAuth) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *AdminAuth) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (a *AdminAuth) authorize(ctx context.Context, method string) (context.Context, error) {
+	caller := a.authenticate(ctx)
+	if caller == nil {
+		a.logger.Warn("Unauthenticated admin call", zap.String("method", method))
+		return nil, status.Error(codes.Unauthenticated, "valid admin token is required")
+	}
+
+	if scope, ok := adminMethodScopes[method]; ok && !caller.Scopes[scope] {
+		a.logger.Warn("Admin call denied",
+			zap.String("method", method),
+			zap.String("caller", caller.Name),
+			zap.String("scope", scope),
+		)
+		return nil, status.Errorf(codes.PermissionDenied, "admin %s has no %q scope", caller.Name, scope)
+	}
+
+	return context.WithValue(ctx, adminCallerKey{}, caller), nil
+}
+
+func (a *AdminAuth) authenticate(ctx context.Context) *AdminCaller {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if !ok || token == "" {
+			continue
+		}
+		// Сравниваем со всеми токенами за постоянное время
+		var found *AdminCaller
+		for _, caller := range a.callers {
+			if subtle.ConstantTimeCompare([]byte(token), caller.token) == 1 {
+				found = caller
+			}
+		}
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// authorizedStream подменяет context потока на context с AdminCaller
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
@@ -11,7 +11,33 @@ var (
 
 	ErrInvalidSessionID = errors.New("invalid session id")
 
+	ErrInvalidAnonymousID = errors.New("invalid anonymous id")
+
+	ErrIdentityConflict = errors.New("anonymous id is already linked to another user")
+
 	ErrEventAlreadyProcessed = errors.New("event already processed")
 
 	ErrEventNotFound = errors.New("event not found")
+
+	ErrInvalidMetadata = errors.New("invalid event metadata")
+
+	ErrInvalidSchema = errors.New("invalid event schema")
+
+	ErrSchemaNotFound = errors.New("event schema not found")
+
+	ErrBuiltInSchema = errors.New("built-in event schema can not be deleted")
+
+	ErrInvalidRequestID = errors.New("invalid request id")
+
+	ErrRequestConflict = errors.New("request id is already used by another request")
+
+	ErrEventsNotPartitioned = errors.New("events table is not partitioned, run migrate up to convert it")
+
+	ErrArchiveNotFound = errors.New("event archive not found")
+
+	ErrArchiveCorrupted = errors.New("event archive checksum mismatch")
+
+	ErrInvalidArchiveRange = errors.New("invalid archive time range")
+
+	ErrInvalidRestoreTable = errors.New("invalid restore table")
 )
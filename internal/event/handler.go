@@ -5,24 +5,36 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
+	"github.com/Wuchinator/realtime-analytics/pkg/identity"
 	pb "github.com/Wuchinator/realtime-analytics/pkg/pb/events"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+type HandlerConfig struct {
+	// TrackEventStream пишет батч, когда накопилось столько событий
+	StreamBatchSize int
+	// или когда прошло столько времени с прошлого flush
+	StreamFlushInterval time.Duration
+}
+
 type Handler struct {
 	pb.UnimplementedEventServiceServer
 	service *Service
+	cfg     HandlerConfig
 	logger  *zap.Logger
 }
 
-func NewHandler(service *Service, logger *zap.Logger) *Handler {
+func NewHandler(service *Service, cfg HandlerConfig, logger *zap.Logger) *Handler {
 	return &Handler{
 		service: service,
+		cfg:     cfg,
 		logger:  logger,
 	}
 }
@@ -32,17 +44,20 @@ func (h *Handler) TrackEvent(ctx context.Context, req *pb.TrackEventRequest) (*p
 		"TrackEvent",
 		zap.String("event_id", req.Event.EventId),
 		zap.String("event_type", req.Event.EventType.String()),
+		zap.String("event_name", req.Event.EventName),
 	)
 
 	event, err := h.protoToEvent(req.Event)
 	if err != nil {
+		eventsRejected.WithLabelValues(rejectInvalidPayload).Inc()
 		h.logger.Error("can not to convert proto to event", zap.Error(err))
 		return nil, status.Errorf(codes.InvalidArgument, "can't to convert proto to event: %v", err)
 	}
 
 	if err := h.service.TrackEvent(ctx, event); err != nil {
 		switch {
-		case errors.Is(err, ErrEventNotFound), errors.Is(err, ErrInvalidSessionID), errors.Is(err, ErrInvalidUserID):
+		case errors.Is(err, ErrEventNotFound), errors.Is(err, ErrInvalidSessionID), errors.Is(err, ErrInvalidUserID),
+			errors.Is(err, ErrInvalidAnonymousID), errors.Is(err, ErrInvalidEventType), errors.Is(err, ErrInvalidMetadata):
 			return nil, status.Errorf(codes.InvalidArgument, "can't track event: %v", err.Error())
 		default:
 			return nil, status.Errorf(codes.Internal, "can't track event: %v", err.Error())
@@ -65,28 +80,111 @@ func (h *Handler) TrackEventBatch(ctx context.Context, req *pb.TrackEventBatchRe
 		return nil, status.Error(codes.InvalidArgument, "events list is empty")
 	}
 
+	// Результаты собираются в порядке запроса: события, которые не удалось
+	// разобрать, сразу invalid, остальные получат статус от сервиса
+	results := make([]*EventResult, len(req.Events))
 	events := make([]*Event, 0, len(req.Events))
-	for _, protoEvent := range req.Events {
+	eventIdx := make([]int, 0, len(req.Events))
+	for i, protoEvent := range req.Events {
 		event, err := h.protoToEvent(protoEvent)
 		if err != nil {
+			eventsRejected.WithLabelValues(rejectInvalidPayload).Inc()
 			h.logger.Warn("Invalid event in batch",
 				zap.Error(err),
 				zap.String("event_id", protoEvent.EventId),
 			)
+			results[i] = NewEventResult(protoEvent.EventId, EventStatusInvalid, err)
 			continue
 		}
 		events = append(events, event)
+		eventIdx = append(eventIdx, i)
+	}
+
+	if len(events) > 0 {
+		tracked, err := h.service.TrackEventBatch(ctx, events)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to track batch: %v", err)
+		}
+		for j, result := range tracked {
+			results[eventIdx[j]] = result
+		}
+	}
+
+	return batchResponse(results), nil
+}
+
+func batchResponse(results []*EventResult) *pb.TrackEventBatchResponse {
+	resp := &pb.TrackEventBatchResponse{
+		Results: make([]*pb.EventResult, len(results)),
+	}
+
+	for i, result := range results {
+		resp.Results[i] = &pb.EventResult{
+			EventId: result.EventID,
+			Status:  eventStatusToProto(result.Status),
+			Reason:  result.Reason,
+		}
+
+		switch result.Status {
+		case EventStatusAccepted:
+			resp.ProcessedCount++
+		case EventStatusInvalid, EventStatusFailed:
+			resp.FailedEventIds = append(resp.FailedEventIds, result.EventID)
+		}
 	}
-	successCount, failedIDs, err := h.service.TrackEventBatch(ctx, events)
+
+	resp.Success = len(resp.FailedEventIds) == 0
+	if resp.Success {
+		resp.Message = "Batch processed"
+	} else {
+		resp.Message = fmt.Sprintf("Batch processed, %d of %d events rejected", len(resp.FailedEventIds), len(results))
+	}
+
+	return resp
+}
+
+func eventStatusToProto(s EventStatus) pb.EventStatus {
+	switch s {
+	case EventStatusAccepted:
+		return pb.EventStatus_EVENT_STATUS_ACCEPTED
+	case EventStatusDuplicate:
+		return pb.EventStatus_EVENT_STATUS_DUPLICATE
+	case EventStatusInvalid:
+		return pb.EventStatus_EVENT_STATUS_INVALID
+	case EventStatusFailed:
+		return pb.EventStatus_EVENT_STATUS_FAILED
+	default:
+		return pb.EventStatus_EVENT_STATUS_UNSPECIFIED
+	}
+}
+
+func (h *Handler) Identify(ctx context.Context, req *pb.IdentifyRequest) (*pb.IdentifyResponse, error) {
+	h.logger.Debug("Identify",
+		zap.String("anonymous_id", req.AnonymousId),
+		zap.String("user_id", req.UserId),
+	)
+
+	userID, err := uuid.Parse(req.UserId)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	}
+
+	created, err := h.service.Identify(ctx, NewIdentityLink(req.AnonymousId, userID))
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to track batch: %v", err)
+		switch {
+		case errors.Is(err, ErrInvalidAnonymousID), errors.Is(err, ErrInvalidUserID):
+			return nil, status.Errorf(codes.InvalidArgument, "can't identify: %v", err)
+		case errors.Is(err, ErrIdentityConflict):
+			return nil, status.Errorf(codes.AlreadyExists, "can't identify: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "can't identify: %v", err)
+		}
 	}
 
-	return &pb.TrackEventBatchResponse{
-		Success:        true,
-		Message:        "Batch processed",
-		ProcessedCount: int32(successCount),
-		FailedEventIds: failedIDs,
+	return &pb.IdentifyResponse{
+		AnonymousId: req.AnonymousId,
+		UserId:      userID.String(),
+		Created:     created,
 	}, nil
 }
 
@@ -107,9 +205,18 @@ func (h *Handler) protoToEvent(protoEvent *pb.Event) (*Event, error) {
 		eventID = uuid.New()
 	}
 
-	userID, err := uuid.Parse(protoEvent.UserId)
-	if err != nil {
-		return nil, ErrInvalidUserID
+	// До логина user_id неизвестен, события пишутся под id анонимного посетителя
+	var userID uuid.UUID
+	switch {
+	case protoEvent.UserId != "":
+		userID, err = uuid.Parse(protoEvent.UserId)
+		if err != nil {
+			return nil, ErrInvalidUserID
+		}
+	case protoEvent.AnonymousId != "":
+		userID = identity.AnonymousUserID(protoEvent.AnonymousId)
+	default:
+		return nil, fmt.Errorf("%w: user_id or anonymous_id is required", ErrInvalidUserID)
 	}
 
 	sessionID, err := uuid.Parse(protoEvent.SessionId)
@@ -127,13 +234,33 @@ func (h *Handler) protoToEvent(protoEvent *pb.Event) (*Event, error) {
 		productID = &pid
 	}
 
-	eventType := h.eventTypeToString(protoEvent.EventType)
+	// Строковое имя позволяет слать типы из реестра, которых нет в enum
+	eventType := protoEvent.EventName
+	if eventType == "" {
+		eventType = h.eventTypeToString(protoEvent.EventType)
+	}
 
-	data, err := json.Marshal(protoEvent.Metadata)
+	// properties с типами значений важнее строковой metadata старых клиентов
+	values := make(map[string]any, len(protoEvent.Metadata)+len(protoEvent.GetProperties().GetFields()))
+	for k, v := range protoEvent.Metadata {
+		values[k] = v
+	}
+	for k, v := range protoEvent.GetProperties().AsMap() {
+		values[k] = v
+	}
+
+	data, err := json.Marshal(values)
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal metadata: %v", err)
 	}
 
+	// Без timestamp AsTime дал бы 1970 год: событие попало бы в events_default
+	// и удалилось бы по retention. Как и в Segment, берём время получения.
+	createdAt := time.Now().UTC()
+	if protoEvent.Timestamp != nil {
+		createdAt = protoEvent.Timestamp.AsTime()
+	}
+
 	event := &Event{
 		ID:        eventID,
 		EventType: eventType,
@@ -141,7 +268,10 @@ func (h *Handler) protoToEvent(protoEvent *pb.Event) (*Event, error) {
 		SessionID: sessionID,
 		ProductID: productID,
 		Data:      data,
-		CreatedAt: protoEvent.Timestamp.AsTime(),
+		CreatedAt: createdAt,
+	}
+	if protoEvent.AnonymousId != "" {
+		event.AnonymousID = &protoEvent.AnonymousId
 	}
 	return event, nil
 }
@@ -162,27 +292,45 @@ func (h *Handler) eventTypeToString(eventType pb.EventType) string {
 	case pb.EventType_EVENT_TYPE_SEARCH:
 		return EventTypeSearch
 	default:
-		return "unknown"
+		// Пустой тип не пройдёт Validate
+		return ""
 	}
 }
 
 func (h *Handler) eventToProto(event *Event) (*pb.Event, error) {
-	var metadata map[string]string
-	if err := json.Unmarshal(event.Data, &metadata); err != nil {
-		metadata = make(map[string]string)
+	var values map[string]any
+	if err := json.Unmarshal(event.Data, &values); err != nil {
+		values = make(map[string]any)
+	}
+
+	properties, err := structpb.NewStruct(values)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert data to properties: %w", err)
+	}
+
+	metadata := make(map[string]string, len(values))
+	for k, v := range values {
+		if str, ok := v.(string); ok {
+			metadata[k] = str
+		}
 	}
 
 	protoEvent := &pb.Event{
-		EventId:   event.ID.String(),
-		UserId:    event.UserID.String(),
-		SessionId: event.SessionID.String(),
-		Metadata:  metadata,
-		Timestamp: timestamppb.New(event.CreatedAt),
+		EventId:    event.ID.String(),
+		EventName:  event.EventType,
+		UserId:     event.UserID.String(),
+		SessionId:  event.SessionID.String(),
+		Metadata:   metadata,
+		Properties: properties,
+		Timestamp:  timestamppb.New(event.CreatedAt),
 	}
 
 	if event.ProductID != nil {
 		protoEvent.ProductId = event.ProductID.String()
 	}
+	if event.AnonymousID != nil {
+		protoEvent.AnonymousId = *event.AnonymousID
+	}
 
 	return protoEvent, nil
 }
@@ -0,0 +1,273 @@
+package event
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	pb "github.com/Wuchinator/realtime-analytics/pkg/pb/events"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type HTTPConfig struct {
+	// "*" разрешает любой origin
+	AllowedOrigins []string
+	// Лимит на тело запроса после распаковки gzip
+	MaxBodyBytes int64
+}
+
+// HTTPHandler - JSON шлюз для браузеров и мобильных клиентов. Запросы
+// разбираются в те же protobuf сообщения и идут через gRPC Handler, поэтому
+// валидация и ошибки совпадают с gRPC API.
+type HTTPHandler struct {
+	handler *Handler
+	cfg     HTTPConfig
+	logger  *zap.Logger
+	mux     *http.ServeMux
+}
+
+func NewHTTPHandler(handler *Handler, cfg HTTPConfig, logger *zap.Logger) *HTTPHandler {
+	h := &HTTPHandler{
+		handler: handler,
+		cfg:     cfg,
+		logger:  logger,
+		mux:     http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("/v1/events", h.trackEvent)
+	h.mux.HandleFunc("/v1/events/batch", h.trackEventBatch)
+	h.mux.HandleFunc("/v1/identities", h.identify)
+
+	// Segment-совместимый API, чтобы существующие SDK можно было направить сюда
+	h.mux.HandleFunc("/v1/track", h.segmentSingle(segmentTrack))
+	h.mux.HandleFunc("/v1/identify", h.segmentSingle(segmentIdentify))
+	h.mux.HandleFunc("/v1/page", h.segmentSingle(segmentPage))
+	h.mux.HandleFunc("/v1/batch", h.segmentBatch)
+
+	return h
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.setCORSHeaders(w, r)
+
+	// Preflight обрабатывается до роутинга
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+// POST /v1/events, тело - Event в JSON
+func (h *HTTPHandler) trackEvent(w http.ResponseWriter, r *http.Request) {
+	event := &pb.Event{}
+	if !h.decode(w, r, event) {
+		return
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	resp, err := h.handler.TrackEvent(ctx, &pb.TrackEventRequest{Event: event})
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /v1/events/batch, тело - {"events": [...]}
+func (h *HTTPHandler) trackEventBatch(w http.ResponseWriter, r *http.Request) {
+	req := &pb.TrackEventBatchRequest{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	resp, err := h.handler.TrackEventBatch(ctx, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /v1/identities, тело - {"anonymous_id": "...", "user_id": "..."}
+func (h *HTTPHandler) identify(w http.ResponseWriter, r *http.Request) {
+	req := &pb.IdentifyRequest{}
+	if !h.decode(w, r, req) {
+		return
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	resp, err := h.handler.Identify(ctx, req)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// decode читает тело (с распаковкой gzip) и разбирает его в msg.
+// При ошибке ответ уже записан и возвращается false.
+func (h *HTTPHandler) decode(w http.ResponseWriter, r *http.Request, msg proto.Message) bool {
+	body, ok := h.readPost(w, r)
+	if !ok {
+		return false
+	}
+
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(body, msg); err != nil {
+		eventsRejected.WithLabelValues(rejectInvalidPayload).Inc()
+		h.writeError(w, status.Errorf(codes.InvalidArgument, "invalid JSON: %v", err))
+		return false
+	}
+
+	return true
+}
+
+// readPost проверяет метод и читает тело POST запроса
+func (h *HTTPHandler) readPost(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeErrorBody(w, http.StatusMethodNotAllowed, codes.Unimplemented, "method not allowed")
+		return nil, false
+	}
+
+	body, err := h.readBody(r)
+	if err != nil {
+		h.writeError(w, err)
+		return nil, false
+	}
+	return body, true
+}
+
+func (h *HTTPHandler) readBody(r *http.Request) ([]byte, error) {
+	var reader io.Reader = r.Body
+
+	switch strings.ToLower(r.Header.Get("Content-Encoding")) {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid gzip body: %v", err)
+		}
+		defer gz.Close()
+		reader = gz
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported content encoding: %s", r.Header.Get("Content-Encoding"))
+	}
+
+	// Читаем на байт больше лимита, чтобы отличить тело ровно на лимит от слишком большого
+	body, err := io.ReadAll(io.LimitReader(reader, h.cfg.MaxBodyBytes+1))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to read body: %v", err)
+	}
+	if int64(len(body)) > h.cfg.MaxBodyBytes {
+		return nil, errBodyTooLarge
+	}
+
+	return body, nil
+}
+
+var errBodyTooLarge = errors.New("request body too large")
+
+func (h *HTTPHandler) setCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+
+	switch {
+	case slices.Contains(h.cfg.AllowedOrigins, "*"):
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	case slices.Contains(h.cfg.AllowedOrigins, origin):
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Add("Vary", "Origin")
+	default:
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Encoding, Authorization, traceparent, tracestate")
+	w.Header().Set("Access-Control-Max-Age", "600")
+}
+
+func (h *HTTPHandler) writeJSON(w http.ResponseWriter, code int, msg proto.Message) {
+	body, err := (protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}).Marshal(msg)
+	if err != nil {
+		h.logger.Error("Failed to marshal HTTP response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+type httpError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (h *HTTPHandler) writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errBodyTooLarge) {
+		writeErrorBody(w, http.StatusRequestEntityTooLarge, codes.InvalidArgument,
+			fmt.Sprintf("%v: limit is %d bytes", err, h.cfg.MaxBodyBytes))
+		return
+	}
+
+	st := status.Convert(err)
+	writeErrorBody(w, httpStatus(st.Code()), st.Code(), st.Message())
+}
+
+func writeErrorBody(w http.ResponseWriter, httpCode int, code codes.Code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpCode)
+	json.NewEncoder(w).Encode(httpError{Code: code.String(), Message: message})
+}
+
+// httpStatus - то же соответствие gRPC -> HTTP, что у grpc-gateway
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.Canceled:
+		return 499
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,55 @@
+package event
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	eventsIngested = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_ingested_total",
+		Help: "Events accepted by event-service by event type",
+	}, []string{"event_type"})
+
+	eventsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "events_rejected_total",
+		Help: "Events rejected by event-service by reason",
+	}, []string{"reason"})
+)
+
+// Причины отказа для events_rejected_total
+const (
+	rejectInvalidPayload     = "invalid_payload"
+	rejectInvalidEventType   = "invalid_event_type"
+	rejectInvalidUserID      = "invalid_user_id"
+	rejectInvalidAnonymousID = "invalid_anonymous_id"
+	rejectInvalidSessionID   = "invalid_session_id"
+	rejectInvalidMetadata    = "invalid_metadata"
+	rejectDuplicate          = "duplicate"
+	rejectStorageError       = "storage_error"
+)
+
+func rejectReason(err error) string {
+	switch {
+	case errors.Is(err, ErrInvalidEventType):
+		return rejectInvalidEventType
+	case errors.Is(err, ErrInvalidUserID):
+		return rejectInvalidUserID
+	case errors.Is(err, ErrInvalidAnonymousID):
+		return rejectInvalidAnonymousID
+	case errors.Is(err, ErrInvalidSessionID):
+		return rejectInvalidSessionID
+	case errors.Is(err, ErrInvalidMetadata):
+		return rejectInvalidMetadata
+	case errors.Is(err, ErrDuplicateEvent):
+		return rejectDuplicate
+	default:
+		return rejectStorageError
+	}
+}
+
+func recordRejected(err error) {
+	eventsRejected.WithLabelValues(rejectReason(err)).Inc()
+}
@@ -2,8 +2,10 @@ package event
 
 import (
 	"encoding/json"
+	"fmt"
 	"time"
 
+	"github.com/Wuchinator/realtime-analytics/pkg/identity"
 	"github.com/google/uuid"
 )
 
@@ -11,6 +13,7 @@ type Event struct {
 	ID          uuid.UUID       `db:"id" json:"id"`
 	EventType   string          `db:"event_type" json:"event_type"`
 	UserID      uuid.UUID       `db:"user_id" json:"user_id"`
+	AnonymousID *string         `db:"anonymous_id" json:"anonymous_id,omitempty"`
 	SessionID   uuid.UUID       `db:"session_id" json:"session_id"`
 	ProductID   *uuid.UUID      `db:"product_id" json:"product_id"`
 	Data        json.RawMessage `db:"data" json:"data"`
@@ -25,6 +28,9 @@ const (
 	EventTypeRemoveFromCart = "remove_from_cart"
 	EventTypePurchase       = "purchase"
 	EventTypeSearch         = "search"
+
+	// identify приходит только из Segment API: связывает anonymousId с userId
+	EventTypeIdentify = "identify"
 )
 
 func NewEvent(
@@ -49,20 +55,197 @@ func NewEvent(
 	}, nil
 }
 
-func (e *Event) Validate() error {
+// Validate проверяет событие по схеме его типа из реестра. nil schema значит,
+// что тип не зарегистрирован.
+func (e *Event) Validate(schema *EventSchema) error {
 	if e.EventType == "" {
 		return ErrInvalidEventType
 	}
+	if schema == nil || schema.Name != e.EventType {
+		return fmt.Errorf("%w: %q is not registered", ErrInvalidEventType, e.EventType)
+	}
 	if e.UserID == uuid.Nil {
 		return ErrInvalidUserID
 	}
+	if e.AnonymousID != nil && (*e.AnonymousID == "" || len(*e.AnonymousID) > identity.MaxAnonymousIDLength) {
+		return ErrInvalidAnonymousID
+	}
 	if e.SessionID == uuid.Nil {
 		return ErrInvalidSessionID
 	}
+	return schema.ValidateData(e.Data)
+}
+
+// IdentityLink возвращает связь anonymous_id с user_id, если событие несёт оба
+// id. Событие только с anonymous_id сохраняется под AnonymousUserID и ничего не связывает.
+func (e *Event) IdentityLink() *IdentityLink {
+	if e.AnonymousID == nil {
+		return nil
+	}
+	link := NewIdentityLink(*e.AnonymousID, e.UserID)
+	if link.AnonymousUserID == link.UserID {
+		return nil
+	}
+	return link
+}
+
+// IdentityLink - ребро identity graph: анонимный посетитель стал пользователем UserID
+type IdentityLink struct {
+	AnonymousID string `db:"anonymous_id" json:"anonymous_id"`
+	// user_id, под которым сохранены события посетителя до логина
+	AnonymousUserID uuid.UUID `db:"anonymous_user_id" json:"anonymous_user_id"`
+	UserID          uuid.UUID `db:"user_id" json:"user_id"`
+	LinkedAt        time.Time `db:"linked_at" json:"linked_at"`
+}
+
+func NewIdentityLink(anonymousID string, userID uuid.UUID) *IdentityLink {
+	return &IdentityLink{
+		AnonymousID:     anonymousID,
+		AnonymousUserID: identity.AnonymousUserID(anonymousID),
+		UserID:          userID,
+		LinkedAt:        time.Now().UTC(),
+	}
+}
+
+func (l *IdentityLink) Validate() error {
+	if l.AnonymousID == "" || len(l.AnonymousID) > identity.MaxAnonymousIDLength {
+		return ErrInvalidAnonymousID
+	}
+	if l.UserID == uuid.Nil {
+		return ErrInvalidUserID
+	}
+	// Иначе события посетителя ссылались бы сами на себя
+	if l.UserID == l.AnonymousUserID {
+		return fmt.Errorf("%w: user_id must differ from anonymous_id", ErrInvalidUserID)
+	}
 	return nil
 }
 
+// EventStatus - результат приёма одного события из батча
+type EventStatus string
+
+const (
+	EventStatusAccepted  EventStatus = "accepted"
+	EventStatusDuplicate EventStatus = "duplicate"
+	EventStatusInvalid   EventStatus = "invalid"
+	// Событие валидно, но не сохранено и не попадёт в Kafka
+	EventStatusFailed EventStatus = "failed"
+)
+
+type EventResult struct {
+	EventID string
+	Status  EventStatus
+	Reason  string
+}
+
+func NewEventResult(eventID string, status EventStatus, err error) *EventResult {
+	result := &EventResult{
+		EventID: eventID,
+		Status:  status,
+	}
+	if err != nil {
+		result.Reason = err.Error()
+	}
+	return result
+}
+
+func CountResults(results []*EventResult, status EventStatus) int {
+	count := 0
+	for _, result := range results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
 func (e *Event) MarkAsProcessed() {
 	now := time.Now().UTC()
 	e.ProcessedAt = &now
 }
+
+// OutboxMessage - сообщение для Kafka, сохранённое в той же транзакции, что и событие
+type OutboxMessage struct {
+	ID            int64           `db:"id" json:"id"`
+	EventID       *uuid.UUID      `db:"event_id" json:"event_id"`
+	MessageKey    string          `db:"message_key" json:"message_key"`
+	Payload       json.RawMessage `db:"payload" json:"payload"`
+	Attempts      int             `db:"attempts" json:"attempts"`
+	LastError     *string         `db:"last_error" json:"last_error"`
+	CreatedAt     time.Time       `db:"created_at" json:"created_at"`
+	NextAttemptAt time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	SentAt        *time.Time      `db:"sent_at" json:"sent_at"`
+	// Trace context запроса, в котором событие было принято (traceparent и т.п.)
+	TraceContext json.RawMessage `db:"trace_context" json:"trace_context"`
+	// Kafka tombstone: consumers удаляют все данные по MessageKey
+	Tombstone bool `db:"tombstone" json:"tombstone"`
+	// До этого момента сообщение забрал relay одного из инстансов
+	ClaimedUntil *time.Time `db:"claimed_until" json:"claimed_until"`
+}
+
+func NewOutboxMessage(event *Event) (*OutboxMessage, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	eventID := event.ID
+
+	return &OutboxMessage{
+		EventID: &eventID,
+		// События одного пользователя идут в одну партицию
+		MessageKey:    event.UserID.String(),
+		Payload:       payload,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// NewTombstoneMessage - сообщение с ключом userID и пустым value. Оно идёт в ту
+// же партицию, что и события пользователя, поэтому consumer получает его после них.
+func NewTombstoneMessage(userID uuid.UUID) *OutboxMessage {
+	now := time.Now().UTC()
+
+	return &OutboxMessage{
+		MessageKey:    userID.String(),
+		Payload:       json.RawMessage("{}"),
+		CreatedAt:     now,
+		NextAttemptAt: now,
+		Tombstone:     true,
+	}
+}
+
+func (m *OutboxMessage) SetTraceContext(carrier map[string]string) error {
+	if len(carrier) == 0 {
+		m.TraceContext = nil
+		return nil
+	}
+
+	data, err := json.Marshal(carrier)
+	if err != nil {
+		return err
+	}
+	m.TraceContext = data
+	return nil
+}
+
+func (m *OutboxMessage) TraceCarrier() map[string]string {
+	carrier := map[string]string{}
+	if len(m.TraceContext) > 0 {
+		_ = json.Unmarshal(m.TraceContext, &carrier)
+	}
+	return carrier
+}
+
+func (m *OutboxMessage) MarkAsSent() {
+	now := time.Now().UTC()
+	m.SentAt = &now
+}
+
+func (m *OutboxMessage) MarkAsFailed(err error, backoff time.Duration) {
+	msg := err.Error()
+	m.Attempts++
+	m.LastError = &msg
+	m.NextAttemptAt = time.Now().UTC().Add(backoff)
+}
@@ -0,0 +1,563 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Гранулярность партиций events
+const (
+	PartitionDay   = "day"
+	PartitionMonth = "month"
+)
+
+const (
+	partitionPrefix = "events_p"
+	// Ключ pg_advisory_lock, чтобы партиции обслуживала одна реплика event-service
+	partitionLockKey = 7_240_019_231
+	// Сколько строк удаляется за один DELETE при чистке по retention
+	retentionDeleteBatch = 10000
+)
+
+// Partition - партиция events с диапазоном created_at [From, To)
+type Partition struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+// NewPartition возвращает партицию гранулярности interval, в которую попадает t
+func NewPartition(interval string, t time.Time) (*Partition, error) {
+	t = t.UTC()
+
+	switch interval {
+	case PartitionDay:
+		from := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return &Partition{
+			Name: partitionPrefix + from.Format("20060102"),
+			From: from,
+			To:   from.AddDate(0, 0, 1),
+		}, nil
+	case PartitionMonth:
+		from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return &Partition{
+			Name: partitionPrefix + from.Format("200601"),
+			From: from,
+			To:   from.AddDate(0, 1, 0),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown partition interval %q", interval)
+	}
+}
+
+// parsePartition восстанавливает диапазон по имени партиции. Партиции с
+// другими именами (events_default и созданные вручную) не обслуживаются.
+func parsePartition(name string) (*Partition, bool) {
+	suffix, ok := strings.CutPrefix(name, partitionPrefix)
+	if !ok {
+		return nil, false
+	}
+
+	var (
+		interval string
+		layout   string
+	)
+	switch len(suffix) {
+	case len("20060102"):
+		interval, layout = PartitionDay, "20060102"
+	case len("200601"):
+		interval, layout = PartitionMonth, "200601"
+	default:
+		return nil, false
+	}
+
+	t, err := time.Parse(layout, suffix)
+	if err != nil {
+		return nil, false
+	}
+
+	partition, err := NewPartition(interval, t)
+	if err != nil || partition.Name != name {
+		return nil, false
+	}
+	return partition, true
+}
+
+func (p *Partition) overlaps(other *Partition) bool {
+	return p.From.Before(other.To) && other.From.Before(p.To)
+}
+
+type PartitionRepository interface {
+	// IsPartitioned - false, если events осталась обычной таблицей
+	IsPartitioned(ctx context.Context) (bool, error)
+	// Lock берёт advisory lock обслуживания. false значит, что его держит другая реплика.
+	Lock(ctx context.Context) (unlock func(), ok bool, err error)
+	ListPartitions(ctx context.Context) ([]*Partition, error)
+	// CreatePartition создаёт партицию и переносит в неё строки из events_default.
+	// Возвращает число перенесённых строк.
+	CreatePartition(ctx context.Context, partition *Partition) (int64, error)
+	DropPartition(ctx context.Context, partition *Partition) error
+	DetachPartition(ctx context.Context, partition *Partition) error
+	// DeleteExpired удаляет события типов eventTypes (или всех, кроме exceptTypes,
+	// если eventTypes пуст) старше before
+	DeleteExpired(ctx context.Context, eventTypes, exceptTypes []string, before time.Time) (int64, error)
+	// DeleteEventIDs удаляет из event_ids id событий старше before
+	DeleteEventIDs(ctx context.Context, before time.Time) (int64, error)
+}
+
+type partitionRepository struct {
+	db     *postgres.DB
+	logger *zap.Logger
+}
+
+func NewPartitionRepository(db *postgres.DB, logger *zap.Logger) PartitionRepository {
+	return &partitionRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *partitionRepository) Lock(ctx context.Context) (func(), bool, error) {
+	// Session-level lock живёт, пока открыто это соединение
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, "SELECT pg_try_advisory_lock($1)", partitionLockKey); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to take partition lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// Отдельный context: lock нужно отпустить, даже если ctx уже отменён
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", partitionLockKey); err != nil {
+			r.logger.Warn("Failed to release partition lock", zap.Error(err))
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
+func (r *partitionRepository) IsPartitioned(ctx context.Context) (bool, error) {
+	var partitioned bool
+	err := r.db.GetContext(ctx, &partitioned, `
+		SELECT relkind = 'p' FROM pg_class WHERE oid = 'events'::regclass
+	`)
+	if err != nil {
+		return false, fmt.Errorf("failed to check events table: %w", err)
+	}
+	return partitioned, nil
+}
+
+func (r *partitionRepository) ListPartitions(ctx context.Context) ([]*Partition, error) {
+	var names []string
+	err := r.db.SelectContext(ctx, &names, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'events'::regclass
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	partitions := make([]*Partition, 0, len(names))
+	for _, name := range names {
+		if partition, ok := parsePartition(name); ok {
+			partitions = append(partitions, partition)
+		}
+	}
+
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].From.Before(partitions[j].From)
+	})
+	return partitions, nil
+}
+
+func (r *partitionRepository) CreatePartition(ctx context.Context, partition *Partition) (int64, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	name := pq.QuoteIdentifier(partition.Name)
+
+	// CREATE ... PARTITION OF упал бы, если в events_default уже есть строки
+	// из этого диапазона, поэтому таблица создаётся отдельно и подключается после переноса
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE %s (LIKE events INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+	`, name)); err != nil {
+		return 0, fmt.Errorf("failed to create partition %s: %w", partition.Name, err)
+	}
+
+	result, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		WITH moved AS (
+			DELETE FROM events_default
+			WHERE created_at >= $1 AND created_at < $2
+			RETURNING *
+		)
+		INSERT INTO %s SELECT * FROM moved
+	`, name), partition.From, partition.To)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move rows to partition %s: %w", partition.Name, err)
+	}
+
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		ALTER TABLE events ATTACH PARTITION %s FOR VALUES FROM (%s) TO (%s)
+	`, name, partitionBound(partition.From), partitionBound(partition.To))); err != nil {
+		return 0, fmt.Errorf("failed to attach partition %s: %w", partition.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return moved, nil
+}
+
+func (r *partitionRepository) DropPartition(ctx context.Context, partition *Partition) error {
+	if _, err := r.db.ExecContext(ctx, "DROP TABLE IF EXISTS "+pq.QuoteIdentifier(partition.Name)); err != nil {
+		return fmt.Errorf("failed to drop partition %s: %w", partition.Name, err)
+	}
+	return nil
+}
+
+// DetachPartition оставляет партицию отдельной таблицей с тем же именем
+func (r *partitionRepository) DetachPartition(ctx context.Context, partition *Partition) error {
+	if _, err := r.db.ExecContext(ctx, "ALTER TABLE events DETACH PARTITION "+pq.QuoteIdentifier(partition.Name)); err != nil {
+		return fmt.Errorf("failed to detach partition %s: %w", partition.Name, err)
+	}
+	return nil
+}
+
+func (r *partitionRepository) DeleteExpired(ctx context.Context, eventTypes, exceptTypes []string, before time.Time) (int64, error) {
+	// Пачками, чтобы не держать блокировки на миллионах строк в одной транзакции.
+	// pq.StringArray(nil) приходит как NULL, а ANY(NULL) никогда не совпадает,
+	// поэтому пустые списки приводятся к '{}'.
+	query := `
+		DELETE FROM events
+		WHERE (id, created_at) IN (
+			SELECT id, created_at
+			FROM events
+			WHERE created_at < $1
+			  AND (cardinality(COALESCE($2::text[], '{}')) = 0 OR event_type = ANY($2))
+			  AND NOT (event_type = ANY(COALESCE($3::text[], '{}')))
+			LIMIT $4
+		)
+	`
+
+	var total int64
+	for {
+		result, err := r.db.ExecContext(ctx, query, before, pq.StringArray(eventTypes), pq.StringArray(exceptTypes), retentionDeleteBatch)
+		if err != nil {
+			return total, fmt.Errorf("failed to delete expired events: %w", err)
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		total += deleted
+
+		if deleted < retentionDeleteBatch || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+func (r *partitionRepository) DeleteEventIDs(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM event_ids
+		WHERE id IN (
+			SELECT id FROM event_ids WHERE created_at < $1 LIMIT $2
+		)
+	`
+
+	var total int64
+	for {
+		result, err := r.db.ExecContext(ctx, query, before, retentionDeleteBatch)
+		if err != nil {
+			return total, fmt.Errorf("failed to delete expired event ids: %w", err)
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		total += deleted
+
+		if deleted < retentionDeleteBatch || ctx.Err() != nil {
+			return total, nil
+		}
+	}
+}
+
+// partitionBound - граница диапазона как литерал timestamptz в UTC
+func partitionBound(t time.Time) string {
+	return pq.QuoteLiteral(t.UTC().Format("2006-01-02 15:04:05Z07:00"))
+}
+
+type PartitionConfig struct {
+	// PartitionDay или PartitionMonth
+	Interval string
+	// Сколько партиций вперёд держать созданными, считая текущую
+	Premake int
+	// Срок хранения событий. 0 - хранить всегда.
+	Retention time.Duration
+	// Срок хранения для отдельных типов, 0 - хранить всегда
+	RetentionByType map[string]time.Duration
+	// Отключать просроченные партиции вместо удаления, например для архивации
+	Detach bool
+}
+
+// PartitionManager создаёт партиции events заранее и удаляет просроченные данные
+type PartitionManager struct {
+	repo PartitionRepository
+	// nil - просроченные партиции удаляются без архивации
+	archiver *Archiver
+	cfg      PartitionConfig
+	logger   *zap.Logger
+}
+
+func NewPartitionManager(repo PartitionRepository, archiver *Archiver, cfg PartitionConfig, logger *zap.Logger) (*PartitionManager, error) {
+	if _, err := NewPartition(cfg.Interval, time.Now()); err != nil {
+		return nil, err
+	}
+	if cfg.Premake < 1 {
+		cfg.Premake = 1
+	}
+
+	return &PartitionManager{
+		repo:     repo,
+		archiver: archiver,
+		cfg:      cfg,
+		logger:   logger,
+	}, nil
+}
+
+// Run обслуживает партиции раз в interval до отмены ctx
+func (m *PartitionManager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Maintain(ctx); err != nil && ctx.Err() == nil {
+				m.logger.Error("Failed to maintain event partitions", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Maintain создаёт недостающие партиции, чистит события типов с более
+// коротким сроком хранения, затем удаляет или отключает просроченные партиции
+// и вычищает из архивов пользователей, удалённых после выгрузки.
+// Если обслуживанием занята другая реплика, ничего не делает.
+func (m *PartitionManager) Maintain(ctx context.Context) error {
+	// До lock, чтобы каждая реплика при старте видела непартиционированную events
+	partitioned, err := m.repo.IsPartitioned(ctx)
+	if err != nil {
+		return err
+	}
+	if !partitioned {
+		return ErrEventsNotPartitioned
+	}
+
+	unlock, ok, err := m.repo.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		m.logger.Debug("Event partitions are maintained by another instance")
+		return nil
+	}
+	defer unlock()
+
+	partitions, err := m.repo.ListPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if err := m.createPartitions(ctx, partitions, now); err != nil {
+		return err
+	}
+	// Сначала построчная чистка: в архив просроченных партиций не должны
+	// попасть события типов с более коротким сроком хранения
+	if err := m.deleteExpired(ctx, now); err != nil {
+		return err
+	}
+	if err := m.expirePartitions(ctx, partitions, now); err != nil {
+		return err
+	}
+
+	if m.archiver != nil {
+		return m.archiver.PurgeErased(ctx)
+	}
+	return nil
+}
+
+func (m *PartitionManager) createPartitions(ctx context.Context, existing []*Partition, now time.Time) error {
+	next := now
+	for i := 0; i < m.cfg.Premake; i++ {
+		partition, err := NewPartition(m.cfg.Interval, next)
+		if err != nil {
+			return err
+		}
+		next = partition.To
+
+		// Партиции другой гранулярности остаются после смены EVENTS_PARTITION_INTERVAL
+		if overlapsAny(existing, partition) {
+			continue
+		}
+
+		moved, err := m.repo.CreatePartition(ctx, partition)
+		if err != nil {
+			return err
+		}
+
+		m.logger.Info("Event partition created",
+			zap.String("partition", partition.Name),
+			zap.Time("from", partition.From),
+			zap.Time("to", partition.To),
+			zap.Int64("moved_from_default", moved),
+		)
+	}
+	return nil
+}
+
+// expirePartitions удаляет партиции, в которых просрочены события всех типов,
+// и id этих событий в event_ids. С архивацией партиция удаляется только после
+// успешной выгрузки. События типов с более коротким сроком хранения к этому
+// моменту уже удалены deleteExpired и в архив не попадают.
+func (m *PartitionManager) expirePartitions(ctx context.Context, partitions []*Partition, now time.Time) error {
+	horizon, ok := m.partitionHorizon()
+	if !ok {
+		return nil
+	}
+	cutoff := now.Add(-horizon)
+
+	for _, partition := range partitions {
+		if partition.To.After(cutoff) {
+			continue
+		}
+
+		if m.archiver != nil {
+			if _, err := m.archiver.ArchivePartition(ctx, partition); err != nil {
+				return err
+			}
+		}
+
+		if m.cfg.Detach {
+			if err := m.repo.DetachPartition(ctx, partition); err != nil {
+				return err
+			}
+		} else if err := m.repo.DropPartition(ctx, partition); err != nil {
+			return err
+		}
+
+		m.logger.Info("Expired event partition removed",
+			zap.String("partition", partition.Name),
+			zap.Bool("detached", m.cfg.Detach),
+			zap.Bool("archived", m.archiver != nil),
+		)
+	}
+
+	// Событий старше cutoff уже нет ни в одной партиции, их id больше не нужны
+	// для проверки дубликатов
+	deleted, err := m.repo.DeleteEventIDs(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		m.logger.Info("Expired event ids deleted", zap.Int64("deleted", deleted))
+	}
+	return nil
+}
+
+// partitionHorizon - максимальный срок хранения среди всех типов. false, если
+// какой-то тип хранится всегда и партиции удалять нельзя.
+func (m *PartitionManager) partitionHorizon() (time.Duration, bool) {
+	if m.cfg.Retention <= 0 {
+		return 0, false
+	}
+
+	horizon := m.cfg.Retention
+	for _, retention := range m.cfg.RetentionByType {
+		if retention <= 0 {
+			return 0, false
+		}
+		horizon = max(horizon, retention)
+	}
+	return horizon, true
+}
+
+// deleteExpired удаляет строки, которые просрочены по своему типу, но лежат
+// в партициях, которые ещё нельзя удалить целиком
+func (m *PartitionManager) deleteExpired(ctx context.Context, now time.Time) error {
+	overridden := make([]string, 0, len(m.cfg.RetentionByType))
+	for eventType, retention := range m.cfg.RetentionByType {
+		overridden = append(overridden, eventType)
+		if retention <= 0 {
+			continue
+		}
+
+		deleted, err := m.repo.DeleteExpired(ctx, []string{eventType}, nil, now.Add(-retention))
+		if err != nil {
+			return err
+		}
+		m.logExpired(eventType, deleted)
+	}
+
+	if m.cfg.Retention <= 0 {
+		return nil
+	}
+
+	deleted, err := m.repo.DeleteExpired(ctx, nil, overridden, now.Add(-m.cfg.Retention))
+	if err != nil {
+		return err
+	}
+	m.logExpired("", deleted)
+	return nil
+}
+
+func (m *PartitionManager) logExpired(eventType string, deleted int64) {
+	if deleted == 0 {
+		return
+	}
+	m.logger.Info("Expired events deleted",
+		zap.String("event_type", eventType),
+		zap.Int64("deleted", deleted),
+	)
+}
+
+func overlapsAny(partitions []*Partition, partition *Partition) bool {
+	for _, p := range partitions {
+		if p.overlaps(partition) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,402 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Виды запросов в журнале user_data_requests
+const (
+	UserDataRequestDelete = "delete"
+	UserDataRequestExport = "export"
+)
+
+// UserDataRequest - запись журнала запросов на удаление и выгрузку данных
+// пользователя. ID - ключ идемпотентности.
+type UserDataRequest struct {
+	ID                 uuid.UUID  `db:"id" json:"id"`
+	Kind               string     `db:"kind" json:"kind"`
+	UserID             uuid.UUID  `db:"user_id" json:"user_id"`
+	RequestedBy        string     `db:"requested_by" json:"requested_by"`
+	Reason             string     `db:"reason" json:"reason"`
+	EventsAffected     int64      `db:"events_affected" json:"events_affected"`
+	IdentitiesAffected int64      `db:"identities_affected" json:"identities_affected"`
+	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
+	CompletedAt        *time.Time `db:"completed_at" json:"completed_at"`
+}
+
+// NewUserDataRequest генерирует request id, если клиент его не передал
+func NewUserDataRequest(kind, requestID string, userID uuid.UUID, requestedBy, reason string) (*UserDataRequest, error) {
+	if userID == uuid.Nil {
+		return nil, ErrInvalidUserID
+	}
+
+	id := uuid.New()
+	if requestID != "" {
+		parsed, err := uuid.Parse(requestID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRequestID, err)
+		}
+		id = parsed
+	}
+
+	return &UserDataRequest{
+		ID:          id,
+		Kind:        kind,
+		UserID:      userID,
+		RequestedBy: requestedBy,
+		Reason:      reason,
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// sameRequest проверяет, что повтор request id относится к тому же запросу
+func (r *UserDataRequest) sameRequest(other *UserDataRequest) bool {
+	return r.Kind == other.Kind && r.UserID == other.UserID
+}
+
+type PrivacyRepository interface {
+	// DeleteUserData возвращает сохранённый запрос и true, если он уже был выполнен
+	DeleteUserData(ctx context.Context, req *UserDataRequest) (*UserDataRequest, bool, error)
+	ExportUserData(ctx context.Context, req *UserDataRequest, write func(event *Event) error) (*UserDataRequest, error)
+}
+
+type privacyRepository struct {
+	*repository
+}
+
+func NewPrivacyRepository(db *postgres.DB, logger *zap.Logger) PrivacyRepository {
+	return &privacyRepository{
+		repository: &repository{
+			db:     db,
+			logger: logger,
+		},
+	}
+}
+
+// DeleteUserData в одной транзакции удаляет события пользователя и связанных
+// с ним анонимных id из events и её копий, их сообщения в outbox и связи identity graph, ставит в
+// outbox tombstone для каждого id и записывает запрос в журнал.
+func (r *privacyRepository) DeleteUserData(ctx context.Context, req *UserDataRequest) (*UserDataRequest, bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	stored, created, err := r.claimRequest(ctx, tx, req)
+	if err != nil {
+		return nil, false, err
+	}
+	// Удаление атомарное, поэтому существующая запись значит, что оно уже выполнено
+	if !created {
+		return stored, true, nil
+	}
+
+	ids, err := r.linkedUserIDs(ctx, tx, req.UserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Восстановление архива, начатое до этого запроса, должно закоммитить
+	// строки до удаления из копий events, а начатое после - увидеть запрос
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, erasureLockKey); err != nil {
+		return nil, false, fmt.Errorf("failed to lock erasure: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM events WHERE user_id = ANY($1::uuid[])
+	`, pq.StringArray(ids))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to delete events: %w", err)
+	}
+	if req.EventsAffected, err = result.RowsAffected(); err != nil {
+		return nil, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	copied, err := r.deleteEventCopies(ctx, tx, ids)
+	if err != nil {
+		return nil, false, err
+	}
+	req.EventsAffected += copied
+
+	// В payload отправленных и ещё не отправленных сообщений лежат те же события.
+	// Сообщения, которые relay уже забрал и публикует, не трогаем: tombstone
+	// ниже встанет за ними в очередь ключа и дойдёт до consumers после них.
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM outbox
+		WHERE message_key = ANY($1::text[])
+		  AND (claimed_until IS NULL OR claimed_until < NOW())
+	`, pq.StringArray(ids)); err != nil {
+		return nil, false, fmt.Errorf("failed to delete outbox messages: %w", err)
+	}
+
+	result, err = tx.ExecContext(ctx, `
+		DELETE FROM identity_links WHERE user_id = $1 OR anonymous_user_id = ANY($2::uuid[])
+	`, req.UserID, pq.StringArray(ids))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to delete identity links: %w", err)
+	}
+	if req.IdentitiesAffected, err = result.RowsAffected(); err != nil {
+		return nil, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := r.insertOutbox(ctx, tx, NewTombstoneMessage(uuid.MustParse(id))); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// По этому списку Archiver вычищает пользователя из уже выгруженных архивов
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_data_requests SET erased_user_ids = $2::uuid[] WHERE id = $1
+	`, req.ID, pq.StringArray(ids)); err != nil {
+		return nil, false, fmt.Errorf("failed to save erased user ids: %w", err)
+	}
+
+	if err := r.completeRequest(ctx, tx, req); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return req, false, nil
+}
+
+// ExportUserData передаёт в write события пользователя и связанных с ним
+// анонимных id в порядке времени. Запрос попадает в журнал до начала выгрузки,
+// completed_at проставляется, только если выгрузка дошла до конца.
+func (r *privacyRepository) ExportUserData(
+	ctx context.Context,
+	req *UserDataRequest,
+	write func(event *Event) error) (*UserDataRequest, error) {
+	if _, _, err := r.claimRequest(ctx, r.db, req); err != nil {
+		return nil, err
+	}
+
+	ids, err := r.linkedUserIDs(ctx, r.db, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryxContext(ctx, `
+		SELECT id, event_type, user_id, anonymous_id, session_id, product_id, data, created_at, processed_at
+		FROM events
+		WHERE user_id = ANY($1::uuid[])
+		ORDER BY created_at, id
+	`, pq.StringArray(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user events: %w", err)
+	}
+	defer rows.Close()
+
+	req.EventsAffected = 0
+	for rows.Next() {
+		var event Event
+		if err := rows.StructScan(&event); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		if err := write(&event); err != nil {
+			return nil, err
+		}
+		req.EventsAffected++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read user events: %w", err)
+	}
+
+	if err := r.completeRequest(ctx, r.db, req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// claimRequest записывает запрос в журнал. Если запрос с таким id уже есть,
+// возвращает его и false, а запрос другого вида или пользователя - ErrRequestConflict.
+func (r *privacyRepository) claimRequest(
+	ctx context.Context,
+	db sqlx.ExtContext,
+	req *UserDataRequest) (*UserDataRequest, bool, error) {
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO user_data_requests (id, kind, user_id, requested_by, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (id) DO NOTHING
+	`, req.ID, req.Kind, req.UserID, req.RequestedBy, req.Reason, req.CreatedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save user data request: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows > 0 {
+		return req, true, nil
+	}
+
+	var stored UserDataRequest
+	err = sqlx.GetContext(ctx, db, &stored, `
+		SELECT id, kind, user_id, requested_by, reason, events_affected, identities_affected, created_at, completed_at
+		FROM user_data_requests
+		WHERE id = $1
+	`, req.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, fmt.Errorf("user data request %s disappeared", req.ID)
+		}
+		return nil, false, fmt.Errorf("failed to get user data request: %w", err)
+	}
+
+	if !stored.sameRequest(req) {
+		return nil, false, ErrRequestConflict
+	}
+
+	return &stored, false, nil
+}
+
+func (r *privacyRepository) completeRequest(ctx context.Context, db sqlx.ExecerContext, req *UserDataRequest) error {
+	now := time.Now().UTC()
+	req.CompletedAt = &now
+
+	_, err := db.ExecContext(ctx, `
+		UPDATE user_data_requests
+		SET events_affected = $2, identities_affected = $3, completed_at = $4
+		WHERE id = $1
+	`, req.ID, req.EventsAffected, req.IdentitiesAffected, req.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete user data request: %w", err)
+	}
+
+	return nil
+}
+
+// deleteEventCopies удаляет события ids из таблиц с копиями events вне
+// партиционированной таблицы: восстановленных архивов restored_* и партиций,
+// отключённых через EVENTS_RETENTION_DETACH
+func (r *privacyRepository) deleteEventCopies(ctx context.Context, tx *sqlx.Tx, ids []string) (int64, error) {
+	var tables []string
+	err := tx.SelectContext(ctx, &tables, `
+		SELECT c.relname
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema()
+		  AND c.relkind = 'r'
+		  AND NOT c.relispartition
+		  AND (starts_with(c.relname, $1) OR starts_with(c.relname, $2))
+		ORDER BY c.relname
+	`, restoreTablePrefix, partitionPrefix)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list event copies: %w", err)
+	}
+
+	var deleted int64
+	for _, table := range tables {
+		// Таблицы events_p* с чужими именами созданы не PartitionManager
+		if _, ok := parsePartition(table); !ok && validateRestoreTable(table) != nil {
+			continue
+		}
+
+		result, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			DELETE FROM %s WHERE user_id = ANY($1::uuid[])
+		`, pq.QuoteIdentifier(table)), pq.StringArray(ids))
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete events from %s: %w", table, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		deleted += affected
+	}
+
+	return deleted, nil
+}
+
+// linkedUserIDs - сам userID и user_id анонимных событий, связанных с ним
+func (r *privacyRepository) linkedUserIDs(ctx context.Context, db sqlx.QueryerContext, userID uuid.UUID) ([]string, error) {
+	var ids []string
+	err := sqlx.SelectContext(ctx, db, &ids, `
+		SELECT $1::uuid::text
+		UNION
+		SELECT anonymous_user_id::text FROM identity_links WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get linked user ids: %w", err)
+	}
+
+	return ids, nil
+}
+
+// Privacy выполняет запросы на удаление и выгрузку данных пользователя (GDPR)
+type Privacy struct {
+	repo   PrivacyRepository
+	logger *zap.Logger
+}
+
+func NewPrivacy(repo PrivacyRepository, logger *zap.Logger) *Privacy {
+	return &Privacy{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// DeleteUserData возвращает true вторым значением, если запрос с этим id уже выполнялся
+func (p *Privacy) DeleteUserData(ctx context.Context, req *UserDataRequest) (*UserDataRequest, bool, error) {
+	stored, alreadyProcessed, err := p.repo.DeleteUserData(ctx, req)
+	if err != nil {
+		if !errors.Is(err, ErrRequestConflict) {
+			p.logger.Error("Failed to delete user data", zap.Error(err),
+				zap.String("request_id", req.ID.String()))
+		}
+		return nil, false, fmt.Errorf("failed to delete user data: %w", err)
+	}
+
+	p.logger.Info("User data deleted",
+		zap.String("request_id", stored.ID.String()),
+		zap.String("user_id", stored.UserID.String()),
+		zap.String("requested_by", stored.RequestedBy),
+		zap.Int64("events", stored.EventsAffected),
+		zap.Int64("identities", stored.IdentitiesAffected),
+		zap.Bool("already_processed", alreadyProcessed),
+	)
+	return stored, alreadyProcessed, nil
+}
+
+// ExportUserData передаёт в write по одной строке JSON lines на событие
+func (p *Privacy) ExportUserData(ctx context.Context, req *UserDataRequest, write func(line []byte) error) (*UserDataRequest, error) {
+	stored, err := p.repo.ExportUserData(ctx, req, func(event *Event) error {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+		return write(append(line, '\n'))
+	})
+	if err != nil {
+		if !errors.Is(err, ErrRequestConflict) {
+			p.logger.Error("Failed to export user data", zap.Error(err),
+				zap.String("request_id", req.ID.String()))
+		}
+		return nil, fmt.Errorf("failed to export user data: %w", err)
+	}
+
+	p.logger.Info("User data exported",
+		zap.String("request_id", stored.ID.String()),
+		zap.String("user_id", stored.UserID.String()),
+		zap.String("requested_by", stored.RequestedBy),
+		zap.Int64("events", stored.EventsAffected),
+	)
+	return stored, nil
+}
@@ -0,0 +1,214 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
+	"go.uber.org/zap"
+)
+
+type SchemaRepository interface {
+	List(ctx context.Context) ([]*EventSchema, error)
+	Get(ctx context.Context, name string) (*EventSchema, error)
+	Upsert(ctx context.Context, schema *EventSchema) (*EventSchema, error)
+	Delete(ctx context.Context, name string) error
+}
+
+type schemaRepository struct {
+	db     *postgres.DB
+	logger *zap.Logger
+}
+
+func NewSchemaRepository(db *postgres.DB, logger *zap.Logger) SchemaRepository {
+	return &schemaRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *schemaRepository) List(ctx context.Context) ([]*EventSchema, error) {
+	var schemas []*EventSchema
+	err := r.db.SelectContext(ctx, &schemas, `
+		SELECT name, description, fields, version, built_in, created_at, updated_at
+		FROM event_schemas
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event schemas: %w", err)
+	}
+	return schemas, nil
+}
+
+func (r *schemaRepository) Get(ctx context.Context, name string) (*EventSchema, error) {
+	var schema EventSchema
+	err := r.db.GetContext(ctx, &schema, `
+		SELECT name, description, fields, version, built_in, created_at, updated_at
+		FROM event_schemas
+		WHERE name = $1
+	`, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSchemaNotFound
+		}
+		return nil, fmt.Errorf("failed to get event schema: %w", err)
+	}
+	return &schema, nil
+}
+
+// Upsert создаёт схему или заменяет поля существующей, увеличивая версию.
+// Признак built_in при обновлении не меняется.
+func (r *schemaRepository) Upsert(ctx context.Context, schema *EventSchema) (*EventSchema, error) {
+	var stored EventSchema
+	err := r.db.GetContext(ctx, &stored, `
+		INSERT INTO event_schemas (name, description, fields, version, built_in, created_at, updated_at)
+		VALUES ($1, $2, $3, 1, FALSE, NOW(), NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			description = EXCLUDED.description,
+			fields = EXCLUDED.fields,
+			version = event_schemas.version + 1,
+			updated_at = NOW()
+		RETURNING name, description, fields, version, built_in, created_at, updated_at
+	`, schema.Name, schema.Description, schema.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save event schema: %w", err)
+	}
+
+	r.logger.Info("Event schema saved",
+		zap.String("name", stored.Name),
+		zap.Int("version", stored.Version),
+		zap.Int("fields", len(stored.Fields)),
+	)
+
+	return &stored, nil
+}
+
+func (r *schemaRepository) Delete(ctx context.Context, name string) error {
+	schema, err := r.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	if schema.BuiltIn {
+		return ErrBuiltInSchema
+	}
+
+	res, err := r.db.ExecContext(ctx, `DELETE FROM event_schemas WHERE name = $1 AND NOT built_in`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete event schema: %w", err)
+	}
+	if rows, err := res.RowsAffected(); err == nil && rows == 0 {
+		return ErrSchemaNotFound
+	}
+
+	r.logger.Info("Event schema deleted", zap.String("name", name))
+	return nil
+}
+
+// Registry держит схемы в памяти, чтобы не ходить в Postgres на каждое событие.
+// Изменения, сделанные через другие реплики, подтягиваются в Run.
+type Registry struct {
+	repo   SchemaRepository
+	logger *zap.Logger
+
+	mu      sync.RWMutex
+	schemas map[string]*EventSchema
+}
+
+func NewRegistry(repo SchemaRepository, logger *zap.Logger) *Registry {
+	return &Registry{
+		repo:    repo,
+		logger:  logger,
+		schemas: make(map[string]*EventSchema),
+	}
+}
+
+// Refresh заново загружает все схемы из Postgres
+func (r *Registry) Refresh(ctx context.Context) error {
+	schemas, err := r.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	byName := make(map[string]*EventSchema, len(schemas))
+	for _, schema := range schemas {
+		byName[schema.Name] = schema
+	}
+
+	r.mu.Lock()
+	r.schemas = byName
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Run обновляет кэш схем раз в interval до отмены ctx
+func (r *Registry) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Error("Failed to refresh event schemas", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Get возвращает nil, если тип не зарегистрирован
+func (r *Registry) Get(name string) *EventSchema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.schemas[name]
+}
+
+func (r *Registry) List() []*EventSchema {
+	r.mu.RLock()
+	schemas := make([]*EventSchema, 0, len(r.schemas))
+	for _, schema := range r.schemas {
+		schemas = append(schemas, schema)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Name < schemas[j].Name
+	})
+	return schemas
+}
+
+func (r *Registry) Register(ctx context.Context, schema *EventSchema) (*EventSchema, error) {
+	if err := schema.Check(); err != nil {
+		return nil, err
+	}
+
+	stored, err := r.repo.Upsert(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.schemas[stored.Name] = stored
+	r.mu.Unlock()
+
+	return stored, nil
+}
+
+func (r *Registry) Delete(ctx context.Context, name string) error {
+	if err := r.repo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	delete(r.schemas, name)
+	r.mu.Unlock()
+
+	return nil
+}
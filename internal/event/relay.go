@@ -0,0 +1,155 @@
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type KafkaProducer interface {
+	SendMessage(ctx context.Context, key string, value any) error
+	SendMessageBatch(ctx context.Context, messages map[string]any) error
+	SendTombstone(ctx context.Context, key string) error
+}
+
+type RelayConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	Retention    time.Duration
+	// На сколько relay забирает пачку. Что не успело уйти в Kafka за это
+	// время, возвращается в outbox и может достаться другому инстансу.
+	Lease time.Duration
+}
+
+// Relay переносит сообщения из outbox в Kafka (at-least-once)
+type Relay struct {
+	repo     Repository
+	producer KafkaProducer
+	cfg      RelayConfig
+	logger   *zap.Logger
+}
+
+func NewRelay(repo Repository, producer KafkaProducer, cfg RelayConfig, logger *zap.Logger) *Relay {
+	return &Relay{
+		repo:     repo,
+		producer: producer,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+// Run блокируется до отмены ctx
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	r.logger.Info("Outbox relay started",
+		zap.Duration("poll_interval", r.cfg.PollInterval),
+		zap.Int("batch_size", r.cfg.BatchSize),
+	)
+
+	for {
+		select {
+		case <-ticker.C:
+			r.drain(ctx)
+		case <-cleanup.C:
+			r.cleanup(ctx)
+		case <-ctx.Done():
+			r.logger.Info("Outbox relay stopped")
+			return
+		}
+	}
+}
+
+// drain отправляет пачки, пока outbox не опустеет
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		fetched := 0
+		sent, err := r.repo.ProcessOutbox(ctx, r.cfg.BatchSize, r.cfg.Lease, func(msg *OutboxMessage) error {
+			fetched++
+			return r.publish(ctx, msg)
+		})
+		if err != nil {
+			r.logger.Error("Failed to process outbox", zap.Error(err))
+			return
+		}
+
+		if sent > 0 {
+			r.logger.Debug("Outbox messages published", zap.Int("sent", sent))
+		}
+
+		if fetched < r.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, msg *OutboxMessage) error {
+	// Продолжаем trace запроса, в котором событие было принято
+	ctx, span := tracer.Start(tracing.Extract(ctx, msg.TraceCarrier()), "outbox publish",
+		trace.WithAttributes(
+			attribute.Int64("outbox.id", msg.ID),
+			attribute.Int("outbox.attempts", msg.Attempts),
+			attribute.Bool("outbox.tombstone", msg.Tombstone),
+			attribute.Float64("outbox.delay_seconds", time.Since(msg.CreatedAt).Seconds()),
+		),
+	)
+	defer span.End()
+
+	send := func() error {
+		if msg.Tombstone {
+			return r.producer.SendTombstone(ctx, msg.MessageKey)
+		}
+		return r.producer.SendMessage(ctx, msg.MessageKey, msg.Payload)
+	}
+
+	if err := send(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "publish failed")
+		backoff := r.backoff(msg.Attempts)
+		msg.MarkAsFailed(err, backoff)
+
+		r.logger.Warn("Failed to publish outbox message",
+			zap.Int64("outbox_id", msg.ID),
+			zap.Int("attempts", msg.Attempts),
+			zap.Duration("retry_in", backoff),
+			zap.Error(err),
+		)
+		return err
+	}
+
+	msg.MarkAsSent()
+	return nil
+}
+
+// backoff экспоненциальный: MinBackoff * 2^attempts, но не больше MaxBackoff
+func (r *Relay) backoff(attempts int) time.Duration {
+	backoff := r.cfg.MinBackoff
+	for i := 0; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= r.cfg.MaxBackoff {
+			return r.cfg.MaxBackoff
+		}
+	}
+	return backoff
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	deleted, err := r.repo.DeleteSentOutbox(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		r.logger.Error("Failed to cleanup outbox", zap.Error(err))
+		return
+	}
+
+	r.logger.Debug("Outbox cleanup completed", zap.Int64("deleted", deleted))
+}
@@ -3,23 +3,53 @@ package event
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
+	"github.com/Wuchinator/realtime-analytics/pkg/tracing"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type Repository interface {
 	Create(ctx context.Context, event *Event) error
-	CreateBatch(ctx context.Context, events []*Event) error
+	CreateBatch(ctx context.Context, events []*Event) ([]*EventResult, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*Event, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]*Event, error)
 	MarkAsProcessed(ctx context.Context, id uuid.UUID) error
 	GetUnprocessed(ctx context.Context, limit int) ([]*Event, error)
+	ProcessOutbox(ctx context.Context, limit int, lease time.Duration, handle func(msg *OutboxMessage) error) (int, error)
+	DeleteSentOutbox(ctx context.Context, before time.Time) (int64, error)
+	Identify(ctx context.Context, link *IdentityLink) (bool, error)
 }
 
+var tracer = otel.Tracer("github.com/Wuchinator/realtime-analytics/internal/event")
+
+// insertEventQuery пишет событие, только если его id ещё не занят в event_ids.
+// Primary key партиционированной events включает created_at и повтор с другим
+// временем не ловит. Для дубликата запрос ничего не вставляет.
+const insertEventQuery = `
+	WITH claimed AS (
+		INSERT INTO event_ids (id, created_at)
+		VALUES ($1, $8)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING id
+	)
+	INSERT INTO events (id, event_type, user_id, anonymous_id, session_id, product_id, data, created_at)
+	SELECT claimed.id, $2, $3::uuid, $4, $5::uuid, $6::uuid, $7::jsonb, $8
+	FROM claimed
+	ON CONFLICT (id, created_at) DO NOTHING
+`
+
 type repository struct {
 	db     *postgres.DB
 	logger *zap.Logger
@@ -32,22 +62,38 @@ func NewRepository(db *postgres.DB, logger *zap.Logger) Repository {
 	}
 }
 
-func (r *repository) Create(ctx context.Context, event *Event) error {
-	if err := event.Validate(); err != nil {
-		return err
+func (r *repository) Create(ctx context.Context, event *Event) (err error) {
+	ctx, span := tracer.Start(ctx, "events insert", trace.WithAttributes(
+		attribute.String("event.id", event.ID.String()),
+		attribute.String("event.type", event.EventType),
+	))
+	defer func() {
+		if err != nil && !errors.Is(err, ErrDuplicateEvent) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "insert failed")
+		}
+		span.End()
+	}()
+
+	msg, err := NewOutboxMessage(event)
+	if err != nil {
+		return fmt.Errorf("failed to build outbox message: %w", err)
 	}
 
-	query := `
-		INSERT INTO events (id, event_type, user_id, session_id, product_id, data, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-	`
+	// Событие и сообщение для Kafka коммитятся вместе, отправкой занимается relay
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
 
-	_, err := r.db.ExecContext(
+	result, err := tx.ExecContext(
 		ctx,
-		query,
+		insertEventQuery,
 		event.ID,
 		event.EventType,
 		event.UserID,
+		event.AnonymousID,
 		event.SessionID,
 		event.ProductID,
 		event.Data,
@@ -67,6 +113,27 @@ func (r *repository) Create(ctx context.Context, event *Event) error {
 		return fmt.Errorf("failed to create event: %w", err)
 	}
 
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		r.logger.Warn("Duplicate event ignored",
+			zap.String("event_id", event.ID.String()),
+		)
+		return ErrDuplicateEvent
+	}
+
+	if err := r.insertOutbox(ctx, tx, msg); err != nil {
+		return err
+	}
+
+	if link := event.IdentityLink(); link != nil {
+		if _, err := r.insertIdentityLink(ctx, tx, link); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	r.logger.Debug("Event created",
 		zap.String("event_id", event.ID.String()),
 		zap.String("event_type", event.EventType),
@@ -76,72 +143,123 @@ func (r *repository) Create(ctx context.Context, event *Event) error {
 	return nil
 }
 
-func (r *repository) CreateBatch(ctx context.Context, events []*Event) error {
+// CreateBatch сохраняет события и возвращает статус каждого в том же порядке.
+// Каждое событие пишется под своим savepoint, поэтому ошибка одного не
+// откатывает остальные. Ошибка возвращается, только если не удалось
+// выполнить саму транзакцию - тогда ни одно событие не сохранено.
+func (r *repository) CreateBatch(ctx context.Context, events []*Event) (results []*EventResult, err error) {
 	if len(events) == 0 {
-		return nil
+		return nil, nil
 	}
 
+	ctx, span := tracer.Start(ctx, "events insert batch", trace.WithAttributes(
+		attribute.Int("events.count", len(events)),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "batch insert failed")
+		}
+		span.End()
+	}()
+
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback() // Намеренно игнорирую ошибку
 
-	stmt, err := tx.PreparexContext(ctx, `
-		INSERT INTO events (id, event_type, user_id, session_id, product_id, data, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		ON CONFLICT (id) DO NOTHING
-	`)
+	stmt, err := tx.PreparexContext(ctx, insertEventQuery)
 	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	successCount := 0
-	for _, event := range events {
-		if err := event.Validate(); err != nil {
-			r.logger.Warn("Invalid event in batch",
-				zap.String("event_id", event.ID.String()),
-				zap.Error(err),
-			)
-			continue
-		}
-
-		_, err := stmt.ExecContext(
-			ctx,
-			event.ID,
-			event.EventType,
-			event.UserID,
-			event.SessionID,
-			event.ProductID,
-			event.Data,
-			event.CreatedAt,
-		)
+	results = make([]*EventResult, len(events))
+	for i, event := range events {
+		results[i], err = r.insertBatchEvent(ctx, tx, stmt, event)
 		if err != nil {
-			r.logger.Error("Failed to insert event in batch",
-				zap.String("event_id", event.ID.String()),
-				zap.Error(err),
-			)
-			continue
+			return nil, err
 		}
-		successCount++
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	r.logger.Info("Batch insert completed",
 		zap.Int("total", len(events)),
-		zap.Int("success", successCount),
+		zap.Int("accepted", CountResults(results, EventStatusAccepted)),
+		zap.Int("duplicates", CountResults(results, EventStatusDuplicate)),
 	)
 
-	return nil
+	return results, nil
+}
+
+// insertBatchEvent пишет событие и его outbox сообщение под savepoint.
+// Ошибка события превращается в EventStatusFailed, error возвращается,
+// только если сломалась сама транзакция.
+func (r *repository) insertBatchEvent(ctx context.Context, tx *sqlx.Tx, stmt *sqlx.Stmt, event *Event) (*EventResult, error) {
+	id := event.ID.String()
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_event"); err != nil {
+		return nil, fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	fail := func(err error) (*EventResult, error) {
+		r.logger.Error("Failed to insert event in batch",
+			zap.String("event_id", id),
+			zap.Error(err),
+		)
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_event"); rbErr != nil {
+			return nil, fmt.Errorf("failed to rollback to savepoint: %w", rbErr)
+		}
+		return NewEventResult(id, EventStatusFailed, err), nil
+	}
+
+	result, err := stmt.ExecContext(
+		ctx,
+		event.ID,
+		event.EventType,
+		event.UserID,
+		event.AnonymousID,
+		event.SessionID,
+		event.ProductID,
+		event.Data,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Дубликат уже лежит в outbox, второй раз не публикуем
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return NewEventResult(id, EventStatusDuplicate, nil), nil
+	}
+
+	msg, err := NewOutboxMessage(event)
+	if err != nil {
+		return fail(fmt.Errorf("failed to build outbox message: %w", err))
+	}
+	if err := r.insertOutbox(ctx, tx, msg); err != nil {
+		return fail(err)
+	}
+	if link := event.IdentityLink(); link != nil {
+		if _, err := r.insertIdentityLink(ctx, tx, link); err != nil {
+			return fail(err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_event"); err != nil {
+		return nil, fmt.Errorf("failed to release savepoint: %w", err)
+	}
+
+	return NewEventResult(id, EventStatusAccepted, nil), nil
 }
 
 func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Event, error) {
 	query := `
-		SELECT id, event_type, user_id, session_id, product_id, data, created_at, processed_at
+		SELECT id, event_type, user_id, anonymous_id, session_id, product_id, data, created_at, processed_at
 		FROM events
 		WHERE id = $1
 	`
@@ -160,7 +278,7 @@ func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Event, error)
 
 func (r *repository) GetByUserID(ctx context.Context, userID uuid.UUID, limit int) ([]*Event, error) {
 	query := `
-		SELECT id, event_type, user_id, session_id, product_id, data, created_at, processed_at
+		SELECT id, event_type, user_id, anonymous_id, session_id, product_id, data, created_at, processed_at
 		FROM events
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -202,7 +320,7 @@ func (r *repository) MarkAsProcessed(ctx context.Context, id uuid.UUID) error {
 
 func (r *repository) GetUnprocessed(ctx context.Context, limit int) ([]*Event, error) {
 	query := `
-		SELECT id, event_type, user_id, session_id, product_id, data, created_at, processed_at
+		SELECT id, event_type, user_id, anonymous_id, session_id, product_id, data, created_at, processed_at
 		FROM events
 		WHERE processed_at IS NULL
 		ORDER BY created_at ASC
@@ -217,3 +335,291 @@ func (r *repository) GetUnprocessed(ctx context.Context, limit int) ([]*Event, e
 
 	return events, nil
 }
+
+// Identify сохраняет связь anonymous_id с user_id. Первая связь побеждает:
+// повтор той же связи возвращает false, связь с другим user_id - ErrIdentityConflict.
+func (r *repository) Identify(ctx context.Context, link *IdentityLink) (bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	created, err := r.insertIdentityLink(ctx, tx, link)
+	if err != nil {
+		return false, err
+	}
+
+	if !created {
+		var userID uuid.UUID
+		err := tx.GetContext(ctx, &userID, `
+			SELECT user_id FROM identity_links WHERE anonymous_user_id = $1
+		`, link.AnonymousUserID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get identity link: %w", err)
+		}
+		if userID != link.UserID {
+			return false, ErrIdentityConflict
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// insertIdentityLink не перезаписывает существующую связь anonymous_id
+func (r *repository) insertIdentityLink(ctx context.Context, tx *sqlx.Tx, link *IdentityLink) (bool, error) {
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO identity_links (anonymous_id, anonymous_user_id, user_id, linked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`, link.AnonymousID, link.AnonymousUserID, link.UserID, link.LinkedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create identity link: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+func (r *repository) insertOutbox(ctx context.Context, tx *sqlx.Tx, msg *OutboxMessage) error {
+	// Relay публикует позже и в другой goroutine, поэтому trace context сохраняется вместе с сообщением
+	if err := msg.SetTraceContext(tracing.Inject(ctx)); err != nil {
+		return fmt.Errorf("failed to encode trace context: %w", err)
+	}
+
+	query := `
+		INSERT INTO outbox (event_id, message_key, payload, created_at, next_attempt_at, trace_context, tombstone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`
+
+	err := tx.QueryRowContext(
+		ctx,
+		query,
+		msg.EventID,
+		msg.MessageKey,
+		msg.Payload,
+		msg.CreatedAt,
+		msg.NextAttemptAt,
+		msg.TraceContext,
+		msg.Tombstone,
+	).Scan(&msg.ID)
+	if err != nil {
+		r.logger.Error("Failed to create outbox message", zap.Error(err))
+		return fmt.Errorf("failed to create outbox message: %w", err)
+	}
+
+	return nil
+}
+
+// ProcessOutbox забирает пачку неотправленных сообщений на lease и передаёт их
+// в handle по порядку id. Claim - короткая транзакция, сама отправка идёт без
+// открытой транзакции и блокировок. Claim ключа сериализуется advisory lock,
+// и сообщение не забирается, пока у его ключа есть более раннее неотправленное
+// сообщение на backoff или на чужом lease. После первой ошибки остальные
+// сообщения ключа из пачки возвращаются в outbox: так сохраняется порядок
+// событий пользователя. Сообщения, до которых relay не дошёл до конца lease,
+// пропускаются и уходят со следующей пачкой.
+func (r *repository) ProcessOutbox(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+	handle func(msg *OutboxMessage) error) (int, error) {
+	// Засекаем до claim: claimed_until в базе наступит не раньше deadline,
+	// и расхождение часов relay и Postgres на это не влияет
+	deadline := time.Now().Add(lease)
+
+	messages, err := r.claimOutbox(ctx, limit, lease)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		sent     int
+		released []*OutboxMessage
+		failed   = make(map[string]bool)
+	)
+	for _, msg := range messages {
+		// Lease истёк: сообщение уже свободно и могло достаться другому инстансу
+		if !time.Now().Before(deadline) {
+			continue
+		}
+		if failed[msg.MessageKey] {
+			released = append(released, msg)
+			continue
+		}
+
+		if err := handle(msg); err != nil {
+			failed[msg.MessageKey] = true
+
+			// Только пока lease наш: иначе сообщение уже забрал другой relay
+			_, err := r.db.ExecContext(ctx, `
+				UPDATE outbox
+				SET attempts = $2, last_error = $3, next_attempt_at = $4, claimed_until = NULL
+				WHERE id = $1 AND claimed_until = $5 AND sent_at IS NULL
+			`, msg.ID, msg.Attempts, msg.LastError, msg.NextAttemptAt, msg.ClaimedUntil)
+			if err != nil {
+				return sent, fmt.Errorf("failed to update outbox message: %w", err)
+			}
+			continue
+		}
+
+		if err := r.markOutboxSent(ctx, msg); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	for _, msg := range released {
+		_, err := r.db.ExecContext(ctx, `
+			UPDATE outbox
+			SET claimed_until = NULL
+			WHERE id = $1 AND claimed_until = $2 AND sent_at IS NULL
+		`, msg.ID, msg.ClaimedUntil)
+		if err != nil {
+			return sent, fmt.Errorf("failed to release outbox message: %w", err)
+		}
+	}
+
+	return sent, nil
+}
+
+// claimOutbox забирает до limit сообщений на lease. Сначала берётся
+// транзакционный advisory lock на каждый ключ, и только потом, отдельным
+// запросом с новым snapshot, сообщения этих ключей: пока claim одного relay
+// не закоммичен, другой relay ключ пропускает, а после commit уже видит
+// claimed_until ранних сообщений ключа.
+func (r *repository) claimOutbox(ctx context.Context, limit int, lease time.Duration) ([]*OutboxMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	// LIMIT во вложенном запросе не даёт Postgres вызвать lock для лишних ключей
+	var keys []string
+	err = tx.SelectContext(ctx, &keys, `
+		SELECT message_key
+		FROM (
+			SELECT message_key, MIN(id) AS first_id
+			FROM outbox
+			WHERE sent_at IS NULL
+			  AND next_attempt_at <= NOW()
+			  AND (claimed_until IS NULL OR claimed_until < NOW())
+			GROUP BY message_key
+			ORDER BY first_id
+			LIMIT $1
+		) candidates
+		WHERE pg_try_advisory_xact_lock(hashtext(message_key))
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock outbox keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var messages []*OutboxMessage
+	err = tx.SelectContext(ctx, &messages, `
+		WITH claimable AS (
+			SELECT id
+			FROM outbox o
+			WHERE o.message_key = ANY($3)
+			  AND o.sent_at IS NULL
+			  AND o.next_attempt_at <= NOW()
+			  AND (o.claimed_until IS NULL OR o.claimed_until < NOW())
+			  AND NOT EXISTS (
+				SELECT 1
+				FROM outbox p
+				WHERE p.message_key = o.message_key
+				  AND p.sent_at IS NULL
+				  AND p.id < o.id
+				  AND (p.next_attempt_at > NOW() OR p.claimed_until >= NOW())
+			  )
+			ORDER BY o.id
+			LIMIT $1
+			FOR UPDATE
+		)
+		UPDATE outbox
+		SET claimed_until = NOW() + make_interval(secs => $2)
+		FROM claimable
+		WHERE outbox.id = claimable.id
+		RETURNING outbox.id, outbox.event_id, outbox.message_key, outbox.payload, outbox.attempts,
+			outbox.last_error, outbox.created_at, outbox.next_attempt_at, outbox.sent_at,
+			outbox.trace_context, outbox.tombstone, outbox.claimed_until
+	`, limit, lease.Seconds(), pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+	return messages, nil
+}
+
+// markOutboxSent отмечает сообщение отправленным, а его событие - обработанным
+func (r *repository) markOutboxSent(ctx context.Context, msg *OutboxMessage) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE outbox
+		SET sent_at = $2, claimed_until = NULL
+		WHERE id = $1
+	`, msg.ID, msg.SentAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message as sent: %w", err)
+	}
+
+	if msg.EventID != nil {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE events
+			SET processed_at = $2
+			WHERE id = $1 AND processed_at IS NULL
+		`, *msg.EventID, msg.SentAt)
+		if err != nil {
+			return fmt.Errorf("failed to mark event as processed: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteSentOutbox(ctx context.Context, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM outbox
+		WHERE sent_at IS NOT NULL AND sent_at < $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete sent outbox messages: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,234 @@
+package event
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FieldType string
+
+const (
+	FieldTypeString    FieldType = "string"
+	FieldTypeNumber    FieldType = "number"
+	FieldTypeBoolean   FieldType = "boolean"
+	FieldTypeUUID      FieldType = "uuid"
+	FieldTypeTimestamp FieldType = "timestamp"
+)
+
+var fieldTypes = []FieldType{
+	FieldTypeString,
+	FieldTypeNumber,
+	FieldTypeBoolean,
+	FieldTypeUUID,
+	FieldTypeTimestamp,
+}
+
+// Имя события попадает в events.event_type VARCHAR(50)
+var eventNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// SchemaField описывает одно поле metadata события
+type SchemaField struct {
+	Name     string    `json:"name"`
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required"`
+	// Если задано, значение должно совпадать с одним из списка
+	AllowedValues []string `json:"allowed_values,omitempty"`
+}
+
+// SchemaFields хранится в JSONB
+type SchemaFields []SchemaField
+
+func (f SchemaFields) Value() (driver.Value, error) {
+	if f == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *SchemaFields) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return fmt.Errorf("unsupported type for schema fields: %T", src)
+	}
+}
+
+// EventSchema - зарегистрированный тип события. Поля, которых нет в схеме,
+// допускаются без проверки.
+type EventSchema struct {
+	Name        string       `db:"name" json:"name"`
+	Description string       `db:"description" json:"description"`
+	Fields      SchemaFields `db:"fields" json:"fields"`
+	Version     int          `db:"version" json:"version"`
+	// Встроенные e-commerce типы нельзя удалить, но можно описать их поля
+	BuiltIn   bool      `db:"built_in" json:"built_in"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Check проверяет саму схему перед регистрацией
+func (s *EventSchema) Check() error {
+	if !eventNamePattern.MatchString(s.Name) {
+		return fmt.Errorf("%w: name must match %s", ErrInvalidSchema, eventNamePattern)
+	}
+
+	seen := make(map[string]bool, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("%w: field name is required", ErrInvalidSchema)
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("%w: duplicate field %q", ErrInvalidSchema, field.Name)
+		}
+		seen[field.Name] = true
+
+		if !slices.Contains(fieldTypes, field.Type) {
+			return fmt.Errorf("%w: field %q has unknown type %q", ErrInvalidSchema, field.Name, field.Type)
+		}
+		for _, allowed := range field.AllowedValues {
+			if err := checkFieldValue(field.Type, allowed); err != nil {
+				return fmt.Errorf("%w: allowed value %q of field %q: %v", ErrInvalidSchema, allowed, field.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateData проверяет metadata события по схеме
+func (s *EventSchema) ValidateData(data json.RawMessage) error {
+	if len(s.Fields) == 0 {
+		return nil
+	}
+
+	values := map[string]any{}
+	if len(data) > 0 && string(data) != "null" {
+		if err := json.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("%w: metadata must be an object", ErrInvalidMetadata)
+		}
+	}
+
+	for _, field := range s.Fields {
+		value, ok := values[field.Name]
+		if !ok || value == nil {
+			if field.Required {
+				return fmt.Errorf("%w: %s requires field %q", ErrInvalidMetadata, s.Name, field.Name)
+			}
+			continue
+		}
+
+		str, err := fieldValueString(field.Type, value)
+		if err != nil {
+			return fmt.Errorf("%w: field %q: %v", ErrInvalidMetadata, field.Name, err)
+		}
+
+		if len(field.AllowedValues) > 0 && !slices.Contains(field.AllowedValues, str) {
+			return fmt.Errorf("%w: field %q must be one of %v, got %q", ErrInvalidMetadata, field.Name, field.AllowedValues, str)
+		}
+	}
+
+	return nil
+}
+
+// NormalizeData приводит строковые значения number и boolean полей к JSON
+// типам, чтобы в JSONB они хранились как числа и bool. Вызывается после
+// ValidateData, поэтому значения уже проверены.
+func (s *EventSchema) NormalizeData(data json.RawMessage) json.RawMessage {
+	if len(s.Fields) == 0 || len(data) == 0 {
+		return data
+	}
+
+	values := map[string]any{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return data
+	}
+
+	changed := false
+	for _, field := range s.Fields {
+		str, ok := values[field.Name].(string)
+		if !ok {
+			continue
+		}
+
+		switch field.Type {
+		case FieldTypeNumber:
+			if v, err := strconv.ParseFloat(str, 64); err == nil {
+				values[field.Name] = v
+				changed = true
+			}
+		case FieldTypeBoolean:
+			if v, err := strconv.ParseBool(str); err == nil {
+				values[field.Name] = v
+				changed = true
+			}
+		}
+	}
+
+	if !changed {
+		return data
+	}
+
+	normalized, err := json.Marshal(values)
+	if err != nil {
+		return data
+	}
+	return normalized
+}
+
+// fieldValueString проверяет тип значения и приводит его к строке для
+// сравнения с allowed_values. Строки тоже принимаются: metadata в gRPC API
+// приходит как map<string, string>.
+func fieldValueString(fieldType FieldType, value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, checkFieldValue(fieldType, v)
+	case float64:
+		if fieldType != FieldTypeNumber {
+			return "", fmt.Errorf("expected %s, got number", fieldType)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if fieldType != FieldTypeBoolean {
+			return "", fmt.Errorf("expected %s, got boolean", fieldType)
+		}
+		return strconv.FormatBool(v), nil
+	default:
+		return "", fmt.Errorf("expected %s, got %T", fieldType, value)
+	}
+}
+
+func checkFieldValue(fieldType FieldType, value string) error {
+	var err error
+	switch fieldType {
+	case FieldTypeString:
+	case FieldTypeNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	case FieldTypeBoolean:
+		_, err = strconv.ParseBool(value)
+	case FieldTypeUUID:
+		_, err = uuid.Parse(value)
+	case FieldTypeTimestamp:
+		_, err = time.Parse(time.RFC3339, value)
+	default:
+		err = errors.New("unknown field type")
+	}
+
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %s", value, fieldType)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/identity"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Типы сообщений Segment
+const (
+	segmentTrack    = "track"
+	segmentIdentify = "identify"
+	segmentPage     = "page"
+)
+
+// segmentNamespace - namespace для UUIDv5 из строковых id Segment. Один и тот же
+// userId/anonymousId/messageId всегда даёт один и тот же UUID.
+var segmentNamespace = identity.Namespace
+
+// Названия track событий из Segment e-commerce spec и наши собственные имена
+var segmentEventTypes = map[string]string{
+	"product viewed":    EventTypeProductView,
+	"product added":     EventTypeAddToCart,
+	"product removed":   EventTypeRemoveFromCart,
+	"order completed":   EventTypePurchase,
+	"products searched": EventTypeSearch,
+	"page viewed":       EventTypePageView,
+
+	EventTypePageView:       EventTypePageView,
+	EventTypeProductView:    EventTypeProductView,
+	EventTypeAddToCart:      EventTypeAddToCart,
+	EventTypeRemoveFromCart: EventTypeRemoveFromCart,
+	EventTypePurchase:       EventTypePurchase,
+	EventTypeSearch:         EventTypeSearch,
+}
+
+// Всё, кроме букв и цифр, в имени track события заменяется на "_"
+var segmentEventName = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SegmentMessage - общий формат track/identify/page сообщений Segment
+type SegmentMessage struct {
+	Type        string         `json:"type"`
+	MessageID   string         `json:"messageId"`
+	UserID      string         `json:"userId"`
+	AnonymousID string         `json:"anonymousId"`
+	Event       string         `json:"event"`
+	Name        string         `json:"name"`
+	Properties  map[string]any `json:"properties"`
+	Traits      map[string]any `json:"traits"`
+	Context     map[string]any `json:"context"`
+	Timestamp   *time.Time     `json:"timestamp"`
+	SentAt      *time.Time     `json:"sentAt"`
+}
+
+type SegmentBatch struct {
+	Batch   []*SegmentMessage `json:"batch"`
+	Context map[string]any    `json:"context"`
+	SentAt  *time.Time        `json:"sentAt"`
+}
+
+// toEvent переводит сообщение Segment в Event. receivedAt нужен для поправки
+// часов клиента: timestamp + (receivedAt - sentAt), как это делает Segment.
+func (m *SegmentMessage) toEvent(receivedAt time.Time) (*Event, error) {
+	eventType, err := m.eventType()
+	if err != nil {
+		return nil, err
+	}
+
+	// userId важнее anonymousId, анонимный id сохраняется отдельно для склейки
+	var userID uuid.UUID
+	switch {
+	case m.UserID != "":
+		userID = segmentUUID(m.UserID)
+	case m.AnonymousID != "":
+		userID = identity.AnonymousUserID(m.AnonymousID)
+	default:
+		return nil, fmt.Errorf("%w: userId or anonymousId is required", ErrInvalidUserID)
+	}
+
+	data := make(map[string]any, len(m.Properties)+4)
+	for k, v := range m.Properties {
+		data[k] = v
+	}
+	if m.Type == segmentIdentify {
+		for k, v := range m.Traits {
+			data[k] = v
+		}
+	}
+	if m.UserID != "" {
+		data["segment_user_id"] = m.UserID
+	}
+	if m.Type == segmentPage {
+		// Sessionization берёт страницу из data["page"]
+		if page := m.pageName(); page != "" {
+			data["page"] = page
+		}
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal properties: %w", err)
+	}
+
+	event := &Event{
+		ID:        segmentUUID(m.MessageID),
+		EventType: eventType,
+		UserID:    userID,
+		SessionID: m.sessionID(),
+		ProductID: m.productID(),
+		Data:      payload,
+		CreatedAt: m.eventTime(receivedAt),
+	}
+	if m.AnonymousID != "" {
+		event.AnonymousID = &m.AnonymousID
+	}
+	return event, nil
+}
+
+func (m *SegmentMessage) eventType() (string, error) {
+	switch m.Type {
+	case segmentPage:
+		return EventTypePageView, nil
+	case segmentIdentify:
+		return EventTypeIdentify, nil
+	case segmentTrack:
+		name := strings.ToLower(strings.TrimSpace(m.Event))
+		if eventType, ok := segmentEventTypes[name]; ok {
+			return eventType, nil
+		}
+		// Остальные имена проверяются по реестру схем: "Video Played" -> video_played
+		return strings.Trim(segmentEventName.ReplaceAllString(name, "_"), "_"), nil
+	default:
+		return "", fmt.Errorf("%w: unsupported message type %q", ErrInvalidEventType, m.Type)
+	}
+}
+
+func (m *SegmentMessage) pageName() string {
+	if path, ok := m.Properties["path"].(string); ok && path != "" {
+		return path
+	}
+	return m.Name
+}
+
+// sessionID берётся из context.sessionId или properties.session_id, иначе
+// сессией считается анонимный посетитель
+func (m *SegmentMessage) sessionID() uuid.UUID {
+	for _, v := range []any{m.Context["sessionId"], m.Properties["session_id"]} {
+		switch id := v.(type) {
+		case string:
+			if id != "" {
+				return segmentUUID(id)
+			}
+		case float64:
+			return segmentUUID(fmt.Sprintf("%.0f", id))
+		}
+	}
+
+	if m.AnonymousID != "" {
+		return segmentUUID(m.AnonymousID)
+	}
+	return segmentUUID(m.UserID)
+}
+
+func (m *SegmentMessage) productID() *uuid.UUID {
+	id, ok := m.Properties["product_id"].(string)
+	if !ok || id == "" {
+		return nil
+	}
+	productID := segmentUUID(id)
+	return &productID
+}
+
+func (m *SegmentMessage) eventTime(receivedAt time.Time) time.Time {
+	if m.Timestamp == nil {
+		return receivedAt
+	}
+	if m.SentAt == nil {
+		return m.Timestamp.UTC()
+	}
+	return m.Timestamp.Add(receivedAt.Sub(*m.SentAt)).UTC()
+}
+
+// segmentUUID оставляет UUID как есть, остальные строки переводит в UUIDv5.
+// Пустой id (нет messageId) получает случайный UUID.
+func segmentUUID(id string) uuid.UUID {
+	if id == "" {
+		return uuid.New()
+	}
+	if parsed, err := uuid.Parse(id); err == nil {
+		return parsed
+	}
+	return uuid.NewSHA1(segmentNamespace, []byte(id))
+}
+
+// segmentResponse - Segment SDK проверяет только код ответа и success
+type segmentResponse struct {
+	Success bool `json:"success"`
+}
+
+// POST /v1/track, /v1/identify, /v1/page
+func (h *HTTPHandler) segmentSingle(messageType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, ok := h.readPost(w, r)
+		if !ok {
+			return
+		}
+
+		receivedAt := time.Now().UTC()
+
+		var msg SegmentMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			eventsRejected.WithLabelValues(rejectInvalidPayload).Inc()
+			h.writeError(w, status.Errorf(codes.InvalidArgument, "invalid JSON: %v", err))
+			return
+		}
+		msg.Type = messageType
+
+		event, err := msg.toEvent(receivedAt)
+		if err != nil {
+			recordRejected(err)
+			h.writeError(w, segmentStatus(err))
+			return
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		if err := h.handler.service.TrackEvent(ctx, event); err != nil {
+			h.writeError(w, segmentStatus(err))
+			return
+		}
+
+		writeSegmentResponse(w)
+	}
+}
+
+// POST /v1/batch
+func (h *HTTPHandler) segmentBatch(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readPost(w, r)
+	if !ok {
+		return
+	}
+
+	receivedAt := time.Now().UTC()
+
+	var batch SegmentBatch
+	if err := json.Unmarshal(body, &batch); err != nil {
+		eventsRejected.WithLabelValues(rejectInvalidPayload).Inc()
+		h.writeError(w, status.Errorf(codes.InvalidArgument, "invalid JSON: %v", err))
+		return
+	}
+	if len(batch.Batch) == 0 {
+		h.writeError(w, status.Error(codes.InvalidArgument, "batch is empty"))
+		return
+	}
+
+	events := make([]*Event, 0, len(batch.Batch))
+	for _, msg := range batch.Batch {
+		// context и sentAt батча действуют на сообщения, где своих нет
+		if msg.Context == nil {
+			msg.Context = batch.Context
+		}
+		if msg.SentAt == nil {
+			msg.SentAt = batch.SentAt
+		}
+
+		event, err := msg.toEvent(receivedAt)
+		if err != nil {
+			recordRejected(err)
+			h.logger.Warn("Invalid Segment message in batch",
+				zap.Error(err),
+				zap.String("message_id", msg.MessageID),
+				zap.String("type", msg.Type),
+			)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		h.writeError(w, status.Error(codes.InvalidArgument, "no valid messages in batch"))
+		return
+	}
+
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	if _, err := h.handler.service.TrackEventBatch(ctx, events); err != nil {
+		h.writeError(w, status.Errorf(codes.Internal, "failed to track batch: %v", err))
+		return
+	}
+
+	writeSegmentResponse(w)
+}
+
+// segmentStatus повторяет коды gRPC Handler.TrackEvent
+func segmentStatus(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidEventType), errors.Is(err, ErrInvalidSessionID), errors.Is(err, ErrInvalidUserID),
+		errors.Is(err, ErrInvalidAnonymousID), errors.Is(err, ErrInvalidMetadata):
+		return status.Errorf(codes.InvalidArgument, "can't track event: %v", err)
+	default:
+		return status.Errorf(codes.Internal, "can't track event: %v", err)
+	}
+}
+
+func writeSegmentResponse(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(segmentResponse{Success: true})
+}
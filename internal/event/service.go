@@ -9,27 +9,35 @@ import (
 	"go.uber.org/zap"
 )
 
-type KafkaProducer interface {
-	SendMessage(ctx context.Context, key string, value any) error
-	SendMessageBatch(ctx context.Context, messages map[string]any) error
-}
-
 type Service struct {
 	repo     Repository
-	producer KafkaProducer
+	registry *Registry
 	logger   *zap.Logger
 }
 
-func NewService(repo Repository, producer KafkaProducer, logger *zap.Logger) *Service {
+func NewService(repo Repository, registry *Registry, logger *zap.Logger) *Service {
 	return &Service{
 		repo:     repo,
-		producer: producer,
+		registry: registry,
 		logger:   logger,
 	}
 }
 
+// Validate проверяет событие по схеме его типа из реестра и приводит
+// строковые значения типизированных полей к числам и bool
+func (s *Service) Validate(event *Event) error {
+	schema := s.registry.Get(event.EventType)
+	if err := event.Validate(schema); err != nil {
+		return err
+	}
+
+	event.Data = schema.NormalizeData(event.Data)
+	return nil
+}
+
 func (s *Service) TrackEvent(ctx context.Context, event *Event) error {
-	if err := event.Validate(); err != nil {
+	if err := s.Validate(event); err != nil {
+		recordRejected(err)
 		s.logger.Warn("failed to validate event",
 			zap.Error(err),
 			zap.String("event_id", event.ID.String()))
@@ -37,6 +45,7 @@ func (s *Service) TrackEvent(ctx context.Context, event *Event) error {
 	}
 
 	if err := s.repo.Create(ctx, event); err != nil {
+		recordRejected(err)
 		if errors.Is(err, ErrDuplicateEvent) {
 			s.logger.Debug("event is already tracked", zap.String("event_id", event.ID.String()))
 			return nil
@@ -49,15 +58,9 @@ func (s *Service) TrackEvent(ctx context.Context, event *Event) error {
 		return fmt.Errorf("failed to create event: %w", err)
 	}
 
-	// События одного пользователя идут в одну партицию
-	key := event.UserID.String()
-
-	if err := s.producer.SendMessage(ctx, key, event); err != nil {
-		s.logger.Error("failed to send message",
-			zap.String("event_id", event.ID.String()),
-			zap.Error(err))
-	}
+	eventsIngested.WithLabelValues(event.EventType).Inc()
 
+	// В Kafka событие уходит через outbox relay
 	s.logger.Info("Event tracked successfully",
 		zap.String("event_id", event.ID.String()),
 		zap.String("event_type", event.EventType),
@@ -66,41 +69,78 @@ func (s *Service) TrackEvent(ctx context.Context, event *Event) error {
 	return nil
 }
 
-func (s *Service) TrackEventBatch(ctx context.Context, events []*Event) (int, []string, error) {
+// TrackEventBatch возвращает результат по каждому событию в порядке events.
+// Ошибка означает, что батч не сохранён целиком.
+func (s *Service) TrackEventBatch(ctx context.Context, events []*Event) ([]*EventResult, error) {
 	if len(events) == 0 {
-		return 0, nil, fmt.Errorf("no events provided")
+		return nil, fmt.Errorf("no events provided")
 	}
 
 	s.logger.Info("Tracking events", zap.Int("events", len(events)))
 
-	if err := s.repo.CreateBatch(ctx, events); err != nil {
-		s.logger.Error("failed to create event batch", zap.Error(err))
-		return 0, nil, fmt.Errorf("failed to save batch: %w", err)
+	results := make([]*EventResult, len(events))
+	valid := make([]*Event, 0, len(events))
+	validIdx := make([]int, 0, len(events))
+
+	for i, event := range events {
+		if err := s.Validate(event); err != nil {
+			recordRejected(err)
+			results[i] = NewEventResult(event.ID.String(), EventStatusInvalid, err)
+			continue
+		}
+		valid = append(valid, event)
+		validIdx = append(validIdx, i)
 	}
 
-	messages := make(map[string]any)
-	failedIDs := make([]string, 0)
+	if len(valid) == 0 {
+		return results, nil
+	}
 
-	for _, event := range events {
-		key := event.UserID.String()
-		messages[key] = event.EventType
+	saved, err := s.repo.CreateBatch(ctx, valid)
+	if err != nil {
+		eventsRejected.WithLabelValues(rejectStorageError).Add(float64(len(valid)))
+		s.logger.Error("failed to create event batch", zap.Error(err))
+		return nil, fmt.Errorf("failed to save batch: %w", err)
 	}
 
-	for key, value := range messages {
-		if err := s.producer.SendMessage(ctx, key, value); err != nil {
-			if ev, ok := value.(*Event); ok {
-				failedIDs = append(failedIDs, ev.ID.String())
-			}
-			s.logger.Error("Failed to send message in batch",
-				zap.Error(err),
-				zap.String("key", key),
-			)
+	for j, result := range saved {
+		results[validIdx[j]] = result
+
+		switch result.Status {
+		case EventStatusAccepted:
+			eventsIngested.WithLabelValues(valid[j].EventType).Inc()
+		case EventStatusDuplicate:
+			eventsRejected.WithLabelValues(rejectDuplicate).Inc()
+		default:
+			eventsRejected.WithLabelValues(rejectStorageError).Inc()
 		}
 	}
 
-	successCount := len(events) - len(failedIDs)
+	return results, nil
+}
+
+// Identify связывает анонимного посетителя с пользователем. Возвращает false,
+// если такая связь уже была.
+func (s *Service) Identify(ctx context.Context, link *IdentityLink) (bool, error) {
+	if err := link.Validate(); err != nil {
+		return false, fmt.Errorf("invalid identity link: %w", err)
+	}
+
+	created, err := s.repo.Identify(ctx, link)
+	if err != nil {
+		if !errors.Is(err, ErrIdentityConflict) {
+			s.logger.Error("failed to link identity", zap.Error(err),
+				zap.String("anonymous_id", link.AnonymousID))
+		}
+		return false, fmt.Errorf("failed to link identity: %w", err)
+	}
 
-	return successCount, failedIDs, nil
+	s.logger.Info("Identity linked",
+		zap.String("anonymous_id", link.AnonymousID),
+		zap.String("user_id", link.UserID.String()),
+		zap.Bool("created", created),
+	)
+	return created, nil
 }
 
 func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*Event, error) {
@@ -0,0 +1,173 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	pb "github.com/Wuchinator/realtime-analytics/pkg/pb/events"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// streamBatch - события, накопленные до следующего flush
+type streamBatch struct {
+	events   []*Event
+	rejected []*pb.RejectedEvent
+}
+
+func (b *streamBatch) size() int {
+	return len(b.events) + len(b.rejected)
+}
+
+func (b *streamBatch) reset() {
+	b.events = b.events[:0]
+	b.rejected = nil
+}
+
+// TrackEventStream принимает поток событий и пишет их батчами через CreateBatch.
+// Flush происходит при накоплении StreamBatchSize событий или раз в
+// StreamFlushInterval; после каждого flush клиент получает ack.
+func (h *Handler) TrackEventStream(stream grpc.BidiStreamingServer[pb.TrackEventStreamRequest, pb.TrackEventStreamAck]) error {
+	ctx := stream.Context()
+
+	requests := make(chan *pb.TrackEventStreamRequest)
+	recvErr := make(chan error, 1)
+
+	// Recv блокирующий, поэтому читаем в отдельной goroutine, а flush по таймеру делаем здесь
+	go func() {
+		defer close(requests)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-ctx.Done():
+				recvErr <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(h.cfg.StreamFlushInterval)
+	defer ticker.Stop()
+
+	batch := &streamBatch{events: make([]*Event, 0, h.cfg.StreamBatchSize)}
+	var totalAccepted int64
+
+	flush := func() error {
+		if batch.size() == 0 {
+			return nil
+		}
+
+		ack := h.flushStream(ctx, batch)
+		totalAccepted += int64(len(ack.AcceptedEventIds))
+		ack.TotalAccepted = totalAccepted
+		batch.reset()
+
+		return stream.Send(ack)
+	}
+
+	for {
+		select {
+		case req, ok := <-requests:
+			if !ok {
+				// Клиент закрыл stream: дописываем остаток и завершаем RPC
+				if err := <-recvErr; !errors.Is(err, io.EOF) {
+					return err
+				}
+				return flush()
+			}
+
+			for _, protoEvent := range req.Events {
+				h.addToStreamBatch(batch, protoEvent)
+
+				if batch.size() >= h.cfg.StreamBatchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// addToStreamBatch проверяет событие так же, как TrackEvent, и кладёт его в батч
+func (h *Handler) addToStreamBatch(batch *streamBatch, protoEvent *pb.Event) {
+	event, err := h.protoToEvent(protoEvent)
+	if err != nil {
+		eventsRejected.WithLabelValues(rejectInvalidPayload).Inc()
+		batch.rejected = append(batch.rejected, &pb.RejectedEvent{
+			EventId: protoEvent.EventId,
+			Reason:  err.Error(),
+		})
+		return
+	}
+
+	if err := h.service.Validate(event); err != nil {
+		recordRejected(err)
+		batch.rejected = append(batch.rejected, &pb.RejectedEvent{
+			EventId: event.ID.String(),
+			Reason:  err.Error(),
+		})
+		return
+	}
+
+	batch.events = append(batch.events, event)
+}
+
+func (h *Handler) flushStream(ctx context.Context, batch *streamBatch) *pb.TrackEventStreamAck {
+	ack := &pb.TrackEventStreamAck{
+		Rejected: batch.rejected,
+	}
+	if len(batch.events) == 0 {
+		return ack
+	}
+
+	results, err := h.service.TrackEventBatch(ctx, batch.events)
+	if err != nil {
+		h.logger.Error("Failed to flush event stream batch",
+			zap.Error(err),
+			zap.Int("events", len(batch.events)),
+		)
+		for _, event := range batch.events {
+			ack.Rejected = append(ack.Rejected, &pb.RejectedEvent{
+				EventId: event.ID.String(),
+				Reason:  err.Error(),
+			})
+		}
+		return ack
+	}
+
+	// Дубликат уже был принят раньше, для клиента это тоже подтверждение
+	for _, result := range results {
+		switch result.Status {
+		case EventStatusAccepted, EventStatusDuplicate:
+			ack.AcceptedEventIds = append(ack.AcceptedEventIds, result.EventID)
+		default:
+			ack.Rejected = append(ack.Rejected, &pb.RejectedEvent{
+				EventId: result.EventID,
+				Reason:  result.Reason,
+			})
+		}
+	}
+
+	h.logger.Debug("Event stream batch flushed",
+		zap.Int("accepted", len(ack.AcceptedEventIds)),
+		zap.Int("rejected", len(ack.Rejected)),
+	)
+
+	return ack
+}
@@ -0,0 +1,137 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/internal/analytics"
+	"github.com/Wuchinator/realtime-analytics/pkg/hll"
+	"go.uber.org/zap"
+)
+
+// getDimensionStats считает статистику по analytics_dimensions: строки
+// фильтруются по filters и группируются по бакету, типу события и groupBy
+func (s *Service) getDimensionStats(
+	ctx context.Context,
+	from, to time.Time,
+	eventType string,
+	granularity string,
+	groupBy []string,
+	filters map[string]string,
+) ([]*EventStat, error) {
+	for _, name := range groupBy {
+		if !slices.Contains(s.cfg.Dimensions, name) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownDimension, name)
+		}
+	}
+	for name := range filters {
+		if !slices.Contains(s.cfg.Dimensions, name) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownDimension, name)
+		}
+	}
+
+	// analytics_dimensions хранит только часовые бакеты
+	if !granularities[granularity] {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidGranularity, granularity)
+	}
+
+	summaries, err := s.analyticsRepo.GetDimensionSummaries(ctx, from, to, eventType, filters)
+	if err != nil {
+		s.logger.Error("Failed to get dimension summaries",
+			zap.Error(err),
+			zap.Time("from", from),
+			zap.Time("to", to))
+		return nil, fmt.Errorf("failed to get dimension summaries: %w", err)
+	}
+
+	stats := s.groupByDimensions(summaries, granularity, groupBy)
+
+	s.logger.Info("Event stats by dimensions retrieved",
+		zap.Int("count", len(stats)),
+		zap.String("granularity", granularity),
+		zap.Strings("group_by", groupBy),
+	)
+
+	return stats, nil
+}
+
+// groupByDimensions оставляет у строк только измерения из groupBy и
+// складывает совпавшие. Строки без значения измерения попадают в группу "".
+func (s *Service) groupByDimensions(
+	summaries []*analytics.DimensionSummary,
+	granularity string,
+	groupBy []string,
+) []*EventStat {
+	grouped := make(map[string]*EventStat)
+	sketches := make(map[string]*hll.Sketch)
+
+	for _, summary := range summaries {
+		hour := time.Date(
+			summary.Date.Year(),
+			summary.Date.Month(),
+			summary.Date.Day(),
+			summary.Hour,
+			0, 0, 0,
+			time.UTC,
+		)
+		timestamp := truncateToGranularity(hour, granularity)
+
+		dims := make(map[string]string, len(groupBy))
+		parts := []string{timestamp.Format(time.RFC3339), summary.EventType}
+		for _, name := range groupBy {
+			dims[name] = summary.Dimensions[name]
+			parts = append(parts, dims[name])
+		}
+		key := strings.Join(parts, "\x00")
+
+		stat, exists := grouped[key]
+		if !exists {
+			stat = &EventStat{
+				Timestamp:  timestamp,
+				EventType:  summary.EventType,
+				Dimensions: dims,
+			}
+			grouped[key] = stat
+			sketches[key] = hll.New()
+		}
+		stat.TotalEvents += summary.TotalEvents
+
+		sketch, err := hll.FromBytes(summary.UsersSketch)
+		if err != nil {
+			s.logger.Warn("Failed to decode users sketch",
+				zap.Error(err),
+				zap.String("key", key),
+			)
+			continue
+		}
+		if err := sketches[key].Merge(sketch); err != nil {
+			s.logger.Warn("Failed to merge users sketch",
+				zap.Error(err),
+				zap.String("key", key),
+			)
+		}
+	}
+
+	stats := make([]*EventStat, 0, len(grouped))
+	for key, stat := range grouped {
+		stat.UniqueUsers = sketches[key].Estimate()
+		stats = append(stats, stat)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if !stats[i].Timestamp.Equal(stats[j].Timestamp) {
+			return stats[i].Timestamp.Before(stats[j].Timestamp)
+		}
+		if stats[i].EventType != stats[j].EventType {
+			return stats[i].EventType < stats[j].EventType
+		}
+		// fmt печатает map с отсортированными ключами
+		return fmt.Sprint(stats[i].Dimensions) < fmt.Sprint(stats[j].Dimensions)
+	})
+
+	return stats
+}
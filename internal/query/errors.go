@@ -0,0 +1,13 @@
+package query
+
+import "errors"
+
+var (
+	ErrInvalidFunnelSteps = errors.New("funnel needs at least two steps")
+
+	ErrInvalidCountBy = errors.New("count_by must be user or session")
+
+	ErrInvalidGranularity = errors.New("invalid granularity")
+
+	ErrUnknownDimension = errors.New("unknown dimension")
+)
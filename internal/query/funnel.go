@@ -0,0 +1,92 @@
+package query
+
+import (
+	"slices"
+	"time"
+)
+
+// Колонки events, по которым склеиваются шаги воронки
+var funnelKeyColumns = map[string]string{
+	"user":    "user_id",
+	"session": "session_id",
+}
+
+// buildFunnel считает воронку по событиям, отсортированным по ключу и времени.
+// Для каждого ключа берётся первое вхождение первого шага в [from, to], дальше
+// шаги засчитываются строго по порядку, пока не истечёт окно конверсии.
+func buildFunnel(events []*FunnelEvent, steps []string, from, to time.Time, window time.Duration) []*FunnelStep {
+	counts := make([]int64, len(steps))
+	durations := make([][]time.Duration, len(steps))
+
+	var (
+		currentKey  string
+		step        int
+		start, last time.Time
+	)
+
+	for i, e := range events {
+		if i == 0 || e.Key != currentKey {
+			currentKey = e.Key
+			step = 0
+		}
+
+		if step == len(steps) || e.EventType != steps[step] {
+			continue
+		}
+
+		if step == 0 {
+			if e.CreatedAt.Before(from) || e.CreatedAt.After(to) {
+				continue
+			}
+			start = e.CreatedAt
+		} else {
+			if e.CreatedAt.Sub(start) > window {
+				continue
+			}
+			durations[step] = append(durations[step], e.CreatedAt.Sub(last))
+		}
+
+		last = e.CreatedAt
+		counts[step]++
+		step++
+	}
+
+	result := make([]*FunnelStep, len(steps))
+	for i, eventType := range steps {
+		result[i] = &FunnelStep{
+			EventType: eventType,
+			Users:     counts[i],
+		}
+
+		if i == 0 {
+			if counts[0] > 0 {
+				result[i].ConversionRate = 1
+				result[i].OverallConversionRate = 1
+			}
+			continue
+		}
+
+		if counts[i-1] > 0 {
+			result[i].ConversionRate = float64(counts[i]) / float64(counts[i-1])
+		}
+		if counts[0] > 0 {
+			result[i].OverallConversionRate = float64(counts[i]) / float64(counts[0])
+		}
+		result[i].MedianTimeFromPrevious = median(durations[i])
+	}
+
+	return result
+}
+
+func median(values []time.Duration) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+
+	slices.Sort(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
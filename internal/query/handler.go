@@ -3,12 +3,19 @@ package query
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
 
+	"github.com/Wuchinator/realtime-analytics/internal/analytics"
+	"github.com/Wuchinator/realtime-analytics/pkg/identity"
 	pb "github.com/Wuchinator/realtime-analytics/pkg/pb/analytics"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -39,14 +46,24 @@ func (h *Handler) GetEventStats(
 		return nil, status.Error(codes.InvalidArgument, "from and to timestamps are required")
 	}
 
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = "hour"
+	}
+
 	stats, err := h.service.GetEventStats(
 		ctx,
 		req.From.AsTime(),
 		req.To.AsTime(),
 		req.EventType,
-		req.Granularity,
+		granularity,
+		req.GroupBy,
+		req.Filters,
 	)
 	if err != nil {
+		if errors.Is(err, ErrUnknownDimension) || errors.Is(err, ErrInvalidGranularity) {
+			return nil, status.Errorf(codes.InvalidArgument, "can't get stats: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "failed to get stats: %v", err)
 	}
 
@@ -57,16 +74,12 @@ func (h *Handler) GetEventStats(
 			EventType:   stat.EventType,
 			TotalEvents: stat.TotalEvents,
 			UniqueUsers: stat.UniqueUsers,
+			Dimensions:  stat.Dimensions,
 		}
 
 		// Добавляем metadata если есть
 		if stat.Metadata != nil {
-			pbStats[i].Metadata = make(map[string]string)
-			for k, v := range stat.Metadata {
-				if str, ok := v.(string); ok {
-					pbStats[i].Metadata[k] = str
-				}
-			}
+			pbStats[i].Metadata, pbStats[i].Properties = metadataToProto(stat.Metadata)
 		}
 	}
 
@@ -76,18 +89,68 @@ func (h *Handler) GetEventStats(
 	}, nil
 }
 
+func (h *Handler) SubscribeEventStats(
+	req *pb.SubscribeEventStatsRequest,
+	stream pb.QueryService_SubscribeEventStatsServer,
+) error {
+	h.logger.Debug("SubscribeEventStats called",
+		zap.Strings("event_types", req.EventTypes),
+		zap.String("granularity", req.Granularity),
+	)
+
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = "hour"
+	}
+
+	minInterval := time.Second // дефолт
+	if req.MinInterval != nil {
+		minInterval = req.MinInterval.AsDuration()
+	}
+	if minInterval < 100*time.Millisecond {
+		return status.Error(codes.InvalidArgument, "min_interval must be at least 100ms")
+	}
+
+	err := h.service.SubscribeEventStats(stream.Context(), granularity, req.EventTypes, minInterval, func(stat *EventStat) error {
+		return stream.Send(&pb.EventStats{
+			Timestamp:   timestamppb.New(stat.Timestamp),
+			EventType:   stat.EventType,
+			TotalEvents: stat.TotalEvents,
+			UniqueUsers: stat.UniqueUsers,
+		})
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidGranularity) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return status.Errorf(codes.Internal, "stats subscription failed: %v", err)
+	}
+
+	return nil
+}
+
 func (h *Handler) GetUserActivity(
 	ctx context.Context,
 	req *pb.GetUserActivityRequest,
 ) (*pb.GetUserActivityResponse, error) {
 	h.logger.Debug("GetUserActivity called",
 		zap.String("user_id", req.UserId),
+		zap.String("anonymous_id", req.AnonymousId),
 	)
 
-	// Парсим UUID
-	userID, err := uuid.Parse(req.UserId)
-	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+	// Анонимный посетитель ищется по тому же UUID, под которым event-service хранит его события
+	var userID uuid.UUID
+	switch {
+	case req.UserId != "":
+		parsed, err := uuid.Parse(req.UserId)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid user_id")
+		}
+		userID = parsed
+	case req.AnonymousId != "":
+		userID = identity.AnonymousUserID(req.AnonymousId)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "user_id or anonymous_id is required")
 	}
 
 	if req.From == nil || req.To == nil {
@@ -99,7 +162,7 @@ func (h *Handler) GetUserActivity(
 		limit = 100 // дефолт
 	}
 
-	events, totalCount, err := h.service.GetUserActivity(
+	activity, err := h.service.GetUserActivity(
 		ctx,
 		userID,
 		req.From.AsTime(),
@@ -110,8 +173,8 @@ func (h *Handler) GetUserActivity(
 		return nil, status.Errorf(codes.Internal, "failed to get user activity: %v", err)
 	}
 
-	pbEvents := make([]*pb.UserEvent, len(events))
-	for i, event := range events {
+	pbEvents := make([]*pb.UserEvent, len(activity.Events))
+	for i, event := range activity.Events {
 		pbEvents[i] = &pb.UserEvent{
 			EventId:   event.ID.String(),
 			EventType: event.EventType,
@@ -121,25 +184,23 @@ func (h *Handler) GetUserActivity(
 		if event.ProductID != nil {
 			pbEvents[i].ProductId = event.ProductID.String()
 		}
+		if event.AnonymousID != nil {
+			pbEvents[i].AnonymousId = *event.AnonymousID
+		}
 
 		// Парсим JSON data в metadata
 		if len(event.Data) > 0 {
 			var metadata map[string]interface{}
 			if err := json.Unmarshal(event.Data, &metadata); err == nil {
-				pbEvents[i].Metadata = make(map[string]string)
-				for k, v := range metadata {
-					if str, ok := v.(string); ok {
-						pbEvents[i].Metadata[k] = str
-					}
-				}
+				pbEvents[i].Metadata, pbEvents[i].Properties = metadataToProto(metadata)
 			}
 		}
 	}
 
 	return &pb.GetUserActivityResponse{
-		UserId:      req.UserId,
+		UserId:      activity.UserID.String(),
 		Events:      pbEvents,
-		TotalEvents: totalCount,
+		TotalEvents: activity.TotalEvents,
 	}, nil
 }
 
@@ -149,6 +210,8 @@ func (h *Handler) GetTopProducts(
 ) (*pb.GetTopProductsResponse, error) {
 	h.logger.Debug("GetTopProducts called",
 		zap.Int32("limit", req.Limit),
+		zap.String("event_type", req.EventType),
+		zap.String("rank_by", req.RankBy),
 	)
 
 	if req.From == nil || req.To == nil {
@@ -166,8 +229,12 @@ func (h *Handler) GetTopProducts(
 		req.To.AsTime(),
 		limit,
 		req.EventType,
+		req.RankBy,
 	)
 	if err != nil {
+		if errors.Is(err, analytics.ErrInvalidRankBy) {
+			return nil, status.Errorf(codes.InvalidArgument, "can't get top products: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "failed to get top products: %v", err)
 	}
 
@@ -178,6 +245,11 @@ func (h *Handler) GetTopProducts(
 			EventCount:     p.EventCount,
 			UniqueUsers:    p.UniqueUsers,
 			ConversionRate: p.ConversionRate,
+			Views:          p.Views,
+			AddToCarts:     p.AddToCarts,
+			Purchases:      p.Purchases,
+			AddToCartRate:  p.AddToCartRate,
+			Revenue:        p.Revenue,
 		}
 	}
 
@@ -186,6 +258,244 @@ func (h *Handler) GetTopProducts(
 	}, nil
 }
 
+func (h *Handler) GetFunnel(
+	ctx context.Context,
+	req *pb.GetFunnelRequest,
+) (*pb.GetFunnelResponse, error) {
+	h.logger.Debug("GetFunnel called",
+		zap.Strings("steps", req.Steps),
+		zap.String("count_by", req.CountBy),
+	)
+
+	if req.From == nil || req.To == nil {
+		return nil, status.Error(codes.InvalidArgument, "from and to timestamps are required")
+	}
+
+	window := 24 * time.Hour // дефолт
+	if req.ConversionWindow != nil {
+		window = req.ConversionWindow.AsDuration()
+	}
+	if window <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "conversion_window must be positive")
+	}
+
+	countBy := req.CountBy
+	if countBy == "" {
+		countBy = "user"
+	}
+
+	steps, err := h.service.GetFunnel(
+		ctx,
+		req.From.AsTime(),
+		req.To.AsTime(),
+		req.Steps,
+		window,
+		countBy,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidFunnelSteps), errors.Is(err, ErrInvalidCountBy):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid funnel: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to get funnel: %v", err)
+		}
+	}
+
+	pbSteps := make([]*pb.FunnelStep, len(steps))
+	for i, step := range steps {
+		pbSteps[i] = &pb.FunnelStep{
+			EventType:              step.EventType,
+			Users:                  step.Users,
+			ConversionRate:         step.ConversionRate,
+			OverallConversionRate:  step.OverallConversionRate,
+			MedianTimeFromPrevious: durationpb.New(step.MedianTimeFromPrevious),
+		}
+	}
+
+	return &pb.GetFunnelResponse{
+		Steps:   pbSteps,
+		CountBy: countBy,
+	}, nil
+}
+
+func (h *Handler) GetRetention(
+	ctx context.Context,
+	req *pb.GetRetentionRequest,
+) (*pb.GetRetentionResponse, error) {
+	h.logger.Debug("GetRetention called",
+		zap.String("period", req.Period),
+		zap.Int32("periods", req.Periods),
+		zap.String("cohort_event_type", req.CohortEventType),
+		zap.String("return_event_type", req.ReturnEventType),
+	)
+
+	if req.From == nil || req.To == nil {
+		return nil, status.Error(codes.InvalidArgument, "from and to timestamps are required")
+	}
+
+	period := req.Period
+	if period == "" {
+		period = analytics.PeriodDay
+	}
+
+	periods := int(req.Periods)
+	if periods <= 0 {
+		periods = 7 // дефолт
+	}
+	if periods > 365 {
+		return nil, status.Error(codes.InvalidArgument, "periods must not exceed 365")
+	}
+
+	cohorts, fromCache, err := h.service.GetRetention(ctx, &analytics.RetentionQuery{
+		From:            req.From.AsTime(),
+		To:              req.To.AsTime(),
+		Period:          period,
+		Periods:         periods,
+		CohortEventType: req.CohortEventType,
+		ReturnEventType: req.ReturnEventType,
+	}, req.UseCache)
+	if err != nil {
+		if errors.Is(err, analytics.ErrInvalidPeriod) {
+			return nil, status.Errorf(codes.InvalidArgument, "can't get retention: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get retention: %v", err)
+	}
+
+	pbCohorts := make([]*pb.RetentionCohort, len(cohorts))
+	for i, cohort := range cohorts {
+		pbCohorts[i] = &pb.RetentionCohort{
+			CohortStart:    timestamppb.New(cohort.CohortStart),
+			Size:           cohort.Size,
+			Retained:       cohort.Retained,
+			RetentionRates: cohort.RetentionRates,
+		}
+	}
+
+	return &pb.GetRetentionResponse{
+		Cohorts:   pbCohorts,
+		Period:    period,
+		FromCache: fromCache,
+	}, nil
+}
+
+func (h *Handler) GetSessionStats(
+	ctx context.Context,
+	req *pb.GetSessionStatsRequest,
+) (*pb.GetSessionStatsResponse, error) {
+	h.logger.Debug("GetSessionStats called",
+		zap.String("granularity", req.Granularity),
+	)
+
+	if req.From == nil || req.To == nil {
+		return nil, status.Error(codes.InvalidArgument, "from and to timestamps are required")
+	}
+
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = "day"
+	}
+
+	stats, err := h.service.GetSessionStats(ctx, req.From.AsTime(), req.To.AsTime(), granularity)
+	if err != nil {
+		if errors.Is(err, ErrInvalidGranularity) {
+			return nil, status.Errorf(codes.InvalidArgument, "can't get session stats: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get session stats: %v", err)
+	}
+
+	pbStats := make([]*pb.SessionStats, len(stats))
+	for i, stat := range stats {
+		pbStats[i] = &pb.SessionStats{
+			Timestamp:           timestamppb.New(stat.Timestamp),
+			Sessions:            stat.Sessions,
+			Users:               stat.Users,
+			AvgDuration:         durationpb.New(stat.AvgDuration),
+			BounceRate:          stat.BounceRate,
+			ConversionRate:      stat.ConversionRate,
+			SessionsPerUser:     stat.SessionsPerUser,
+			AvgEventsPerSession: stat.AvgEventsPerSession,
+		}
+	}
+
+	return &pb.GetSessionStatsResponse{
+		Stats: pbStats,
+	}, nil
+}
+
+func (h *Handler) GetRevenueStats(
+	ctx context.Context,
+	req *pb.GetRevenueStatsRequest,
+) (*pb.GetRevenueStatsResponse, error) {
+	h.logger.Debug("GetRevenueStats called",
+		zap.String("granularity", req.Granularity),
+		zap.Int32("breakdown_limit", req.BreakdownLimit),
+	)
+
+	if req.From == nil || req.To == nil {
+		return nil, status.Error(codes.InvalidArgument, "from and to timestamps are required")
+	}
+
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = "day"
+	}
+
+	limit := int(req.BreakdownLimit)
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	report, err := h.service.GetRevenueStats(ctx, req.From.AsTime(), req.To.AsTime(), granularity, limit)
+	if err != nil {
+		if errors.Is(err, ErrInvalidGranularity) {
+			return nil, status.Errorf(codes.InvalidArgument, "can't get revenue stats: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get revenue stats: %v", err)
+	}
+
+	resp := &pb.GetRevenueStatsResponse{
+		Stats:      make([]*pb.RevenueStats, len(report.Stats)),
+		Total:      revenueStatToProto(report.Total),
+		Products:   revenueBreakdownToProto(report.Products),
+		Categories: revenueBreakdownToProto(report.Categories),
+		Currency:   report.Currency,
+	}
+	for i, stat := range report.Stats {
+		resp.Stats[i] = revenueStatToProto(stat)
+	}
+
+	return resp, nil
+}
+
+func revenueStatToProto(stat *RevenueStat) *pb.RevenueStats {
+	return &pb.RevenueStats{
+		Timestamp:         timestamppb.New(stat.Timestamp),
+		Revenue:           stat.Revenue,
+		Orders:            stat.Orders,
+		Items:             stat.Items,
+		Buyers:            stat.Buyers,
+		AverageOrderValue: stat.AverageOrderValue,
+		RevenuePerUser:    stat.RevenuePerUser,
+	}
+}
+
+func revenueBreakdownToProto(rows []*RevenueBreakdown) []*pb.RevenueBreakdown {
+	breakdown := make([]*pb.RevenueBreakdown, len(rows))
+	for i, row := range rows {
+		breakdown[i] = &pb.RevenueBreakdown{
+			Key:          row.Key,
+			Revenue:      row.Revenue,
+			Orders:       row.Orders,
+			Items:        row.Items,
+			RevenueShare: row.RevenueShare,
+		}
+	}
+	return breakdown
+}
+
 func (h *Handler) HealthCheck(
 	ctx context.Context,
 	req *pb.HealthCheckRequest,
@@ -198,3 +508,32 @@ func (h *Handler) HealthCheck(
 		Dependencies: deps,
 	}, nil
 }
+
+// metadataToProto возвращает значения с исходными типами в properties и их
+// строковое представление для устаревшего поля metadata
+func metadataToProto(values map[string]any) (map[string]string, *structpb.Struct) {
+	metadata := make(map[string]string, len(values))
+	for k, v := range values {
+		switch val := v.(type) {
+		case nil:
+		case string:
+			metadata[k] = val
+		case float64:
+			metadata[k] = strconv.FormatFloat(val, 'f', -1, 64)
+		case bool:
+			metadata[k] = strconv.FormatBool(val)
+		default:
+			// Списки и вложенные объекты отдаются как JSON
+			if raw, err := json.Marshal(val); err == nil {
+				metadata[k] = string(raw)
+			}
+		}
+	}
+
+	properties, err := structpb.NewStruct(values)
+	if err != nil {
+		// В values только то, что дал json.Unmarshal, так что сюда не попадаем
+		return metadata, nil
+	}
+	return metadata, properties
+}
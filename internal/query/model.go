@@ -11,6 +11,7 @@ type Event struct {
 	ID          uuid.UUID       `db:"id" json:"id"`
 	EventType   string          `db:"event_type" json:"event_type"`
 	UserID      uuid.UUID       `db:"user_id" json:"user_id"`
+	AnonymousID *string         `db:"anonymous_id" json:"anonymous_id,omitempty"`
 	SessionID   uuid.UUID       `db:"session_id" json:"session_id"`
 	ProductID   *uuid.UUID      `db:"product_id" json:"product_id"`
 	Data        json.RawMessage `db:"data" json:"data"`
@@ -18,12 +19,22 @@ type Event struct {
 	ProcessedAt *time.Time      `db:"processed_at" json:"processed_at"`
 }
 
+// UserActivity - события пользователя вместе с событиями до логина.
+// UserID - канонический id, даже если запрошен анонимный.
+type UserActivity struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Events      []*Event  `json:"events"`
+	TotalEvents int64     `json:"total_events"`
+}
+
 type EventStat struct {
 	Timestamp   time.Time      `json:"timestamp"`
 	EventType   string         `json:"event_type"`
 	TotalEvents int64          `json:"total_events"`
 	UniqueUsers int64          `json:"unique_users"`
 	Metadata    map[string]any `json:"metadata,omitempty"`
+	// Значения измерений группы, только для запросов с group_by
+	Dimensions map[string]string `json:"dimensions,omitempty"`
 }
 
 type ProductStat struct {
@@ -31,4 +42,68 @@ type ProductStat struct {
 	EventCount     int64   `json:"event_count"`
 	UniqueUsers    int64   `json:"unique_users"`
 	ConversionRate float64 `json:"conversion_rate"`
+	Views          int64   `json:"views"`
+	AddToCarts     int64   `json:"add_to_carts"`
+	Purchases      int64   `json:"purchases"`
+	AddToCartRate  float64 `json:"add_to_cart_rate"`
+	Revenue        float64 `json:"revenue"`
+}
+
+// FunnelEvent - минимальная проекция события для расчёта воронки
+type FunnelEvent struct {
+	Key       string    `db:"key" json:"key"`
+	EventType string    `db:"event_type" json:"event_type"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type FunnelStep struct {
+	EventType              string        `json:"event_type"`
+	Users                  int64         `json:"users"`
+	ConversionRate         float64       `json:"conversion_rate"`
+	OverallConversionRate  float64       `json:"overall_conversion_rate"`
+	MedianTimeFromPrevious time.Duration `json:"median_time_from_previous"`
+}
+
+type RetentionCohort struct {
+	CohortStart    time.Time `json:"cohort_start"`
+	Size           int64     `json:"size"`
+	Retained       []int64   `json:"retained"`
+	RetentionRates []float64 `json:"retention_rates"`
+}
+
+type SessionStat struct {
+	Timestamp           time.Time     `json:"timestamp"`
+	Sessions            int64         `json:"sessions"`
+	Users               int64         `json:"users"`
+	AvgDuration         time.Duration `json:"avg_duration"`
+	BounceRate          float64       `json:"bounce_rate"`
+	ConversionRate      float64       `json:"conversion_rate"`
+	SessionsPerUser     float64       `json:"sessions_per_user"`
+	AvgEventsPerSession float64       `json:"avg_events_per_session"`
+}
+
+type RevenueStat struct {
+	Timestamp         time.Time `json:"timestamp"`
+	Revenue           float64   `json:"revenue"`
+	Orders            int64     `json:"orders"`
+	Items             int64     `json:"items"`
+	Buyers            int64     `json:"buyers"`
+	AverageOrderValue float64   `json:"average_order_value"`
+	RevenuePerUser    float64   `json:"revenue_per_user"`
+}
+
+type RevenueBreakdown struct {
+	Key          string  `json:"key"`
+	Revenue      float64 `json:"revenue"`
+	Orders       int64   `json:"orders"`
+	Items        int64   `json:"items"`
+	RevenueShare float64 `json:"revenue_share"`
+}
+
+type RevenueReport struct {
+	Stats      []*RevenueStat      `json:"stats"`
+	Total      *RevenueStat        `json:"total"`
+	Products   []*RevenueBreakdown `json:"products"`
+	Categories []*RevenueBreakdown `json:"categories"`
+	Currency   string              `json:"currency"`
 }
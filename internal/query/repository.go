@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 )
 
@@ -25,8 +26,8 @@ func NewEventRepository(db *sqlx.DB, logger *zap.Logger) EventRepository {
 
 func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Event, error) {
 	query := `
-		SELECT id, event_type, user_id, session_id, product_id, data, created_at, processed_at
-		FROM events
+		SELECT id, event_type, user_id, anonymous_id, session_id, product_id, data, created_at, processed_at
+		FROM resolved_events
 		WHERE id = $1
 	`
 
@@ -43,6 +44,25 @@ func (r *repository) GetByID(ctx context.Context, id uuid.UUID) (*Event, error)
 	return &event, nil
 }
 
+// ResolveUserID возвращает пользователя, с которым связан анонимный id, или
+// сам userID, если связи нет
+func (r *repository) ResolveUserID(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	var resolved uuid.UUID
+	err := r.db.GetContext(ctx, &resolved, `
+		SELECT user_id FROM identity_links WHERE anonymous_user_id = $1
+	`, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return userID, nil
+		}
+		return uuid.Nil, fmt.Errorf("failed to resolve user id: %w", err)
+	}
+
+	return resolved, nil
+}
+
+// GetByUserID возвращает события пользователя и связанных с ним анонимных посетителей.
+// userID должен быть каноническим, см. ResolveUserID.
 func (r *repository) GetByUserID(
 	ctx context.Context,
 	userID uuid.UUID,
@@ -50,9 +70,13 @@ func (r *repository) GetByUserID(
 	limit int,
 ) ([]*Event, error) {
 	query := `
-		SELECT id, event_type, user_id, session_id, product_id, data, created_at, processed_at
-		FROM events
-		WHERE user_id = $1
+		SELECT id, event_type, user_id, anonymous_id, session_id, product_id, data, created_at, processed_at
+		FROM resolved_events
+		WHERE raw_user_id IN (
+			SELECT $1::uuid
+			UNION ALL
+			SELECT anonymous_user_id FROM identity_links WHERE user_id = $1
+		)
 		  AND created_at >= $2
 		  AND created_at <= $3
 		ORDER BY created_at DESC
@@ -71,3 +95,31 @@ func (r *repository) GetByUserID(
 
 	return events, nil
 }
+
+// GetFunnelEvents возвращает события шагов воронки, отсортированные по ключу и времени.
+// keyColumn - user_id или session_id, проверяется в сервисе. user_id канонический,
+// поэтому шаги до и после логина попадают в одну воронку.
+func (r *repository) GetFunnelEvents(
+	ctx context.Context,
+	from, to time.Time,
+	eventTypes []string,
+	keyColumn string,
+) ([]*FunnelEvent, error) {
+	query := fmt.Sprintf(`
+		SELECT %[1]s::text AS key, event_type, created_at
+		FROM resolved_events
+		WHERE event_type = ANY($1)
+		  AND created_at >= $2
+		  AND created_at <= $3
+		ORDER BY %[1]s, created_at
+	`, keyColumn)
+
+	var events []*FunnelEvent
+	err := r.db.SelectContext(ctx, &events, query, pq.Array(eventTypes), from, to)
+	if err != nil {
+		r.logger.Error("Failed to get funnel events", zap.Error(err))
+		return nil, fmt.Errorf("failed to get funnel events: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,157 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/internal/analytics"
+	"github.com/Wuchinator/realtime-analytics/pkg/hll"
+	"go.uber.org/zap"
+)
+
+// GetRevenueStats сворачивает часовые бакеты выручки в granularity и
+// добавляет разбивку по товарам и категориям за весь период
+func (s *Service) GetRevenueStats(
+	ctx context.Context,
+	from, to time.Time,
+	granularity string,
+	breakdownLimit int,
+) (*RevenueReport, error) {
+	if !granularities[granularity] {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidGranularity, granularity)
+	}
+
+	summaries, err := s.analyticsRepo.GetRevenueSummaries(ctx, from, to)
+	if err != nil {
+		s.logger.Error("Failed to get revenue summaries",
+			zap.Error(err),
+			zap.Time("from", from),
+			zap.Time("to", to))
+		return nil, fmt.Errorf("failed to get revenue summaries: %w", err)
+	}
+
+	products, err := s.analyticsRepo.GetRevenueBreakdown(ctx, from, to, analytics.BreakdownProduct, breakdownLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue by product: %w", err)
+	}
+
+	categories, err := s.analyticsRepo.GetRevenueBreakdown(ctx, from, to, analytics.BreakdownCategory, breakdownLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revenue by category: %w", err)
+	}
+
+	stats, total := s.groupRevenue(summaries, granularity)
+	total.Timestamp = from
+
+	report := &RevenueReport{
+		Stats:      stats,
+		Total:      total,
+		Products:   revenueBreakdown(products, total.Revenue),
+		Categories: revenueBreakdown(categories, total.Revenue),
+		Currency:   s.cfg.RevenueCurrency,
+	}
+
+	s.logger.Info("Revenue stats retrieved",
+		zap.Int("count", len(stats)),
+		zap.String("granularity", granularity),
+		zap.Float64("revenue", total.Revenue),
+	)
+
+	return report, nil
+}
+
+// groupRevenue возвращает бакеты по возрастанию времени и итог за период.
+// Покупатели считаются объединением HLL sketch'ей часов.
+func (s *Service) groupRevenue(summaries []*analytics.RevenueSummary, granularity string) ([]*RevenueStat, *RevenueStat) {
+	stats := make([]*RevenueStat, 0)
+	sketches := make(map[time.Time]*hll.Sketch)
+	total := &RevenueStat{}
+	totalSketch := hll.New()
+
+	var current *RevenueStat
+	for _, summary := range summaries {
+		bucket := truncateToGranularity(summary.Bucket, granularity)
+		if current == nil || !current.Timestamp.Equal(bucket) {
+			current = &RevenueStat{Timestamp: bucket}
+			stats = append(stats, current)
+			sketches[bucket] = hll.New()
+		}
+
+		for _, stat := range []*RevenueStat{current, total} {
+			stat.Revenue += summary.Revenue
+			stat.Orders += summary.Orders
+			stat.Items += summary.Items
+		}
+
+		sketch, err := hll.FromBytes(summary.BuyersSketch)
+		if err != nil {
+			s.logger.Warn("Failed to decode buyers sketch",
+				zap.Error(err),
+				zap.Time("bucket", summary.Bucket),
+			)
+			continue
+		}
+		for _, target := range []*hll.Sketch{sketches[bucket], totalSketch} {
+			if err := target.Merge(sketch); err != nil {
+				s.logger.Warn("Failed to merge buyers sketch",
+					zap.Error(err),
+					zap.Time("bucket", summary.Bucket),
+				)
+			}
+		}
+	}
+
+	for _, stat := range stats {
+		stat.Buyers = sketches[stat.Timestamp].Estimate()
+		stat.setAverages()
+	}
+	total.Buyers = totalSketch.Estimate()
+	total.setAverages()
+
+	return stats, total
+}
+
+func (r *RevenueStat) setAverages() {
+	if r.Orders > 0 {
+		r.AverageOrderValue = r.Revenue / float64(r.Orders)
+	}
+	if r.Buyers > 0 {
+		r.RevenuePerUser = r.Revenue / float64(r.Buyers)
+	}
+}
+
+func revenueBreakdown(rows []*analytics.RevenueBreakdown, totalRevenue float64) []*RevenueBreakdown {
+	breakdown := make([]*RevenueBreakdown, len(rows))
+	for i, row := range rows {
+		breakdown[i] = &RevenueBreakdown{
+			Key:     row.Key,
+			Revenue: row.Revenue,
+			Orders:  row.Orders,
+			Items:   row.Items,
+		}
+		if totalRevenue > 0 {
+			breakdown[i].RevenueShare = row.Revenue / totalRevenue
+		}
+	}
+	return breakdown
+}
+
+// truncateToGranularity повторяет date_trunc Postgres в UTC: неделя с понедельника
+func truncateToGranularity(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	switch granularity {
+	case "minute":
+		return t.Truncate(time.Minute)
+	case "hour":
+		return t.Truncate(time.Hour)
+	case "week":
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
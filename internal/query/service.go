@@ -3,9 +3,11 @@ package query
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/Wuchinator/realtime-analytics/internal/analytics"
+	"github.com/Wuchinator/realtime-analytics/pkg/hll"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -13,78 +15,342 @@ import (
 type EventRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*Event, error)
 	GetByUserID(ctx context.Context, id uuid.UUID, from, to time.Time, limit int) ([]*Event, error)
+	ResolveUserID(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
+	GetFunnelEvents(ctx context.Context, from, to time.Time, eventTypes []string, keyColumn string) ([]*FunnelEvent, error)
 }
 
 type AnalyticsRepository interface {
 	GetSummariesByDateRange(ctx context.Context, from, to time.Time, eventType string) ([]*analytics.Summary, error)
-	GetTopProducts(ctx context.Context, from, to time.Time, limit int) ([]*analytics.ProductStats, error)
+	GetHourSummaries(ctx context.Context, date time.Time, hour int) ([]*analytics.Summary, error)
+	GetTopProducts(ctx context.Context, from, to time.Time, limit int, eventType, rankBy string) ([]*analytics.ProductStats, error)
+	GetRetention(ctx context.Context, q *analytics.RetentionQuery) ([]*analytics.RetentionRow, error)
+	GetCachedRetention(ctx context.Context, q *analytics.RetentionQuery) ([]*analytics.RetentionRow, error)
+	GetSessionStats(ctx context.Context, from, to time.Time, granularity string) ([]*analytics.SessionStats, error)
+	GetRevenueSummaries(ctx context.Context, from, to time.Time) ([]*analytics.RevenueSummary, error)
+	GetRevenueBreakdown(ctx context.Context, from, to time.Time, breakdown string, limit int) ([]*analytics.RevenueBreakdown, error)
+	GetDimensionSummaries(ctx context.Context, from, to time.Time, eventType string, filters analytics.Dimensions) ([]*analytics.DimensionSummary, error)
+	GetStatsBuckets(ctx context.Context, granularity string, from, to time.Time, eventType string) ([]*analytics.StatsBucket, error)
+	GetRollupWatermark(ctx context.Context, granularity string) (time.Time, error)
+}
+
+var granularities = map[string]bool{
+	"hour":  true,
+	"day":   true,
+	"week":  true,
+	"month": true,
+}
+
+// Таблицы, из которых собирается гранулярность GetEventStats, от самой дешёвой.
+// Минутные бакеты хранятся только MINUTE_BUCKET_RETENTION.
+var statsSources = map[string][]string{
+	analytics.GranularityMinute: {analytics.GranularityMinute},
+	analytics.GranularityHour:   {analytics.GranularityHour},
+	analytics.GranularityDay:    {analytics.GranularityDay},
+	analytics.GranularityWeek:   {analytics.GranularityWeek, analytics.GranularityDay},
+	analytics.GranularityMonth:  {analytics.GranularityMonth, analytics.GranularityDay},
+}
+
+// statsSource выбирает первую таблицу из statsSources, бакеты которой не
+// выходят за [from, to]. Даты сравниваются так же, как date в analytics_summary:
+// день, в который попадает to, входит целиком. Незакрытые rollup'ом бакеты
+// statsBuckets собирает из часовых, см. statsBuckets.
+func statsSource(granularity string, from, to, now time.Time) string {
+	sources := statsSources[granularity]
+	for _, source := range sources {
+		if source != analytics.GranularityWeek && source != analytics.GranularityMonth {
+			return source
+		}
+
+		// Неполный первый бакет пришлось бы брать целиком
+		if !truncateToGranularity(from, source).Equal(from) {
+			continue
+		}
+
+		// Последний бакет тоже должен закончиться днём to, если только после to ещё нет данных
+		var next time.Time
+		if source == analytics.GranularityWeek {
+			next = truncateToGranularity(to, source).AddDate(0, 0, 7)
+		} else {
+			next = truncateToGranularity(to, source).AddDate(0, 1, 0)
+		}
+		toDay := truncateToGranularity(to, "day")
+		if toDay.Equal(next.AddDate(0, 0, -1)) || !toDay.Before(truncateToGranularity(now, "day")) {
+			return source
+		}
+	}
+	return sources[len(sources)-1]
+}
+
+type ServiceConfig struct {
+	// Валюта, в которой analytics-service хранит выручку
+	RevenueCurrency string
+	// Измерения, которые агрегирует analytics-service
+	Dimensions []string
 }
 
 type Service struct {
 	eventRepo     EventRepository
 	analyticsRepo AnalyticsRepository
+	hub           *StatsHub
+	cfg           ServiceConfig
 	logger        *zap.Logger
 }
 
 func NewService(
 	eventRepo EventRepository,
 	analyticsRepo AnalyticsRepository,
+	hub *StatsHub,
+	cfg ServiceConfig,
 	logger *zap.Logger) *Service {
 	return &Service{
 		eventRepo:     eventRepo,
 		analyticsRepo: analyticsRepo,
+		hub:           hub,
+		cfg:           cfg,
 		logger:        logger,
 	}
 }
 
+// GetEventStats читает статистику из самой дешёвой таблицы, из которой
+// можно собрать granularity за [from, to], см. statsSource
 func (s *Service) GetEventStats(
 	ctx context.Context,
 	from, to time.Time,
 	eventType string,
 	granularity string,
+	groupBy []string,
+	filters map[string]string,
 ) ([]*EventStat, error) {
-	summaries, err := s.analyticsRepo.GetSummariesByDateRange(ctx, from, to, eventType)
+	if len(groupBy) > 0 || len(filters) > 0 {
+		return s.getDimensionStats(ctx, from, to, eventType, granularity, groupBy, filters)
+	}
+
+	if _, ok := statsSources[granularity]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidGranularity, granularity)
+	}
+
+	source := statsSource(granularity, from, to, time.Now().UTC())
+	buckets, err := s.statsBuckets(ctx, source, from, to, eventType)
 	if err != nil {
-		s.logger.Error("Failed to get summaries",
+		s.logger.Error("Failed to get stats buckets",
 			zap.Error(err),
+			zap.String("source", source),
 			zap.Time("from", from),
 			zap.Time("to", to))
-		return nil, fmt.Errorf("failed to get summaries %w", err)
+		return nil, fmt.Errorf("failed to get stats buckets: %w", err)
 	}
 
-	stats := s.groupByGranularity(summaries, granularity)
+	stats := s.groupBuckets(buckets, granularity)
 
 	s.logger.Info("Event stats retrieved",
 		zap.Int("count", len(stats)),
 		zap.String("granularity", granularity),
+		zap.String("source", source),
 	)
 
 	return stats, nil
 }
 
+// statsBuckets читает бакеты source. Часовые бакеты лежат в analytics_summary
+// с отдельными date и hour, остальные - в таблицах с колонкой bucket.
+// Rollup таблицы отстают от часов на ROLLUP_INTERVAL, поэтому бакеты начиная
+// с того, в который попадает watermark rollup, берутся из часовых бакетов.
+// groupBuckets потом сливает их с бакетами rollup.
+func (s *Service) statsBuckets(
+	ctx context.Context,
+	source string,
+	from, to time.Time,
+	eventType string,
+) ([]*analytics.StatsBucket, error) {
+	if source == analytics.GranularityMinute {
+		return s.analyticsRepo.GetStatsBuckets(ctx, source, from, to, eventType)
+	}
+
+	tail := from
+	buckets := make([]*analytics.StatsBucket, 0)
+	if source != analytics.GranularityHour {
+		watermark, err := s.analyticsRepo.GetRollupWatermark(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		// Нулевой watermark - rollup ещё не запускался, всё берётся из часов
+		open := truncateToGranularity(watermark, source)
+
+		rolledUp, err := s.analyticsRepo.GetStatsBuckets(ctx, source, from, to, eventType)
+		if err != nil {
+			return nil, err
+		}
+		for _, bucket := range rolledUp {
+			if bucket.Bucket.Before(open) {
+				buckets = append(buckets, bucket)
+			}
+		}
+
+		if to.Before(open) {
+			return buckets, nil
+		}
+		if open.After(tail) {
+			tail = open
+		}
+	}
+
+	summaries, err := s.analyticsRepo.GetSummariesByDateRange(ctx, tail, to, eventType)
+	if err != nil {
+		return nil, err
+	}
+	return append(buckets, summaryBuckets(summaries)...), nil
+}
+
+func summaryBuckets(summaries []*analytics.Summary) []*analytics.StatsBucket {
+	buckets := make([]*analytics.StatsBucket, len(summaries))
+	for i, summary := range summaries {
+		buckets[i] = &analytics.StatsBucket{
+			Bucket: time.Date(
+				summary.Date.Year(),
+				summary.Date.Month(),
+				summary.Date.Day(),
+				summary.Hour,
+				0, 0, 0,
+				time.UTC,
+			),
+			EventType:   summary.EventType,
+			TotalEvents: summary.TotalEvents,
+			UniqueUsers: summary.UniqueUsers,
+			UsersSketch: summary.UsersSketch,
+		}
+	}
+	return buckets
+}
+
+// currentBuckets читает текущий минутный или часовой бакет по всем типам событий
+func (s *Service) currentBuckets(ctx context.Context, granularity string, bucket time.Time) ([]*analytics.StatsBucket, error) {
+	if granularity == analytics.GranularityMinute {
+		return s.analyticsRepo.GetStatsBuckets(ctx, granularity, bucket, bucket, "")
+	}
+
+	summaries, err := s.analyticsRepo.GetHourSummaries(ctx, bucket.Truncate(24*time.Hour), bucket.Hour())
+	if err != nil {
+		return nil, err
+	}
+	return summaryBuckets(summaries), nil
+}
+
+// SubscribeEventStats отправляет через send снапшот текущего бакета granularity
+// (minute или hour), а затем обновления бакетов этой гранулярности. Обновления
+// одного бакета склеиваются и уходят не чаще minInterval.
+func (s *Service) SubscribeEventStats(
+	ctx context.Context,
+	granularity string,
+	eventTypes []string,
+	minInterval time.Duration,
+	send func(stat *EventStat) error,
+) error {
+	var unit time.Duration
+	switch granularity {
+	case analytics.GranularityMinute:
+		unit = time.Minute
+	case analytics.GranularityHour:
+		unit = time.Hour
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidGranularity, granularity)
+	}
+
+	// Подписываемся до снапшота, чтобы не потерять обновления между ними
+	updates, unsubscribe := s.hub.Subscribe()
+	defer unsubscribe()
+
+	filter := make(map[string]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		filter[eventType] = true
+	}
+	matches := func(stat *EventStat) bool {
+		return len(filter) == 0 || filter[stat.EventType]
+	}
+
+	current := time.Now().UTC().Truncate(unit)
+
+	snapshot, err := s.currentBuckets(ctx, granularity, current)
+	if err != nil {
+		return err
+	}
+	for _, bucket := range snapshot {
+		stat := &EventStat{
+			Timestamp:   bucket.Bucket.UTC(),
+			EventType:   bucket.EventType,
+			TotalEvents: bucket.TotalEvents,
+			UniqueUsers: bucket.UniqueUsers,
+		}
+		if matches(stat) {
+			if err := send(stat); err != nil {
+				return err
+			}
+		}
+	}
+
+	ticker := time.NewTicker(minInterval)
+	defer ticker.Stop()
+
+	pending := make(map[string]*EventStat)
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if update.granularity == granularity && matches(update.stat) {
+				pending[update.stat.Timestamp.Format(time.RFC3339)+"-"+update.stat.EventType] = update.stat
+			}
+		case <-ticker.C:
+			for key, stat := range pending {
+				if err := send(stat); err != nil {
+					return err
+				}
+				delete(pending, key)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// GetUserActivity принимает user_id или анонимный id и возвращает события
+// канонического пользователя, включая события до логина
 func (s *Service) GetUserActivity(
 	ctx context.Context,
 	userID uuid.UUID,
 	from, to time.Time,
 	limit int,
-) ([]*Event, int64, error) {
-	events, err := s.eventRepo.GetByUserID(ctx, userID, from, to, limit)
+) (*UserActivity, error) {
+	canonicalID, err := s.eventRepo.ResolveUserID(ctx, userID)
 	if err != nil {
-		s.logger.Error("Failed to get user activity",
+		s.logger.Error("Failed to resolve user",
 			zap.Error(err),
 			zap.String("user_id", userID.String()),
 		)
-		return nil, 0, fmt.Errorf("failed to get user activity: %w", err)
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
+	}
+
+	events, err := s.eventRepo.GetByUserID(ctx, canonicalID, from, to, limit)
+	if err != nil {
+		s.logger.Error("Failed to get user activity",
+			zap.Error(err),
+			zap.String("user_id", canonicalID.String()),
+		)
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
 	}
 
-	totalCount := int64(len(events))
+	activity := &UserActivity{
+		UserID:      canonicalID,
+		Events:      events,
+		TotalEvents: int64(len(events)),
+	}
 
 	s.logger.Info("User activity retrieved",
-		zap.String("user_id", userID.String()),
-		zap.Int64("events_count", totalCount),
+		zap.String("user_id", canonicalID.String()),
+		zap.Int64("events_count", activity.TotalEvents),
 	)
 
-	return events, totalCount, nil
+	return activity, nil
 }
 
 func (s *Service) GetTopProducts(
@@ -92,8 +358,9 @@ func (s *Service) GetTopProducts(
 	from, to time.Time,
 	limit int,
 	eventType string,
+	rankBy string,
 ) ([]*ProductStat, error) {
-	products, err := s.analyticsRepo.GetTopProducts(ctx, from, to, limit)
+	products, err := s.analyticsRepo.GetTopProducts(ctx, from, to, limit, eventType, rankBy)
 	if err != nil {
 		s.logger.Error("Failed to get top products",
 			zap.Error(err),
@@ -108,69 +375,235 @@ func (s *Service) GetTopProducts(
 			EventCount:     p.EventCount,
 			UniqueUsers:    p.UniqueUsers,
 			ConversionRate: p.ConversionRate,
+			Views:          p.Views,
+			AddToCarts:     p.AddToCarts,
+			Purchases:      p.Purchases,
+			AddToCartRate:  p.AddToCartRate,
+			Revenue:        p.Revenue,
 		}
 	}
 
 	s.logger.Info("Top products retrieved",
 		zap.Int("count", len(stats)),
+		zap.String("event_type", eventType),
+		zap.String("rank_by", rankBy),
 	)
 
 	return stats, nil
 }
 
-func (s *Service) groupByGranularity(summaries []*analytics.Summary, granularity string) []*EventStat {
-	grouped := make(map[string]*EventStat)
+func (s *Service) GetFunnel(
+	ctx context.Context,
+	from, to time.Time,
+	steps []string,
+	window time.Duration,
+	countBy string,
+) ([]*FunnelStep, error) {
+	if len(steps) < 2 {
+		return nil, ErrInvalidFunnelSteps
+	}
 
-	for _, summary := range summaries {
-		var key string
-		var timestamp time.Time
+	keyColumn, ok := funnelKeyColumns[countBy]
+	if !ok {
+		return nil, ErrInvalidCountBy
+	}
 
-		switch granularity {
-		case "hour":
-			timestamp = time.Date(
-				summary.Date.Year(),
-				summary.Date.Month(),
-				summary.Date.Day(),
-				summary.Hour,
-				0, 0, 0,
-				time.UTC,
+	// Последние шаги могут случиться уже после to, но в пределах окна
+	events, err := s.eventRepo.GetFunnelEvents(ctx, from, to.Add(window), steps, keyColumn)
+	if err != nil {
+		s.logger.Error("Failed to get funnel events",
+			zap.Error(err),
+			zap.Strings("steps", steps),
+		)
+		return nil, fmt.Errorf("failed to get funnel events: %w", err)
+	}
+
+	funnel := buildFunnel(events, steps, from, to, window)
+
+	s.logger.Info("Funnel calculated",
+		zap.Strings("steps", steps),
+		zap.String("count_by", countBy),
+		zap.Int("events", len(events)),
+	)
+
+	return funnel, nil
+}
+
+// GetRetention строит матрицу retention. С useCache сначала читает таблицу,
+// которую обновляет analytics-service, и считает по events, если кеш пуст или
+// последний пересчёт не покрыл начало диапазона или все запрошенные периоды.
+func (s *Service) GetRetention(
+	ctx context.Context,
+	q *analytics.RetentionQuery,
+	useCache bool,
+) ([]*RetentionCohort, bool, error) {
+	var (
+		rows      []*analytics.RetentionRow
+		err       error
+		fromCache bool
+	)
+
+	if useCache {
+		rows, err = s.analyticsRepo.GetCachedRetention(ctx, q)
+		if err != nil {
+			s.logger.Warn("Failed to read retention cache, falling back to events",
+				zap.Error(err),
 			)
-			key = fmt.Sprintf("%s-%s", timestamp.Format("2006-01-02-15"), summary.EventType)
-		case "day":
-			timestamp = summary.Date
-			key = fmt.Sprintf("%s-%s", timestamp.Format("2006-01-02"), summary.EventType)
-		default:
-			timestamp = time.Date(
-				summary.Date.Year(),
-				summary.Date.Month(),
-				summary.Date.Day(),
-				summary.Hour,
-				0, 0, 0,
-				time.UTC,
+		}
+		fromCache = err == nil && len(rows) > 0
+	}
+
+	if !fromCache {
+		rows, err = s.analyticsRepo.GetRetention(ctx, q)
+		if err != nil {
+			s.logger.Error("Failed to get retention",
+				zap.Error(err),
+				zap.String("period", q.Period),
 			)
-			key = fmt.Sprintf("%s-%s", timestamp.Format("2006-01-02-15"), summary.EventType)
+			return nil, false, fmt.Errorf("failed to get retention: %w", err)
 		}
+	}
+
+	cohorts := buildRetentionMatrix(rows, q.Periods)
+
+	s.logger.Info("Retention retrieved",
+		zap.Int("cohorts", len(cohorts)),
+		zap.String("period", q.Period),
+		zap.Bool("from_cache", fromCache),
+	)
+
+	return cohorts, fromCache, nil
+}
+
+// buildRetentionMatrix раскладывает ячейки по cohorts, rows отсортированы по cohort_start
+func buildRetentionMatrix(rows []*analytics.RetentionRow, periods int) []*RetentionCohort {
+	cohorts := make([]*RetentionCohort, 0)
+
+	var current *RetentionCohort
+	for _, row := range rows {
+		if current == nil || !current.CohortStart.Equal(row.CohortStart) {
+			current = &RetentionCohort{
+				CohortStart:    row.CohortStart,
+				Size:           row.CohortSize,
+				Retained:       make([]int64, periods+1),
+				RetentionRates: make([]float64, periods+1),
+			}
+			cohorts = append(cohorts, current)
+		}
+
+		if row.PeriodNumber < 0 || row.PeriodNumber > periods {
+			continue
+		}
+
+		current.Retained[row.PeriodNumber] = row.Users
+		if current.Size > 0 {
+			current.RetentionRates[row.PeriodNumber] = float64(row.Users) / float64(current.Size)
+		}
+	}
+
+	return cohorts
+}
+
+func (s *Service) GetSessionStats(
+	ctx context.Context,
+	from, to time.Time,
+	granularity string,
+) ([]*SessionStat, error) {
+	if !granularities[granularity] {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidGranularity, granularity)
+	}
+
+	rows, err := s.analyticsRepo.GetSessionStats(ctx, from, to, granularity)
+	if err != nil {
+		s.logger.Error("Failed to get session stats",
+			zap.Error(err),
+			zap.Time("from", from),
+			zap.Time("to", to))
+		return nil, fmt.Errorf("failed to get session stats: %w", err)
+	}
+
+	stats := make([]*SessionStat, len(rows))
+	for i, row := range rows {
+		stats[i] = &SessionStat{
+			Timestamp:           row.Bucket,
+			Sessions:            row.Sessions,
+			Users:               row.Users,
+			AvgDuration:         time.Duration(row.AvgDurationSeconds * float64(time.Second)),
+			BounceRate:          row.BounceRate,
+			ConversionRate:      row.ConversionRate,
+			AvgEventsPerSession: row.AvgEvents,
+		}
+		if row.Users > 0 {
+			stats[i].SessionsPerUser = float64(row.Sessions) / float64(row.Users)
+		}
+	}
+
+	s.logger.Info("Session stats retrieved",
+		zap.Int("count", len(stats)),
+		zap.String("granularity", granularity),
+	)
+
+	return stats, nil
+}
+
+// groupBuckets сворачивает бакеты источника в granularity. Уникальные
+// пользователи считаются через объединение HLL sketch'ей, а не суммой или
+// максимумом по бакетам.
+func (s *Service) groupBuckets(buckets []*analytics.StatsBucket, granularity string) []*EventStat {
+	grouped := make(map[string]*EventStat)
+	sketches := make(map[string]*hll.Sketch)
+
+	for _, bucket := range buckets {
+		timestamp := truncateToGranularity(bucket.Bucket, granularity)
+		key := fmt.Sprintf("%s-%s", timestamp.Format(time.RFC3339), bucket.EventType)
 
 		if stat, exists := grouped[key]; exists {
-			stat.TotalEvents += summary.TotalEvents
-			if summary.UniqueUsers > stat.UniqueUsers {
-				stat.UniqueUsers = summary.UniqueUsers
+			stat.TotalEvents += bucket.TotalEvents
+			// Для бакетов без sketch (записанных до HLL) остаётся максимум
+			if bucket.UniqueUsers > stat.UniqueUsers {
+				stat.UniqueUsers = bucket.UniqueUsers
 			}
 		} else {
 			grouped[key] = &EventStat{
 				Timestamp:   timestamp,
-				EventType:   summary.EventType,
-				TotalEvents: summary.TotalEvents,
-				UniqueUsers: summary.UniqueUsers,
+				EventType:   bucket.EventType,
+				TotalEvents: bucket.TotalEvents,
+				UniqueUsers: bucket.UniqueUsers,
 			}
+			sketches[key] = hll.New()
+		}
+
+		sketch, err := hll.FromBytes(bucket.UsersSketch)
+		if err != nil {
+			s.logger.Warn("Failed to decode users sketch",
+				zap.Error(err),
+				zap.String("key", key),
+			)
+			continue
+		}
+		if err := sketches[key].Merge(sketch); err != nil {
+			s.logger.Warn("Failed to merge users sketch",
+				zap.Error(err),
+				zap.String("key", key),
+			)
 		}
 	}
 
 	stats := make([]*EventStat, 0, len(grouped))
-	for _, stat := range grouped {
+	for key, stat := range grouped {
+		if estimate := sketches[key].Estimate(); estimate > stat.UniqueUsers {
+			stat.UniqueUsers = estimate
+		}
 		stats = append(stats, stat)
 	}
 
+	sort.Slice(stats, func(i, j int) bool {
+		if !stats[i].Timestamp.Equal(stats[j].Timestamp) {
+			return stats[i].Timestamp.Before(stats[j].Timestamp)
+		}
+		return stats[i].EventType < stats[j].EventType
+	})
+
 	return stats
 }
 
@@ -0,0 +1,120 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/internal/analytics"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// statsUpdate - новое значение минутного или часового бакета
+type statsUpdate struct {
+	granularity string
+	stat        *EventStat
+}
+
+// StatsHub раздаёт обновления бакетов из LISTEN/NOTIFY всем подписчикам
+type StatsHub struct {
+	mu          sync.Mutex
+	subscribers map[chan *statsUpdate]struct{}
+	logger      *zap.Logger
+}
+
+func NewStatsHub(logger *zap.Logger) *StatsHub {
+	return &StatsHub{
+		subscribers: make(map[chan *statsUpdate]struct{}),
+		logger:      logger,
+	}
+}
+
+// Run читает уведомления до отмены ctx, после чего закрывает каналы подписчиков
+func (h *StatsHub) Run(ctx context.Context, notifications <-chan *pq.Notification) {
+	defer h.closeAll()
+
+	for {
+		select {
+		case n := <-notifications:
+			// nil приходит после переподключения listener'а
+			if n == nil {
+				h.logger.Warn("Stats listener reconnected, some updates may be missed")
+				continue
+			}
+			h.publish(n.Extra)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Subscribe возвращает канал обновлений и функцию отписки
+func (h *StatsHub) Subscribe() (<-chan *statsUpdate, func()) {
+	ch := make(chan *statsUpdate, 256)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subscribers[ch]; ok {
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *StatsHub) publish(payload string) {
+	var n analytics.StatsNotification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		h.logger.Error("Failed to unmarshal stats notification", zap.Error(err))
+		return
+	}
+
+	update := &statsUpdate{
+		granularity: n.Granularity,
+		stat: &EventStat{
+			Timestamp:   n.Bucket.UTC(),
+			EventType:   n.EventType,
+			TotalEvents: n.TotalEvents,
+			UniqueUsers: n.UniqueUsers,
+		},
+	}
+	if update.granularity != analytics.GranularityMinute {
+		update.granularity = analytics.GranularityHour
+		update.stat.Timestamp = time.Date(
+			n.Date.Year(),
+			n.Date.Month(),
+			n.Date.Day(),
+			n.Hour,
+			0, 0, 0,
+			time.UTC,
+		)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		// Медленный подписчик не должен тормозить остальных: значения
+		// абсолютные, следующее обновление бакета перекроет пропущенное
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
+
+func (h *StatsHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
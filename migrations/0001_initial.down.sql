@@ -0,0 +1,8 @@
+-- Удаляет исходную схему вместе с данными. Расширения и роль readonly общие
+-- для кластера и остаются.
+
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS analytics_summary;
+DROP TABLE IF EXISTS processed_offsets;
+
+ALTER DEFAULT PRIVILEGES IN SCHEMA public REVOKE SELECT ON TABLES FROM readonly;
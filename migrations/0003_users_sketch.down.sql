@@ -0,0 +1 @@
+ALTER TABLE analytics_summary DROP COLUMN IF EXISTS users_sketch;
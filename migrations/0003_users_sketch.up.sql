@@ -0,0 +1,2 @@
+-- HyperLogLog sketch уникальных пользователей часового бакета
+ALTER TABLE analytics_summary ADD COLUMN IF NOT EXISTS users_sketch BYTEA;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS retention_cohorts (
+    period VARCHAR(10) NOT NULL,
+    cohort_event_type VARCHAR(50) NOT NULL DEFAULT '',
+    return_event_type VARCHAR(50) NOT NULL DEFAULT '',
+    cohort_start DATE NOT NULL,
+    period_number INTEGER NOT NULL,
+    cohort_size BIGINT NOT NULL,
+    users BIGINT NOT NULL,
+    refreshed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (period, cohort_event_type, return_event_type, cohort_start, period_number)
+);
@@ -0,0 +1,2 @@
+-- Trace context запроса, в котором событие было принято
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS trace_context JSONB;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS event_schemas (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    fields JSONB NOT NULL DEFAULT '[]',
+    version INTEGER NOT NULL DEFAULT 1,
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO event_schemas (name, description, built_in) VALUES
+    ('page_view', 'Page view', TRUE),
+    ('product_view', 'Product page view', TRUE),
+    ('add_to_cart', 'Product added to cart', TRUE),
+    ('remove_from_cart', 'Product removed from cart', TRUE),
+    ('purchase', 'Completed order', TRUE),
+    ('search', 'Product search', TRUE),
+    ('identify', 'Segment identify call', TRUE)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS revenue_summary;
+DROP TABLE IF EXISTS revenue_items;
+DROP TABLE IF EXISTS currency_rates;
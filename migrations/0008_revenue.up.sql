@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS revenue_summary (
+    bucket TIMESTAMP WITH TIME ZONE PRIMARY KEY,
+    revenue NUMERIC(20, 4) NOT NULL DEFAULT 0,
+    orders BIGINT NOT NULL DEFAULT 0,
+    items BIGINT NOT NULL DEFAULT 0,
+    buyers BIGINT NOT NULL DEFAULT 0,
+    buyers_sketch BYTEA,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS revenue_items (
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    product_id VARCHAR(255) NOT NULL DEFAULT '',
+    category VARCHAR(255) NOT NULL DEFAULT '',
+    revenue NUMERIC(20, 4) NOT NULL DEFAULT 0,
+    orders BIGINT NOT NULL DEFAULT 0,
+    items BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (bucket, product_id, category)
+);
+
+-- Цена единицы валюты в опорной валюте (USD). Базовая валюта выручки
+-- задаётся в REVENUE_BASE_CURRENCY и тоже должна быть в таблице.
+CREATE TABLE IF NOT EXISTS currency_rates (
+    currency VARCHAR(3) PRIMARY KEY,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO currency_rates (currency, rate) VALUES
+    ('USD', 1),
+    ('EUR', 1.08),
+    ('GBP', 1.27),
+    ('RUB', 0.011)
+ON CONFLICT (currency) DO NOTHING;
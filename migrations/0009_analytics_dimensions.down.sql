@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS analytics_dimensions;
+DROP TABLE IF EXISTS dimension_values;
@@ -0,0 +1,23 @@
+-- Те же счётчики, что в analytics_summary, в разрезе настроенных измерений
+-- (ANALYTICS_DIMENSIONS). dimensions - объект {"country": "DE", ...}
+CREATE TABLE IF NOT EXISTS analytics_dimensions (
+    date DATE NOT NULL,
+    hour INTEGER NOT NULL CHECK (hour >= 0 AND hour <= 23),
+    event_type VARCHAR(50) NOT NULL,
+    dimensions JSONB NOT NULL DEFAULT '{}',
+    total_events BIGINT NOT NULL DEFAULT 0,
+    unique_users BIGINT NOT NULL DEFAULT 0,
+    users_sketch BYTEA,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (date, hour, event_type, dimensions)
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_dimensions_gin ON analytics_dimensions USING GIN(dimensions);
+
+-- Известные значения измерений для лимитов кардинальности
+CREATE TABLE IF NOT EXISTS dimension_values (
+    dimension VARCHAR(100) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (dimension, value)
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS rollup_state;
+DROP TABLE IF EXISTS analytics_monthly;
+DROP TABLE IF EXISTS analytics_weekly;
+DROP TABLE IF EXISTS analytics_daily;
+DROP TABLE IF EXISTS analytics_minute;
+DROP INDEX IF EXISTS idx_analytics_updated_at;
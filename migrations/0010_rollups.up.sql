@@ -0,0 +1,51 @@
+-- По updated_at rollup находит изменившиеся часы
+CREATE INDEX IF NOT EXISTS idx_analytics_updated_at ON analytics_summary(updated_at);
+
+-- Минутные бакеты для графиков почти в реальном времени, хранятся MINUTE_BUCKET_RETENTION
+CREATE TABLE IF NOT EXISTS analytics_minute (
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    total_events BIGINT NOT NULL DEFAULT 0,
+    unique_users BIGINT NOT NULL DEFAULT 0,
+    users_sketch BYTEA,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (bucket, event_type)
+);
+
+-- Rollup часовых бакетов analytics_summary, их пересобирает analytics-service.
+-- bucket - первый день периода, неделя начинается с понедельника.
+CREATE TABLE IF NOT EXISTS analytics_daily (
+    bucket DATE NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    total_events BIGINT NOT NULL DEFAULT 0,
+    unique_users BIGINT NOT NULL DEFAULT 0,
+    users_sketch BYTEA,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (bucket, event_type)
+);
+
+CREATE TABLE IF NOT EXISTS analytics_weekly (
+    bucket DATE NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    total_events BIGINT NOT NULL DEFAULT 0,
+    unique_users BIGINT NOT NULL DEFAULT 0,
+    users_sketch BYTEA,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (bucket, event_type)
+);
+
+CREATE TABLE IF NOT EXISTS analytics_monthly (
+    bucket DATE NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    total_events BIGINT NOT NULL DEFAULT 0,
+    unique_users BIGINT NOT NULL DEFAULT 0,
+    users_sketch BYTEA,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (bucket, event_type)
+);
+
+-- До какого updated_at часы уже учтены в rollup каждой гранулярности
+CREATE TABLE IF NOT EXISTS rollup_state (
+    granularity VARCHAR(10) PRIMARY KEY,
+    rolled_up_to TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS resolved_events;
+DROP TABLE IF EXISTS identity_links;
+ALTER TABLE events DROP COLUMN IF EXISTS anonymous_id;
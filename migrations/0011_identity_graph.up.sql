@@ -0,0 +1,29 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS anonymous_id VARCHAR(255);
+
+-- Identity graph: anonymous_id посетителя -> user_id после логина.
+-- anonymous_user_id - user_id, под которым лежат анонимные события.
+CREATE TABLE IF NOT EXISTS identity_links (
+    anonymous_id VARCHAR(255) PRIMARY KEY,
+    anonymous_user_id UUID NOT NULL UNIQUE,
+    user_id UUID NOT NULL,
+    linked_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_identity_links_user_id ON identity_links(user_id);
+
+-- События с каноническим user_id: анонимные события связанных посетителей
+-- получают user_id после логина, исходный остаётся в raw_user_id
+CREATE OR REPLACE VIEW resolved_events AS
+    SELECT
+        e.id,
+        e.event_type,
+        COALESCE(l.user_id, e.user_id) AS user_id,
+        e.user_id AS raw_user_id,
+        e.anonymous_id,
+        e.session_id,
+        e.product_id,
+        e.data,
+        e.created_at,
+        e.processed_at
+    FROM events e
+    LEFT JOIN identity_links l ON l.anonymous_user_id = e.user_id;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_data_requests;
+ALTER TABLE outbox DROP COLUMN IF EXISTS tombstone;
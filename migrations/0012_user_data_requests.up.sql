@@ -0,0 +1,18 @@
+-- В Kafka уходит сообщение с ключом message_key и пустым value, payload не отправляется
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS tombstone BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Журнал запросов на удаление и выгрузку данных пользователя (GDPR).
+-- id - ключ идемпотентности из запроса.
+CREATE TABLE IF NOT EXISTS user_data_requests (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('delete', 'export')),
+    user_id UUID NOT NULL,
+    requested_by VARCHAR(255) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    events_affected BIGINT NOT NULL DEFAULT 0,
+    identities_affected BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_data_requests_user_id ON user_data_requests(user_id);
@@ -0,0 +1,35 @@
+-- Возвращает events обычной таблицей с primary key (id). Строки отключённых
+-- (detached) партиций в неё не попадают. Партиционированная events допускает
+-- один id с разным created_at, из таких строк остаётся самая ранняя.
+
+DROP VIEW IF EXISTS resolved_events;
+
+CREATE TABLE events_unpartitioned (LIKE events INCLUDING DEFAULTS);
+ALTER TABLE events_unpartitioned ALTER COLUMN created_at DROP NOT NULL;
+INSERT INTO events_unpartitioned
+SELECT DISTINCT ON (id) * FROM events ORDER BY id, created_at;
+
+DROP TABLE events;
+ALTER TABLE events_unpartitioned RENAME TO events;
+ALTER TABLE events ADD CONSTRAINT events_pkey PRIMARY KEY (id);
+
+CREATE INDEX IF NOT EXISTS idx_events_user_id ON events(user_id);
+CREATE INDEX IF NOT EXISTS idx_events_event_type ON events(event_type);
+CREATE INDEX IF NOT EXISTS idx_events_created_at ON events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_events_processed_at ON events(processed_at) WHERE processed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_events_data_gin ON events USING GIN(data);
+
+CREATE OR REPLACE VIEW resolved_events AS
+    SELECT
+        e.id,
+        e.event_type,
+        COALESCE(l.user_id, e.user_id) AS user_id,
+        e.user_id AS raw_user_id,
+        e.anonymous_id,
+        e.session_id,
+        e.product_id,
+        e.data,
+        e.created_at,
+        e.processed_at
+    FROM events e
+    LEFT JOIN identity_links l ON l.anonymous_user_id = e.user_id;
@@ -0,0 +1,72 @@
+-- events становится таблицей, партиционированной по created_at. Партиции
+-- (events_pYYYYMMDD или events_pYYYYMM) создаёт и удаляет по retention
+-- event-service, см. EVENTS_PARTITION_INTERVAL. Ключ партиционирования
+-- обязан входить в primary key.
+--
+-- Старые строки копируются в events_default в этой же транзакции, на большой
+-- таблице это долгая блокировка. В партиции их переносит event-service, когда
+-- создаёт партицию их диапазона; более старые остаются в events_default,
+-- пока их не удалит retention.
+
+DROP VIEW IF EXISTS resolved_events;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_class WHERE oid = 'events'::regclass AND relkind = 'r') THEN
+        ALTER TABLE events RENAME TO events_unpartitioned;
+        -- Имя индекса events_pkey нужно primary key новой таблицы
+        ALTER TABLE events_unpartitioned DROP CONSTRAINT events_pkey;
+    END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS events (
+    id UUID NOT NULL DEFAULT uuid_generate_v4(),
+    event_type VARCHAR(50) NOT NULL,
+    user_id UUID NOT NULL,
+    anonymous_id VARCHAR(255),
+    session_id UUID NOT NULL,
+    product_id UUID,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- События вне созданных партиций. При создании партиции event-service
+-- переносит отсюда строки её диапазона.
+CREATE TABLE IF NOT EXISTS events_default PARTITION OF events DEFAULT;
+
+DO $$
+BEGIN
+    IF to_regclass('events_unpartitioned') IS NOT NULL THEN
+        INSERT INTO events (id, event_type, user_id, anonymous_id, session_id, product_id, data, created_at, processed_at)
+        SELECT id, event_type, user_id, anonymous_id, session_id, product_id, data, COALESCE(created_at, NOW()), processed_at
+        FROM events_unpartitioned;
+
+        -- Вместе с таблицей уходят её индексы, имена освобождаются для новых
+        DROP TABLE events_unpartitioned;
+    END IF;
+END
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_events_user_id ON events(user_id);
+CREATE INDEX IF NOT EXISTS idx_events_event_type ON events(event_type);
+CREATE INDEX IF NOT EXISTS idx_events_created_at ON events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_events_processed_at ON events(processed_at) WHERE processed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_events_data_gin ON events USING GIN(data);
+
+CREATE OR REPLACE VIEW resolved_events AS
+    SELECT
+        e.id,
+        e.event_type,
+        COALESCE(l.user_id, e.user_id) AS user_id,
+        e.user_id AS raw_user_id,
+        e.anonymous_id,
+        e.session_id,
+        e.product_id,
+        e.data,
+        e.created_at,
+        e.processed_at
+    FROM events e
+    LEFT JOIN identity_links l ON l.anonymous_user_id = e.user_id;
@@ -0,0 +1,15 @@
+-- Манифест холодного хранилища: какие партиции events выгружены и куда.
+-- Строка появляется только после успешной загрузки файла.
+CREATE TABLE IF NOT EXISTS event_archives (
+    partition_name VARCHAR(63) PRIMARY KEY,
+    range_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    range_to TIMESTAMP WITH TIME ZONE NOT NULL,
+    object_key TEXT NOT NULL,
+    format VARCHAR(20) NOT NULL,
+    events BIGINT NOT NULL,
+    bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_archives_range ON event_archives(range_from, range_to);
@@ -0,0 +1,13 @@
+-- Какие cohorts покрывает последний пересчёт кеша retention. Кеш отдаётся
+-- только для запросов, которые целиком попадают в покрытый диапазон.
+CREATE TABLE IF NOT EXISTS retention_refreshes (
+    period VARCHAR(10) NOT NULL,
+    cohort_event_type VARCHAR(50) NOT NULL DEFAULT '',
+    return_event_type VARCHAR(50) NOT NULL DEFAULT '',
+    covered_from DATE NOT NULL,
+    refreshed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (period, cohort_event_type, return_event_type)
+);
+
+-- Старые строки кеша могли пересчитываться по обрезанной cohort
+TRUNCATE retention_cohorts;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_outbox_pending_key;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS claimed_until;
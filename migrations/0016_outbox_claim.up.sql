@@ -0,0 +1,6 @@
+-- Relay забирает пачку коротким UPDATE и отправляет её в Kafka без открытой
+-- транзакции. До claimed_until сообщение не достанется другому инстансу.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMP WITH TIME ZONE;
+
+-- Поиск более ранних неотправленных сообщений того же ключа
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_key ON outbox(message_key, id) WHERE sent_at IS NULL;
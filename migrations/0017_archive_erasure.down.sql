@@ -0,0 +1,3 @@
+ALTER TABLE event_archives DROP COLUMN IF EXISTS purged_at;
+
+ALTER TABLE user_data_requests DROP COLUMN IF EXISTS erased_user_ids;
@@ -0,0 +1,10 @@
+-- Все id, события которых удалил запрос: пользователь и связанные анонимные id.
+-- После удаления identity_links их больше неоткуда взять, а по ним чистятся архивы.
+ALTER TABLE user_data_requests ADD COLUMN IF NOT EXISTS erased_user_ids UUID[] NOT NULL DEFAULT '{}';
+
+UPDATE user_data_requests
+SET erased_user_ids = ARRAY[user_id]
+WHERE kind = 'delete' AND cardinality(erased_user_ids) = 0;
+
+-- Когда из архива последний раз вычищены удалённые пользователи
+ALTER TABLE event_archives ADD COLUMN IF NOT EXISTS purged_at TIMESTAMP WITH TIME ZONE;
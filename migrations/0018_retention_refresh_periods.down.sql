@@ -0,0 +1 @@
+ALTER TABLE retention_refreshes DROP COLUMN IF EXISTS periods;
@@ -0,0 +1,3 @@
+-- Сколько периодов посчитано для каждой cohort. 0 у старых строк: кеш не
+-- используется, пока его не пересчитают.
+ALTER TABLE retention_refreshes ADD COLUMN IF NOT EXISTS periods INTEGER NOT NULL DEFAULT 0;
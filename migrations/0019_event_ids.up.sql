@@ -0,0 +1,16 @@
+-- Primary key партиционированной events включает created_at, поэтому повтор
+-- события с тем же id, но другим временем, он не ловит. Уникальность id
+-- держит эта таблица: event-service вставляет в неё id в той же транзакции,
+-- что и событие. created_at - время события, по нему id удаляются вместе с
+-- просроченными партициями. Удаление пользователя id не трогает: повтор его
+-- события остаётся дубликатом.
+CREATE TABLE IF NOT EXISTS event_ids (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_ids_created_at ON event_ids(created_at);
+
+INSERT INTO event_ids (id, created_at)
+SELECT id, min(created_at) FROM events GROUP BY id
+ON CONFLICT (id) DO NOTHING;
@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// FS - SQL миграции схемы, встроенные в бинарники сервисов и cmd/migrate.
+// Файлы называются NNNN_name.up.sql и NNNN_name.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS
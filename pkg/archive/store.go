@@ -0,0 +1,54 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Бэкенды хранилища архивов
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+var ErrNotFound = errors.New("archive object not found")
+
+// Store - хранилище архивных файлов. Ключи - пути через "/".
+type Store interface {
+	// Put записывает объект целиком. size - длина r в байтах.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get возвращает ErrNotFound, если объекта нет
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete не считает ошибкой отсутствие объекта
+	Delete(ctx context.Context, key string) error
+}
+
+type Config struct {
+	// BackendLocal или BackendS3
+	Backend string
+	// Директория для BackendLocal
+	Dir string
+	S3  S3Config
+}
+
+type S3Config struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+}
+
+func NewStore(ctx context.Context, cfg Config) (Store, error) {
+	switch cfg.Backend {
+	case BackendLocal:
+		return NewLocalStore(cfg.Dir)
+	case BackendS3:
+		return NewS3Store(ctx, cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown archive backend %q", cfg.Backend)
+	}
+}
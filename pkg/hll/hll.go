@@ -0,0 +1,63 @@
+package hll
+
+import (
+	"fmt"
+
+	"github.com/axiomhq/hyperloglog"
+)
+
+// Sketch - HyperLogLog для подсчёта уникальных значений.
+// Пока значений мало, sketch хранится в sparse-виде и считает практически точно,
+// дальше переходит в dense (2^14 регистров, погрешность ~0.8%).
+type Sketch struct {
+	sk *hyperloglog.Sketch
+}
+
+func New() *Sketch {
+	return &Sketch{sk: hyperloglog.New14()}
+}
+
+// FromBytes восстанавливает sketch из БД, пустой срез даёт пустой sketch
+func FromBytes(data []byte) (*Sketch, error) {
+	s := New()
+	if len(data) == 0 {
+		return s, nil
+	}
+
+	if err := s.sk.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal sketch: %w", err)
+	}
+	return s, nil
+}
+
+func (s *Sketch) Add(value string) {
+	s.sk.Insert([]byte(value))
+}
+
+func (s *Sketch) Merge(other *Sketch) error {
+	if other == nil {
+		return nil
+	}
+	return s.sk.Merge(other.sk)
+}
+
+func (s *Sketch) Estimate() int64 {
+	return int64(s.sk.Estimate())
+}
+
+func (s *Sketch) Bytes() ([]byte, error) {
+	data, err := s.sk.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sketch: %w", err)
+	}
+	return data, nil
+}
+
+// MayContain сообщает, мог ли value попасть в sketch. Если value уже добавлен,
+// повторное добавление не меняет оценку, поэтому ложного false не бывает.
+// Ложный true возможен при совпадении регистров с другими значениями.
+func (s *Sketch) MayContain(value string) bool {
+	clone := s.sk.Clone()
+	clone.Insert([]byte(value))
+	return clone.Estimate() == s.sk.Estimate()
+}
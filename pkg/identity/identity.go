@@ -0,0 +1,19 @@
+package identity
+
+import "github.com/google/uuid"
+
+// Namespace для UUIDv5 из строковых id. Совпадает с namespace Segment API,
+// поэтому anonymousId из Segment и anonymous_id из gRPC дают один user_id.
+var Namespace = uuid.MustParse("0b6c9e2a-5f0e-4c41-9d3b-6a1f2f1f8a77")
+
+// MaxAnonymousIDLength - размер колонки anonymous_id
+const MaxAnonymousIDLength = 255
+
+// AnonymousUserID - user_id, под которым сохраняются события анонимного
+// посетителя до Identify. UUID остаётся как есть, остальные строки переводятся в UUIDv5.
+func AnonymousUserID(anonymousID string) uuid.UUID {
+	if parsed, err := uuid.Parse(anonymousID); err == nil {
+		return parsed
+	}
+	return uuid.NewSHA1(Namespace, []byte(anonymousID))
+}
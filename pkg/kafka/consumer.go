@@ -2,22 +2,57 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 )
 
-type MessageHandler func(ctx context.Context, key, value []byte) error
+type Message struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Timestamp time.Time
+}
+
+type MessageHandler func(ctx context.Context, msg *Message) error
+
+// Предельная пауза перед завершением claim, если сообщение не удаётся ни
+// обработать, ни отправить в DLQ
+const maxStallBackoff = time.Minute
+
+var ErrDeadLetterRequired = errors.New("dead letter topic is required when offset store is set")
+
+// OffsetStore отдаёт последние обработанные offsets, если они хранятся вне Kafka
+type OffsetStore interface {
+	GetOffsets(ctx context.Context, topic, consumerGroup string) (map[int32]int64, error)
+	// SaveOffset фиксирует offset сообщения, которое handler не обработал,
+	// но которое уже ушло в DLQ
+	SaveOffset(ctx context.Context, topic, consumerGroup string, partition int32, offset int64) error
+}
 
 type Consumer struct {
 	consumerGroup sarama.ConsumerGroup
 	topics        []string
+	groupID       string
+	offsetStore   OffsetStore
+	retry         RetryPolicy
+	dlqTopic      string
+	dlqProducer   sarama.SyncProducer
 	handler       MessageHandler
 	logger        *zap.Logger
 	ready         chan bool
+
+	// Подряд идущие неудачи по партициям, растят паузу перед повторным чтением
+	stallMu sync.Mutex
+	stalls  map[string]int
 }
 
 type ConsumerConfig struct {
@@ -28,9 +63,22 @@ type ConsumerConfig struct {
 	CommitInterval    time.Duration
 	SessionTimeout    time.Duration
 	RebalanceStrategy string
+
+	// Если задан, после каждого rebalance consumer продолжает с offset из store.
+	// Требует DeadLetterTopic: иначе сообщение, которое handler не может
+	// обработать, навсегда остановит партицию.
+	OffsetStore OffsetStore
+
+	Retry RetryPolicy
+	// Если задан, сообщения, которые не удалось обработать, уходят в этот topic
+	DeadLetterTopic string
 }
 
 func NewConsumer(cfg ConsumerConfig, handler MessageHandler, logger *zap.Logger) (*Consumer, error) {
+	if cfg.OffsetStore != nil && cfg.DeadLetterTopic == "" {
+		return nil, ErrDeadLetterRequired
+	}
+
 	config := sarama.NewConfig()
 	config.Version = sarama.V3_3_0_0
 	config.Consumer.Return.Errors = true
@@ -67,18 +115,35 @@ func NewConsumer(cfg ConsumerConfig, handler MessageHandler, logger *zap.Logger)
 		return nil, fmt.Errorf("failed to create consumer group: %w", err)
 	}
 
+	var dlqProducer sarama.SyncProducer
+	if cfg.DeadLetterTopic != "" {
+		dlqProducer, err = newDeadLetterProducer(cfg.Brokers)
+		if err != nil {
+			consumerGroup.Close()
+			return nil, err
+		}
+	}
+
 	logger.Info("Kafka consumer initialized",
 		zap.Strings("brokers", cfg.Brokers),
 		zap.Strings("topics", cfg.Topics),
 		zap.String("group_id", cfg.GroupID),
+		zap.Int("max_attempts", cfg.Retry.attempts()),
+		zap.String("dlq_topic", cfg.DeadLetterTopic),
 	)
 
 	return &Consumer{
 		consumerGroup: consumerGroup,
 		topics:        cfg.Topics,
+		groupID:       cfg.GroupID,
+		offsetStore:   cfg.OffsetStore,
+		retry:         cfg.Retry,
+		dlqTopic:      cfg.DeadLetterTopic,
+		dlqProducer:   dlqProducer,
 		handler:       handler,
 		logger:        logger,
 		ready:         make(chan bool),
+		stalls:        make(map[string]int),
 	}, nil
 }
 
@@ -113,6 +178,12 @@ func (c *Consumer) Start(ctx context.Context) error {
 }
 
 func (c *Consumer) Close() error {
+	if c.dlqProducer != nil {
+		if err := c.dlqProducer.Close(); err != nil {
+			c.logger.Error("Failed to close dead letter producer", zap.Error(err))
+		}
+	}
+
 	if err := c.consumerGroup.Close(); err != nil {
 		c.logger.Error("Failed to close consumer group", zap.Error(err))
 		return err
@@ -122,12 +193,46 @@ func (c *Consumer) Close() error {
 }
 
 // Setup вызывается при старте новой session (после rebalance)
-func (c *Consumer) Setup(sarama.ConsumerGroupSession) error {
+func (c *Consumer) Setup(session sarama.ConsumerGroupSession) error {
 	c.logger.Info("Consumer group rebalanced")
+
+	if c.offsetStore != nil {
+		if err := c.seekToStoredOffsets(session); err != nil {
+			return err
+		}
+	}
+
 	close(c.ready)
 	return nil
 }
 
+// seekToStoredOffsets переносит позицию каждой полученной партиции на offset,
+// следующий за последним обработанным. Setup вызывается до старта ConsumeClaim,
+// поэтому чтение начнётся уже с новой позиции.
+func (c *Consumer) seekToStoredOffsets(session sarama.ConsumerGroupSession) error {
+	for topic, partitions := range session.Claims() {
+		offsets, err := c.offsetStore.GetOffsets(session.Context(), topic, c.groupID)
+		if err != nil {
+			return fmt.Errorf("failed to load stored offsets: %w", err)
+		}
+
+		for _, partition := range partitions {
+			offset, ok := offsets[partition]
+			if !ok {
+				continue
+			}
+
+			session.ResetOffset(topic, partition, offset+1, "")
+			c.logger.Info("Partition offset restored",
+				zap.String("topic", topic),
+				zap.Int32("partition", partition),
+				zap.Int64("offset", offset+1),
+			)
+		}
+	}
+	return nil
+}
+
 // Cleanup вызывается в конце session
 func (c *Consumer) Cleanup(sarama.ConsumerGroupSession) error {
 	return nil
@@ -149,15 +254,27 @@ func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim saram
 				zap.String("key", string(message.Key)),
 			)
 
-			// Обрабатываем сообщение
-			if err := c.handler(session.Context(), message.Key, message.Value); err != nil {
-				c.logger.Error("Failed to process message",
-					zap.Error(err),
-					zap.String("topic", message.Topic),
-					zap.Int32("partition", message.Partition),
-					zap.Int64("offset", message.Offset),
-				)
+			// HighWaterMarkOffset - offset следующего сообщения, которое придёт в партицию
+			consumerLag.WithLabelValues(
+				message.Topic,
+				strconv.FormatInt(int64(message.Partition), 10),
+				c.groupID,
+			).Set(float64(claim.HighWaterMarkOffset() - message.Offset - 1))
 
+			// Обрабатываем сообщение в trace, который пришёл из producer'а
+			ctx, span := startConsumerSpan(session.Context(), c.groupID, message)
+			err := c.process(ctx, message)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "message processing failed")
+			}
+			span.End()
+
+			if err != nil {
+				if session.Context().Err() != nil {
+					return nil
+				}
+				return err
 			}
 			session.MarkMessage(message, "")
 
@@ -167,6 +284,101 @@ func (c *Consumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim saram
 	}
 }
 
+// process обрабатывает сообщение с повторами. nil означает, что сообщение можно
+// пометить обработанным: handler отработал, или сообщение ушло в DLQ, или
+// (без DLQ) ошибка просто залогирована, как раньше.
+func (c *Consumer) process(ctx context.Context, message *sarama.ConsumerMessage) error {
+	attempts, err := c.handleWithRetry(ctx, newMessage(message))
+	if err == nil {
+		c.resetStall(message)
+		return nil
+	}
+
+	// Session закрывается (rebalance или shutdown) - сообщение дочитает следующая session
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	c.logger.Error("Failed to process message",
+		zap.Error(err),
+		zap.String("topic", message.Topic),
+		zap.Int32("partition", message.Partition),
+		zap.Int64("offset", message.Offset),
+		zap.Int("attempts", attempts),
+	)
+
+	// Без DLQ нет и store (см. NewConsumer), сообщение просто пропускаем
+	if c.dlqProducer == nil {
+		return nil
+	}
+
+	// Если DLQ недоступен, сообщение нельзя терять - перечитаем его после restart session
+	if err := c.sendToDeadLetter(message, err, attempts); err != nil {
+		return c.stall(ctx, message, err)
+	}
+
+	if c.offsetStore != nil {
+		if err := c.offsetStore.SaveOffset(ctx, message.Topic, c.groupID, message.Partition, message.Offset); err != nil {
+			return c.stall(ctx, message, fmt.Errorf("failed to save offset of dead letter: %w", err))
+		}
+	}
+	c.resetStall(message)
+	return nil
+}
+
+// stall ждёт перед завершением claim, чтобы новая session не перечитывала
+// то же сообщение без паузы. Пауза растёт с каждой неудачей подряд до maxStallBackoff.
+func (c *Consumer) stall(ctx context.Context, message *sarama.ConsumerMessage, err error) error {
+	key := message.Topic + "/" + strconv.FormatInt(int64(message.Partition), 10)
+
+	c.stallMu.Lock()
+	c.stalls[key]++
+	failures := c.stalls[key]
+	c.stallMu.Unlock()
+
+	policy := RetryPolicy{MinBackoff: c.retry.MinBackoff, MaxBackoff: maxStallBackoff}
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = time.Second
+	}
+	backoff := policy.backoff(failures)
+
+	c.logger.Warn("Partition stalled, ending claim",
+		zap.Error(err),
+		zap.String("topic", message.Topic),
+		zap.Int32("partition", message.Partition),
+		zap.Int64("offset", message.Offset),
+		zap.Int("failures", failures),
+		zap.Duration("retry_in", backoff),
+	)
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return err
+}
+
+func (c *Consumer) resetStall(message *sarama.ConsumerMessage) {
+	key := message.Topic + "/" + strconv.FormatInt(int64(message.Partition), 10)
+
+	c.stallMu.Lock()
+	delete(c.stalls, key)
+	c.stallMu.Unlock()
+}
+
+func newMessage(message *sarama.ConsumerMessage) *Message {
+	return &Message{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Key:       message.Key,
+		Value:     message.Value,
+		Timestamp: message.Timestamp,
+	}
+}
+
 // WaitReady ждёт пока consumer будет готов
 func (c *Consumer) WaitReady() <-chan bool {
 	return c.ready
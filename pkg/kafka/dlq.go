@@ -0,0 +1,305 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+// Заголовки, которые consumer добавляет к сообщению при отправке в DLQ
+const (
+	HeaderDLQError             = "dlq-error"
+	HeaderDLQAttempts          = "dlq-attempts"
+	HeaderDLQOriginalTopic     = "dlq-original-topic"
+	HeaderDLQOriginalPartition = "dlq-original-partition"
+	HeaderDLQOriginalOffset    = "dlq-original-offset"
+	HeaderDLQFailedAt          = "dlq-failed-at"
+
+	dlqHeaderPrefix = "dlq-"
+)
+
+// DeadLetter - сообщение из DLQ вместе с причиной, по которой оно туда попало
+type DeadLetter struct {
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []sarama.RecordHeader
+
+	Error             string
+	Attempts          int
+	OriginalTopic     string
+	OriginalPartition int32
+	OriginalOffset    int64
+	FailedAt          time.Time
+}
+
+func deadLetterProducerConfig() *sarama.Config {
+	config := sarama.NewConfig()
+	config.Version = sarama.V3_3_0_0
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Retry.Max = 5
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	return config
+}
+
+func newDeadLetterProducer(brokers []string) (sarama.SyncProducer, error) {
+	producer, err := sarama.NewSyncProducer(brokers, deadLetterProducerConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dead letter producer: %w", err)
+	}
+	return producer, nil
+}
+
+// sendToDeadLetter перекладывает сообщение в DLQ as is, причину пишет в заголовки
+func (c *Consumer) sendToDeadLetter(message *sarama.ConsumerMessage, cause error, attempts int) error {
+	headers := make([]sarama.RecordHeader, 0, len(message.Headers)+6)
+	for _, h := range message.Headers {
+		if h != nil && !strings.HasPrefix(string(h.Key), dlqHeaderPrefix) {
+			headers = append(headers, *h)
+		}
+	}
+	headers = append(headers,
+		header(HeaderDLQError, cause.Error()),
+		header(HeaderDLQAttempts, strconv.Itoa(attempts)),
+		header(HeaderDLQOriginalTopic, message.Topic),
+		header(HeaderDLQOriginalPartition, strconv.FormatInt(int64(message.Partition), 10)),
+		header(HeaderDLQOriginalOffset, strconv.FormatInt(message.Offset, 10)),
+		header(HeaderDLQFailedAt, time.Now().UTC().Format(time.RFC3339Nano)),
+	)
+
+	_, _, err := c.dlqProducer.SendMessage(&sarama.ProducerMessage{
+		Topic:   c.dlqTopic,
+		Key:     sarama.ByteEncoder(message.Key),
+		Value:   sarama.ByteEncoder(message.Value),
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message to dead letter topic: %w", err)
+	}
+	deadLetters.WithLabelValues(message.Topic).Inc()
+
+	c.logger.Warn("Message moved to dead letter topic",
+		zap.Error(cause),
+		zap.String("topic", message.Topic),
+		zap.Int32("partition", message.Partition),
+		zap.Int64("offset", message.Offset),
+		zap.Int("attempts", attempts),
+		zap.String("dlq_topic", c.dlqTopic),
+	)
+	return nil
+}
+
+func header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
+
+// DeadLetterQueue читает DLQ и возвращает сообщения обратно в исходный topic
+type DeadLetterQueue struct {
+	client   sarama.Client
+	producer sarama.SyncProducer
+	topic    string
+	logger   *zap.Logger
+}
+
+func NewDeadLetterQueue(brokers []string, topic string, logger *zap.Logger) (*DeadLetterQueue, error) {
+	client, err := sarama.NewClient(brokers, deadLetterProducerConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kafka client: %w", err)
+	}
+
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to create producer: %w", err)
+	}
+
+	return &DeadLetterQueue{
+		client:   client,
+		producer: producer,
+		topic:    topic,
+		logger:   logger,
+	}, nil
+}
+
+// Read проходит по сообщениям DLQ, которые уже лежат в topic на момент вызова.
+// partition < 0 - все партиции. Чтение останавливается после limit сообщений
+// (0 - без ограничения) или если fn вернул ошибку.
+func (q *DeadLetterQueue) Read(
+	ctx context.Context,
+	partition int32,
+	fromOffset int64,
+	limit int,
+	fn func(*DeadLetter) error,
+) error {
+	partitions := []int32{partition}
+	if partition < 0 {
+		var err error
+		partitions, err = q.client.Partitions(q.topic)
+		if err != nil {
+			return fmt.Errorf("failed to get partitions: %w", err)
+		}
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(q.client)
+	if err != nil {
+		return fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	read := 0
+	for _, p := range partitions {
+		if limit > 0 && read >= limit {
+			return nil
+		}
+
+		n, err := q.readPartition(ctx, consumer, p, fromOffset, limit-read, fn)
+		read += n
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *DeadLetterQueue) readPartition(
+	ctx context.Context,
+	consumer sarama.Consumer,
+	partition int32,
+	fromOffset int64,
+	limit int,
+	fn func(*DeadLetter) error,
+) (int, error) {
+	oldest, err := q.client.GetOffset(q.topic, partition, sarama.OffsetOldest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get oldest offset: %w", err)
+	}
+	newest, err := q.client.GetOffset(q.topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get newest offset: %w", err)
+	}
+
+	start := max(oldest, fromOffset)
+	if start >= newest {
+		return 0, nil
+	}
+
+	pc, err := consumer.ConsumePartition(q.topic, partition, start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to consume partition %d: %w", partition, err)
+	}
+	defer pc.Close()
+
+	read := 0
+	for {
+		select {
+		case message := <-pc.Messages():
+			if err := fn(newDeadLetter(message)); err != nil {
+				return read, err
+			}
+			read++
+
+			// newest - offset следующего сообщения, дальше читать нечего
+			if message.Offset >= newest-1 || (limit > 0 && read >= limit) {
+				return read, nil
+			}
+
+		case err := <-pc.Errors():
+			return read, fmt.Errorf("failed to read partition %d: %w", partition, err)
+
+		case <-ctx.Done():
+			return read, ctx.Err()
+		}
+	}
+}
+
+// Replay отправляет сообщение в исходный topic без DLQ заголовков.
+// Если исходный topic неизвестен, используется fallbackTopic.
+func (q *DeadLetterQueue) Replay(ctx context.Context, dl *DeadLetter, fallbackTopic string) error {
+	topic := dl.OriginalTopic
+	if topic == "" {
+		topic = fallbackTopic
+	}
+	if topic == "" {
+		return fmt.Errorf("unknown target topic for dead letter at offset %d", dl.Offset)
+	}
+
+	headers := make([]sarama.RecordHeader, 0, len(dl.Headers))
+	for _, h := range dl.Headers {
+		if !strings.HasPrefix(string(h.Key), dlqHeaderPrefix) {
+			headers = append(headers, h)
+		}
+	}
+
+	partition, offset, err := q.producer.SendMessage(&sarama.ProducerMessage{
+		Topic:   topic,
+		Key:     sarama.ByteEncoder(dl.Key),
+		Value:   sarama.ByteEncoder(dl.Value),
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replay message: %w", err)
+	}
+
+	q.logger.Info("Dead letter replayed",
+		zap.Int32("dlq_partition", dl.Partition),
+		zap.Int64("dlq_offset", dl.Offset),
+		zap.String("topic", topic),
+		zap.Int32("partition", partition),
+		zap.Int64("offset", offset),
+	)
+	return nil
+}
+
+func (q *DeadLetterQueue) Close() error {
+	if err := q.producer.Close(); err != nil {
+		q.logger.Error("Failed to close dead letter producer", zap.Error(err))
+	}
+	return q.client.Close()
+}
+
+func newDeadLetter(message *sarama.ConsumerMessage) *DeadLetter {
+	dl := &DeadLetter{
+		Partition:         message.Partition,
+		Offset:            message.Offset,
+		Key:               message.Key,
+		Value:             message.Value,
+		OriginalPartition: -1,
+		OriginalOffset:    -1,
+	}
+
+	for _, h := range message.Headers {
+		if h == nil {
+			continue
+		}
+		dl.Headers = append(dl.Headers, *h)
+
+		value := string(h.Value)
+		switch string(h.Key) {
+		case HeaderDLQError:
+			dl.Error = value
+		case HeaderDLQAttempts:
+			dl.Attempts, _ = strconv.Atoi(value)
+		case HeaderDLQOriginalTopic:
+			dl.OriginalTopic = value
+		case HeaderDLQOriginalPartition:
+			if p, err := strconv.ParseInt(value, 10, 32); err == nil {
+				dl.OriginalPartition = int32(p)
+			}
+		case HeaderDLQOriginalOffset:
+			if o, err := strconv.ParseInt(value, 10, 64); err == nil {
+				dl.OriginalOffset = o
+			}
+		case HeaderDLQFailedAt:
+			dl.FailedAt, _ = time.Parse(time.RFC3339Nano, value)
+		}
+	}
+
+	return dl
+}
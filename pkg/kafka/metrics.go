@@ -0,0 +1,35 @@
+package kafka
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	produceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_produce_duration_seconds",
+		Help:    "Kafka produce latency by topic",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	produceErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_produce_errors_total",
+		Help: "Failed Kafka produce calls by topic",
+	}, []string{"topic"})
+
+	consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Messages behind the high watermark by topic, partition and consumer group",
+	}, []string{"topic", "partition", "group"})
+
+	handlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_handler_duration_seconds",
+		Help:    "Message handler duration by topic and result (ok, error)",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic", "result"})
+
+	deadLetters = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_dead_letters_total",
+		Help: "Messages moved to the dead letter topic by source topic",
+	}, []string{"topic"})
+)
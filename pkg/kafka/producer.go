@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.uber.org/zap"
 )
 
@@ -98,8 +100,39 @@ func (p *Producer) SendMessage(ctx context.Context, key string, value any) error
 		},
 	}
 
+	return p.send(ctx, msg, key)
+}
+
+// SendTombstone отправляет сообщение с ключом key и пустым value. При log
+// compaction Kafka удаляет все сообщения с этим ключом, consumers по нему
+// удаляют свои данные.
+func (p *Producer) SendTombstone(ctx context.Context, key string) error {
+	msg := &sarama.ProducerMessage{
+		Topic: p.topic,
+		Key:   sarama.StringEncoder(key),
+		Headers: []sarama.RecordHeader{
+			{
+				Key:   []byte("timestamp"),
+				Value: []byte(time.Now().Format(time.RFC3339Nano)),
+			},
+		},
+	}
+
+	return p.send(ctx, msg, key)
+}
+
+func (p *Producer) send(ctx context.Context, msg *sarama.ProducerMessage, key string) error {
+	// traceparent кладётся в заголовки рядом с timestamp
+	_, span := startProducerSpan(ctx, msg)
+	defer span.End()
+
+	start := time.Now()
 	partition, offset, err := p.producer.SendMessage(msg)
+	produceDuration.WithLabelValues(p.topic).Observe(time.Since(start).Seconds())
 	if err != nil {
+		produceErrors.WithLabelValues(p.topic).Inc()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "produce failed")
 		p.logger.Error("Failed to send message to Kafka",
 			zap.Error(err),
 			zap.String("topic", p.topic),
@@ -108,6 +141,11 @@ func (p *Producer) SendMessage(ctx context.Context, key string, value any) error
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
+	span.SetAttributes(
+		attribute.Int("messaging.destination.partition.id", int(partition)),
+		attribute.Int64("messaging.kafka.offset", offset),
+	)
+
 	p.logger.Debug("Message sent to Kafka",
 		zap.String("topic", p.topic),
 		zap.Int32("partition", partition),
@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RetryPolicy описывает повторы handler'а для одного сообщения
+type RetryPolicy struct {
+	// Сколько всего попыток, включая первую. 0 или 1 - без повторов
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// permanentError помечает ошибку, которую нет смысла повторять
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent оборачивает ошибку handler'а: сообщение сразу уходит в DLQ без повторов
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func IsPermanent(err error) bool {
+	var perm *permanentError
+	return errors.As(err, &perm)
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff экспоненциальный: MinBackoff * 2^(attempt-1), но не больше MaxBackoff
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.MinBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return backoff
+}
+
+// handleWithRetry вызывает handler, пока он не отработает, не кончатся попытки
+// или ошибка не окажется permanent. Возвращает число сделанных попыток.
+func (c *Consumer) handleWithRetry(ctx context.Context, msg *Message) (int, error) {
+	maxAttempts := c.retry.attempts()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err = c.handler(ctx, msg)
+		observeHandler(msg.Topic, err, time.Since(start))
+		if err == nil || IsPermanent(err) || attempt >= maxAttempts {
+			return attempt, err
+		}
+
+		backoff := c.retry.backoff(attempt)
+		c.logger.Warn("Message processing failed, retrying",
+			zap.Error(err),
+			zap.String("topic", msg.Topic),
+			zap.Int32("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", backoff),
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, ctx.Err()
+		}
+	}
+}
+
+func observeHandler(topic string, err error, duration time.Duration) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	handlerDuration.WithLabelValues(topic, result).Observe(duration.Seconds())
+}
@@ -0,0 +1,359 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Wuchinator/realtime-analytics/pkg/postgres"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// Ключ pg_advisory_lock: миграции применяет только один процесс
+	lockKey = 7_240_019_001
+	// Сколько ждать lock, пока миграции применяет другой процесс
+	lockTimeout = 5 * time.Minute
+)
+
+var (
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+
+	ErrIrreversible = errors.New("migration has no down script")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration - пара скриптов NNNN_name.up.sql и NNNN_name.down.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	// Пустой, если откатить миграцию нельзя
+	Down string
+}
+
+// Status - миграция и время применения, nil если она ещё не применена
+type Status struct {
+	*Migration
+	AppliedAt *time.Time
+}
+
+// Load читает миграции из корня fsys. Версии должны быть уникальны,
+// у каждой миграции обязан быть up скрипт.
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		if match[3] == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator применяет и откатывает миграции, версии хранятся в schema_migrations
+type Migrator struct {
+	db         *postgres.DB
+	migrations []*Migration
+	logger     *zap.Logger
+}
+
+func New(db *postgres.DB, fsys fs.FS, logger *zap.Logger) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, errors.New("no migrations found")
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger,
+	}, nil
+}
+
+// Latest - версия схемы, которую ожидает этот бинарник
+func (m *Migrator) Latest() int {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version - последняя применённая миграция, 0 для пустой базы
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return 0, err
+	}
+	return maxVersion(applied), nil
+}
+
+// Check возвращает ErrSchemaOutdated, если применены не все миграции
+// этого бинарника. Более новая схема допустима: при выкатке старые реплики
+// работают рядом с уже обновлённой базой.
+func (m *Migrator) Check(ctx context.Context) error {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			return fmt.Errorf("%w: migration %d_%s is not applied, run migrate up",
+				ErrSchemaOutdated, migration.Version, migration.Name)
+		}
+	}
+
+	if version := maxVersion(applied); version > m.Latest() {
+		m.logger.Warn("Database schema is newer than this binary",
+			zap.Int("schema_version", version),
+			zap.Int("expected_version", m.Latest()),
+		)
+	}
+	return nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		status := &Status{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses[i] = status
+	}
+	return statuses, nil
+}
+
+// Up применяет по порядку все неприменённые миграции с версией до target
+// включительно, 0 - до последней. Каждая миграция идёт в своей транзакции.
+func (m *Migrator) Up(ctx context.Context, target int) ([]*Migration, error) {
+	if target <= 0 {
+		target = m.Latest()
+	}
+
+	var done []*Migration
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > target {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration, migration.Up, `
+				INSERT INTO schema_migrations (version, name) VALUES ($1, $2)
+			`); err != nil {
+				return err
+			}
+			done = append(done, migration)
+
+			m.logger.Info("Migration applied",
+				zap.Int("version", migration.Version),
+				zap.String("name", migration.Name),
+			)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// Down откатывает steps последних применённых миграций
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("%w: %d_%s", ErrIrreversible, migration.Version, migration.Name)
+			}
+
+			if err := m.apply(ctx, conn, migration, migration.Down, `
+				DELETE FROM schema_migrations WHERE version = $1 AND name = $2
+			`); err != nil {
+				return err
+			}
+			done = append(done, migration)
+
+			m.logger.Info("Migration rolled back",
+				zap.Int("version", migration.Version),
+				zap.String("name", migration.Name),
+			)
+		}
+		return nil
+	})
+
+	return done, err
+}
+
+// apply выполняет скрипт и запись в schema_migrations в одной транзакции
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, migration *Migration, script, record string) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Намеренно игнорирую ошибку
+
+	// Без параметров lib/pq отправляет скрипт целиком, несколько команд допустимы
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("failed to run migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, migration.Version, migration.Name); err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// withLock держит session-level advisory lock на отдельном соединении,
+// на нём же выполняются миграции
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+	if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		// Отдельный context: lock нужно отпустить, даже если ctx уже отменён
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			m.logger.Warn("Failed to release migration lock", zap.Error(err))
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// applied - версии из schema_migrations и время применения. Пустая, если
+// таблицы ещё нет.
+func (m *Migrator) applied(ctx context.Context, db sqlx.QueryerContext) (map[int]time.Time, error) {
+	var exists bool
+	if err := sqlx.GetContext(ctx, db, &exists, "SELECT to_regclass('schema_migrations') IS NOT NULL"); err != nil {
+		return nil, fmt.Errorf("failed to check schema_migrations: %w", err)
+	}
+	if !exists {
+		return map[int]time.Time{}, nil
+	}
+
+	var rows []struct {
+		Version   int       `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	if err := sqlx.SelectContext(ctx, db, &rows, "SELECT version, applied_at FROM schema_migrations"); err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	applied := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+func maxVersion(applied map[int]time.Time) int {
+	version := 0
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version
+}
+
+// Prepare вызывается сервисами при старте: с apply сначала применяет
+// миграции, затем проверяет, что схема не старее бинарника
+func (m *Migrator) Prepare(ctx context.Context, apply bool) error {
+	if apply {
+		if _, err := m.Up(ctx, 0); err != nil {
+			return err
+		}
+	}
+	return m.Check(ctx)
+}
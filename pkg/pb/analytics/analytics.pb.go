@@ -9,6 +9,8 @@ package analytics
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -27,7 +29,9 @@ type GetEventStatsRequest struct {
 	From          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
 	To            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
 	EventType     string                 `protobuf:"bytes,3,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
-	Granularity   string                 `protobuf:"bytes,4,opt,name=granularity,proto3" json:"granularity,omitempty"`
+	Granularity   string                 `protobuf:"bytes,4,opt,name=granularity,proto3" json:"granularity,omitempty"`                                                                   // minute, hour (по умолчанию), day, week или month
+	GroupBy       []string               `protobuf:"bytes,5,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`                                                            // измерения из ANALYTICS_DIMENSIONS
+	Filters       map[string]string      `protobuf:"bytes,6,rep,name=filters,proto3" json:"filters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // точное совпадение значений измерений
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -90,13 +94,30 @@ func (x *GetEventStatsRequest) GetGranularity() string {
 	return ""
 }
 
+func (x *GetEventStatsRequest) GetGroupBy() []string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return nil
+}
+
+func (x *GetEventStatsRequest) GetFilters() map[string]string {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
 type EventStats struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	EventType     string                 `protobuf:"bytes,2,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
-	TotalEvents   int64                  `protobuf:"varint,3,opt,name=total_events,json=totalEvents,proto3" json:"total_events,omitempty"`
-	UniqueUsers   int64                  `protobuf:"varint,4,opt,name=unique_users,json=uniqueUsers,proto3" json:"unique_users,omitempty"`
-	Metadata      map[string]string      `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp   *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	EventType   string                 `protobuf:"bytes,2,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	TotalEvents int64                  `protobuf:"varint,3,opt,name=total_events,json=totalEvents,proto3" json:"total_events,omitempty"`
+	UniqueUsers int64                  `protobuf:"varint,4,opt,name=unique_users,json=uniqueUsers,proto3" json:"unique_users,omitempty"`
+	// Deprecated: Marked as deprecated in analytics.proto.
+	Metadata      map[string]string `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // значения, приведённые к строкам
+	Properties    *structpb.Struct  `protobuf:"bytes,6,opt,name=properties,proto3" json:"properties,omitempty"`
+	Dimensions    map[string]string `protobuf:"bytes,7,rep,name=dimensions,proto3" json:"dimensions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // значения group_by, "__other__" - значения сверх лимита
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -159,6 +180,7 @@ func (x *EventStats) GetUniqueUsers() int64 {
 	return 0
 }
 
+// Deprecated: Marked as deprecated in analytics.proto.
 func (x *EventStats) GetMetadata() map[string]string {
 	if x != nil {
 		return x.Metadata
@@ -166,6 +188,20 @@ func (x *EventStats) GetMetadata() map[string]string {
 	return nil
 }
 
+func (x *EventStats) GetProperties() *structpb.Struct {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+func (x *EventStats) GetDimensions() map[string]string {
+	if x != nil {
+		return x.Dimensions
+	}
+	return nil
+}
+
 type GetEventStatsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Stats         []*EventStats          `protobuf:"bytes,1,rep,name=stats,proto3" json:"stats,omitempty"`
@@ -218,19 +254,80 @@ func (x *GetEventStatsResponse) GetTotalCount() int64 {
 	return 0
 }
 
+type SubscribeEventStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventTypes    []string               `protobuf:"bytes,1,rep,name=event_types,json=eventTypes,proto3" json:"event_types,omitempty"`    // пусто - все типы
+	MinInterval   *durationpb.Duration   `protobuf:"bytes,2,opt,name=min_interval,json=minInterval,proto3" json:"min_interval,omitempty"` // не чаще одного обновления бакета за интервал
+	Granularity   string                 `protobuf:"bytes,3,opt,name=granularity,proto3" json:"granularity,omitempty"`                    // minute или hour (по умолчанию)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeEventStatsRequest) Reset() {
+	*x = SubscribeEventStatsRequest{}
+	mi := &file_analytics_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeEventStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeEventStatsRequest) ProtoMessage() {}
+
+func (x *SubscribeEventStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeEventStatsRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeEventStatsRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SubscribeEventStatsRequest) GetEventTypes() []string {
+	if x != nil {
+		return x.EventTypes
+	}
+	return nil
+}
+
+func (x *SubscribeEventStatsRequest) GetMinInterval() *durationpb.Duration {
+	if x != nil {
+		return x.MinInterval
+	}
+	return nil
+}
+
+func (x *SubscribeEventStatsRequest) GetGranularity() string {
+	if x != nil {
+		return x.Granularity
+	}
+	return ""
+}
+
 type GetUserActivityRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	From          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
 	To            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
-	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"` // количество событий
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`                               // количество событий
+	AnonymousId   string                 `protobuf:"bytes,5,opt,name=anonymous_id,json=anonymousId,proto3" json:"anonymous_id,omitempty"` // вместо user_id, если пользователь ещё не известен
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetUserActivityRequest) Reset() {
 	*x = GetUserActivityRequest{}
-	mi := &file_analytics_proto_msgTypes[3]
+	mi := &file_analytics_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -242,7 +339,7 @@ func (x *GetUserActivityRequest) String() string {
 func (*GetUserActivityRequest) ProtoMessage() {}
 
 func (x *GetUserActivityRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_analytics_proto_msgTypes[3]
+	mi := &file_analytics_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -255,7 +352,7 @@ func (x *GetUserActivityRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetUserActivityRequest.ProtoReflect.Descriptor instead.
 func (*GetUserActivityRequest) Descriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{3}
+	return file_analytics_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetUserActivityRequest) GetUserId() string {
@@ -286,20 +383,30 @@ func (x *GetUserActivityRequest) GetLimit() int32 {
 	return 0
 }
 
+func (x *GetUserActivityRequest) GetAnonymousId() string {
+	if x != nil {
+		return x.AnonymousId
+	}
+	return ""
+}
+
 type UserEvent struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
-	EventType     string                 `protobuf:"bytes,2,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	ProductId     string                 `protobuf:"bytes,4,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	Metadata      map[string]string      `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	EventId   string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	EventType string                 `protobuf:"bytes,2,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ProductId string                 `protobuf:"bytes,4,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	// Deprecated: Marked as deprecated in analytics.proto.
+	Metadata      map[string]string `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // значения, приведённые к строкам
+	Properties    *structpb.Struct  `protobuf:"bytes,6,opt,name=properties,proto3" json:"properties,omitempty"`                                                                       // data события с исходными типами
+	AnonymousId   string            `protobuf:"bytes,7,opt,name=anonymous_id,json=anonymousId,proto3" json:"anonymous_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UserEvent) Reset() {
 	*x = UserEvent{}
-	mi := &file_analytics_proto_msgTypes[4]
+	mi := &file_analytics_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -311,7 +418,7 @@ func (x *UserEvent) String() string {
 func (*UserEvent) ProtoMessage() {}
 
 func (x *UserEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_analytics_proto_msgTypes[4]
+	mi := &file_analytics_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -324,7 +431,7 @@ func (x *UserEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserEvent.ProtoReflect.Descriptor instead.
 func (*UserEvent) Descriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{4}
+	return file_analytics_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *UserEvent) GetEventId() string {
@@ -355,6 +462,7 @@ func (x *UserEvent) GetProductId() string {
 	return ""
 }
 
+// Deprecated: Marked as deprecated in analytics.proto.
 func (x *UserEvent) GetMetadata() map[string]string {
 	if x != nil {
 		return x.Metadata
@@ -362,9 +470,23 @@ func (x *UserEvent) GetMetadata() map[string]string {
 	return nil
 }
 
+func (x *UserEvent) GetProperties() *structpb.Struct {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+func (x *UserEvent) GetAnonymousId() string {
+	if x != nil {
+		return x.AnonymousId
+	}
+	return ""
+}
+
 type GetUserActivityResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // канонический user_id, к которому привязан anonymous_id
 	Events        []*UserEvent           `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
 	TotalEvents   int64                  `protobuf:"varint,3,opt,name=total_events,json=totalEvents,proto3" json:"total_events,omitempty"`
 	unknownFields protoimpl.UnknownFields
@@ -373,7 +495,7 @@ type GetUserActivityResponse struct {
 
 func (x *GetUserActivityResponse) Reset() {
 	*x = GetUserActivityResponse{}
-	mi := &file_analytics_proto_msgTypes[5]
+	mi := &file_analytics_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -385,7 +507,7 @@ func (x *GetUserActivityResponse) String() string {
 func (*GetUserActivityResponse) ProtoMessage() {}
 
 func (x *GetUserActivityResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_analytics_proto_msgTypes[5]
+	mi := &file_analytics_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -398,7 +520,7 @@ func (x *GetUserActivityResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetUserActivityResponse.ProtoReflect.Descriptor instead.
 func (*GetUserActivityResponse) Descriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{5}
+	return file_analytics_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetUserActivityResponse) GetUserId() string {
@@ -428,13 +550,14 @@ type GetTopProductsRequest struct {
 	To            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
 	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
 	EventType     string                 `protobuf:"bytes,4,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	RankBy        string                 `protobuf:"bytes,5,opt,name=rank_by,json=rankBy,proto3" json:"rank_by,omitempty"` // "events" (default), "purchases", "add_to_cart_rate", "revenue"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GetTopProductsRequest) Reset() {
 	*x = GetTopProductsRequest{}
-	mi := &file_analytics_proto_msgTypes[6]
+	mi := &file_analytics_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -446,7 +569,7 @@ func (x *GetTopProductsRequest) String() string {
 func (*GetTopProductsRequest) ProtoMessage() {}
 
 func (x *GetTopProductsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_analytics_proto_msgTypes[6]
+	mi := &file_analytics_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -459,7 +582,7 @@ func (x *GetTopProductsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetTopProductsRequest.ProtoReflect.Descriptor instead.
 func (*GetTopProductsRequest) Descriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{6}
+	return file_analytics_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetTopProductsRequest) GetFrom() *timestamppb.Timestamp {
@@ -490,20 +613,32 @@ func (x *GetTopProductsRequest) GetEventType() string {
 	return ""
 }
 
+func (x *GetTopProductsRequest) GetRankBy() string {
+	if x != nil {
+		return x.RankBy
+	}
+	return ""
+}
+
 type ProductStats struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	ProductId      string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	EventCount     int64                  `protobuf:"varint,2,opt,name=event_count,json=eventCount,proto3" json:"event_count,omitempty"`
-	UniqueUsers    int64                  `protobuf:"varint,3,opt,name=unique_users,json=uniqueUsers,proto3" json:"unique_users,omitempty"`
-	ConversionRate float64                `protobuf:"fixed64,4,opt,name=conversion_rate,json=conversionRate,proto3" json:"conversion_rate,omitempty"`
+	EventCount     int64                  `protobuf:"varint,2,opt,name=event_count,json=eventCount,proto3" json:"event_count,omitempty"`              // с учётом фильтра event_type
+	UniqueUsers    int64                  `protobuf:"varint,3,opt,name=unique_users,json=uniqueUsers,proto3" json:"unique_users,omitempty"`           // с учётом фильтра event_type
+	ConversionRate float64                `protobuf:"fixed64,4,opt,name=conversion_rate,json=conversionRate,proto3" json:"conversion_rate,omitempty"` // купившие / просмотревшие
 	Metadata       map[string]string      `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Views          int64                  `protobuf:"varint,6,opt,name=views,proto3" json:"views,omitempty"`
+	AddToCarts     int64                  `protobuf:"varint,7,opt,name=add_to_carts,json=addToCarts,proto3" json:"add_to_carts,omitempty"`
+	Purchases      int64                  `protobuf:"varint,8,opt,name=purchases,proto3" json:"purchases,omitempty"`
+	AddToCartRate  float64                `protobuf:"fixed64,9,opt,name=add_to_cart_rate,json=addToCartRate,proto3" json:"add_to_cart_rate,omitempty"` // добавившие в корзину / просмотревшие
+	Revenue        float64                `protobuf:"fixed64,10,opt,name=revenue,proto3" json:"revenue,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ProductStats) Reset() {
 	*x = ProductStats{}
-	mi := &file_analytics_proto_msgTypes[7]
+	mi := &file_analytics_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -515,7 +650,7 @@ func (x *ProductStats) String() string {
 func (*ProductStats) ProtoMessage() {}
 
 func (x *ProductStats) ProtoReflect() protoreflect.Message {
-	mi := &file_analytics_proto_msgTypes[7]
+	mi := &file_analytics_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -528,7 +663,7 @@ func (x *ProductStats) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProductStats.ProtoReflect.Descriptor instead.
 func (*ProductStats) Descriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{7}
+	return file_analytics_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ProductStats) GetProductId() string {
@@ -566,6 +701,41 @@ func (x *ProductStats) GetMetadata() map[string]string {
 	return nil
 }
 
+func (x *ProductStats) GetViews() int64 {
+	if x != nil {
+		return x.Views
+	}
+	return 0
+}
+
+func (x *ProductStats) GetAddToCarts() int64 {
+	if x != nil {
+		return x.AddToCarts
+	}
+	return 0
+}
+
+func (x *ProductStats) GetPurchases() int64 {
+	if x != nil {
+		return x.Purchases
+	}
+	return 0
+}
+
+func (x *ProductStats) GetAddToCartRate() float64 {
+	if x != nil {
+		return x.AddToCartRate
+	}
+	return 0
+}
+
+func (x *ProductStats) GetRevenue() float64 {
+	if x != nil {
+		return x.Revenue
+	}
+	return 0
+}
+
 type GetTopProductsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Products      []*ProductStats        `protobuf:"bytes,1,rep,name=products,proto3" json:"products,omitempty"`
@@ -575,7 +745,7 @@ type GetTopProductsResponse struct {
 
 func (x *GetTopProductsResponse) Reset() {
 	*x = GetTopProductsResponse{}
-	mi := &file_analytics_proto_msgTypes[8]
+	mi := &file_analytics_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -587,7 +757,7 @@ func (x *GetTopProductsResponse) String() string {
 func (*GetTopProductsResponse) ProtoMessage() {}
 
 func (x *GetTopProductsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_analytics_proto_msgTypes[8]
+	mi := &file_analytics_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -600,7 +770,7 @@ func (x *GetTopProductsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetTopProductsResponse.ProtoReflect.Descriptor instead.
 func (*GetTopProductsResponse) Descriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{8}
+	return file_analytics_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *GetTopProductsResponse) GetProducts() []*ProductStats {
@@ -610,27 +780,32 @@ func (x *GetTopProductsResponse) GetProducts() []*ProductStats {
 	return nil
 }
 
-type HealthCheckRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+type GetFunnelRequest struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	From             *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To               *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Steps            []string               `protobuf:"bytes,3,rep,name=steps,proto3" json:"steps,omitempty"`                                               // event_type шагов по порядку
+	ConversionWindow *durationpb.Duration   `protobuf:"bytes,4,opt,name=conversion_window,json=conversionWindow,proto3" json:"conversion_window,omitempty"` // от первого шага до последнего
+	CountBy          string                 `protobuf:"bytes,5,opt,name=count_by,json=countBy,proto3" json:"count_by,omitempty"`                            // "user" (default) или "session"
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
-func (x *HealthCheckRequest) Reset() {
-	*x = HealthCheckRequest{}
-	mi := &file_analytics_proto_msgTypes[9]
+func (x *GetFunnelRequest) Reset() {
+	*x = GetFunnelRequest{}
+	mi := &file_analytics_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckRequest) String() string {
+func (x *GetFunnelRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckRequest) ProtoMessage() {}
+func (*GetFunnelRequest) ProtoMessage() {}
 
-func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_analytics_proto_msgTypes[9]
+func (x *GetFunnelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -641,35 +816,72 @@ func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
-func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use GetFunnelRequest.ProtoReflect.Descriptor instead.
+func (*GetFunnelRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{10}
 }
 
-type HealthCheckResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
-	Dependencies  map[string]string      `protobuf:"bytes,3,rep,name=dependencies,proto3" json:"dependencies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *GetFunnelRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
 }
 
-func (x *HealthCheckResponse) Reset() {
-	*x = HealthCheckResponse{}
-	mi := &file_analytics_proto_msgTypes[10]
+func (x *GetFunnelRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetFunnelRequest) GetSteps() []string {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+func (x *GetFunnelRequest) GetConversionWindow() *durationpb.Duration {
+	if x != nil {
+		return x.ConversionWindow
+	}
+	return nil
+}
+
+func (x *GetFunnelRequest) GetCountBy() string {
+	if x != nil {
+		return x.CountBy
+	}
+	return ""
+}
+
+type FunnelStep struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	EventType              string                 `protobuf:"bytes,1,opt,name=event_type,json=eventType,proto3" json:"event_type,omitempty"`
+	Users                  int64                  `protobuf:"varint,2,opt,name=users,proto3" json:"users,omitempty"`
+	ConversionRate         float64                `protobuf:"fixed64,3,opt,name=conversion_rate,json=conversionRate,proto3" json:"conversion_rate,omitempty"`                        // от предыдущего шага
+	OverallConversionRate  float64                `protobuf:"fixed64,4,opt,name=overall_conversion_rate,json=overallConversionRate,proto3" json:"overall_conversion_rate,omitempty"` // от первого шага
+	MedianTimeFromPrevious *durationpb.Duration   `protobuf:"bytes,5,opt,name=median_time_from_previous,json=medianTimeFromPrevious,proto3" json:"median_time_from_previous,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *FunnelStep) Reset() {
+	*x = FunnelStep{}
+	mi := &file_analytics_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckResponse) String() string {
+func (x *FunnelStep) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckResponse) ProtoMessage() {}
+func (*FunnelStep) ProtoMessage() {}
 
-func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_analytics_proto_msgTypes[10]
+func (x *FunnelStep) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -680,84 +892,1007 @@ func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
-func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
-	return file_analytics_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use FunnelStep.ProtoReflect.Descriptor instead.
+func (*FunnelStep) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *HealthCheckResponse) GetHealthy() bool {
+func (x *FunnelStep) GetEventType() string {
 	if x != nil {
-		return x.Healthy
+		return x.EventType
 	}
-	return false
+	return ""
 }
 
-func (x *HealthCheckResponse) GetStatus() string {
+func (x *FunnelStep) GetUsers() int64 {
 	if x != nil {
-		return x.Status
+		return x.Users
 	}
-	return ""
+	return 0
 }
 
-func (x *HealthCheckResponse) GetDependencies() map[string]string {
+func (x *FunnelStep) GetConversionRate() float64 {
 	if x != nil {
-		return x.Dependencies
+		return x.ConversionRate
+	}
+	return 0
+}
+
+func (x *FunnelStep) GetOverallConversionRate() float64 {
+	if x != nil {
+		return x.OverallConversionRate
+	}
+	return 0
+}
+
+func (x *FunnelStep) GetMedianTimeFromPrevious() *durationpb.Duration {
+	if x != nil {
+		return x.MedianTimeFromPrevious
 	}
 	return nil
 }
 
-var File_analytics_proto protoreflect.FileDescriptor
+type GetFunnelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Steps         []*FunnelStep          `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
+	CountBy       string                 `protobuf:"bytes,2,opt,name=count_by,json=countBy,proto3" json:"count_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_analytics_proto_rawDesc = "" +
-	"\n" +
-	"\x0fanalytics.proto\x12\tanalytics\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb3\x01\n" +
-	"\x14GetEventStatsRequest\x12.\n" +
-	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
-	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x1d\n" +
-	"\n" +
-	"event_type\x18\x03 \x01(\tR\teventType\x12 \n" +
-	"\vgranularity\x18\x04 \x01(\tR\vgranularity\"\xa9\x02\n" +
-	"\n" +
-	"EventStats\x128\n" +
-	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1d\n" +
-	"\n" +
-	"event_type\x18\x02 \x01(\tR\teventType\x12!\n" +
-	"\ftotal_events\x18\x03 \x01(\x03R\vtotalEvents\x12!\n" +
-	"\funique_users\x18\x04 \x01(\x03R\vuniqueUsers\x12?\n" +
-	"\bmetadata\x18\x05 \x03(\v2#.analytics.EventStats.MetadataEntryR\bmetadata\x1a;\n" +
-	"\rMetadataEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"e\n" +
-	"\x15GetEventStatsResponse\x12+\n" +
-	"\x05stats\x18\x01 \x03(\v2\x15.analytics.EventStatsR\x05stats\x12\x1f\n" +
-	"\vtotal_count\x18\x02 \x01(\x03R\n" +
-	"totalCount\"\xa3\x01\n" +
+func (x *GetFunnelResponse) Reset() {
+	*x = GetFunnelResponse{}
+	mi := &file_analytics_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFunnelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFunnelResponse) ProtoMessage() {}
+
+func (x *GetFunnelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFunnelResponse.ProtoReflect.Descriptor instead.
+func (*GetFunnelResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetFunnelResponse) GetSteps() []*FunnelStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+func (x *GetFunnelResponse) GetCountBy() string {
+	if x != nil {
+		return x.CountBy
+	}
+	return ""
+}
+
+type GetRetentionRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	From            *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"` // диапазон начала cohort
+	To              *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Period          string                 `protobuf:"bytes,3,opt,name=period,proto3" json:"period,omitempty"`                                            // "day" (default), "week", "month"
+	Periods         int32                  `protobuf:"varint,4,opt,name=periods,proto3" json:"periods,omitempty"`                                         // сколько периодов после cohort
+	CohortEventType string                 `protobuf:"bytes,5,opt,name=cohort_event_type,json=cohortEventType,proto3" json:"cohort_event_type,omitempty"` // пусто - первое любое событие
+	ReturnEventType string                 `protobuf:"bytes,6,opt,name=return_event_type,json=returnEventType,proto3" json:"return_event_type,omitempty"` // пусто - любое событие
+	UseCache        bool                   `protobuf:"varint,7,opt,name=use_cache,json=useCache,proto3" json:"use_cache,omitempty"`                       // читать из таблицы, которую обновляет analytics-service
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GetRetentionRequest) Reset() {
+	*x = GetRetentionRequest{}
+	mi := &file_analytics_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRetentionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRetentionRequest) ProtoMessage() {}
+
+func (x *GetRetentionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRetentionRequest.ProtoReflect.Descriptor instead.
+func (*GetRetentionRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetRetentionRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetRetentionRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetRetentionRequest) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *GetRetentionRequest) GetPeriods() int32 {
+	if x != nil {
+		return x.Periods
+	}
+	return 0
+}
+
+func (x *GetRetentionRequest) GetCohortEventType() string {
+	if x != nil {
+		return x.CohortEventType
+	}
+	return ""
+}
+
+func (x *GetRetentionRequest) GetReturnEventType() string {
+	if x != nil {
+		return x.ReturnEventType
+	}
+	return ""
+}
+
+func (x *GetRetentionRequest) GetUseCache() bool {
+	if x != nil {
+		return x.UseCache
+	}
+	return false
+}
+
+type RetentionCohort struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CohortStart    *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=cohort_start,json=cohortStart,proto3" json:"cohort_start,omitempty"`
+	Size           int64                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Retained       []int64                `protobuf:"varint,3,rep,packed,name=retained,proto3" json:"retained,omitempty"` // retained[n] - вернувшиеся в период n
+	RetentionRates []float64              `protobuf:"fixed64,4,rep,packed,name=retention_rates,json=retentionRates,proto3" json:"retention_rates,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RetentionCohort) Reset() {
+	*x = RetentionCohort{}
+	mi := &file_analytics_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetentionCohort) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetentionCohort) ProtoMessage() {}
+
+func (x *RetentionCohort) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetentionCohort.ProtoReflect.Descriptor instead.
+func (*RetentionCohort) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RetentionCohort) GetCohortStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CohortStart
+	}
+	return nil
+}
+
+func (x *RetentionCohort) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *RetentionCohort) GetRetained() []int64 {
+	if x != nil {
+		return x.Retained
+	}
+	return nil
+}
+
+func (x *RetentionCohort) GetRetentionRates() []float64 {
+	if x != nil {
+		return x.RetentionRates
+	}
+	return nil
+}
+
+type GetRetentionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cohorts       []*RetentionCohort     `protobuf:"bytes,1,rep,name=cohorts,proto3" json:"cohorts,omitempty"`
+	Period        string                 `protobuf:"bytes,2,opt,name=period,proto3" json:"period,omitempty"`
+	FromCache     bool                   `protobuf:"varint,3,opt,name=from_cache,json=fromCache,proto3" json:"from_cache,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRetentionResponse) Reset() {
+	*x = GetRetentionResponse{}
+	mi := &file_analytics_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRetentionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRetentionResponse) ProtoMessage() {}
+
+func (x *GetRetentionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRetentionResponse.ProtoReflect.Descriptor instead.
+func (*GetRetentionResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetRetentionResponse) GetCohorts() []*RetentionCohort {
+	if x != nil {
+		return x.Cohorts
+	}
+	return nil
+}
+
+func (x *GetRetentionResponse) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *GetRetentionResponse) GetFromCache() bool {
+	if x != nil {
+		return x.FromCache
+	}
+	return false
+}
+
+type GetSessionStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Granularity   string                 `protobuf:"bytes,3,opt,name=granularity,proto3" json:"granularity,omitempty"` // "hour", "day" (default), "week", "month"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSessionStatsRequest) Reset() {
+	*x = GetSessionStatsRequest{}
+	mi := &file_analytics_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSessionStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSessionStatsRequest) ProtoMessage() {}
+
+func (x *GetSessionStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSessionStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetSessionStatsRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetSessionStatsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetSessionStatsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetSessionStatsRequest) GetGranularity() string {
+	if x != nil {
+		return x.Granularity
+	}
+	return ""
+}
+
+type SessionStats struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp           *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Sessions            int64                  `protobuf:"varint,2,opt,name=sessions,proto3" json:"sessions,omitempty"`
+	Users               int64                  `protobuf:"varint,3,opt,name=users,proto3" json:"users,omitempty"`
+	AvgDuration         *durationpb.Duration   `protobuf:"bytes,4,opt,name=avg_duration,json=avgDuration,proto3" json:"avg_duration,omitempty"`
+	BounceRate          float64                `protobuf:"fixed64,5,opt,name=bounce_rate,json=bounceRate,proto3" json:"bounce_rate,omitempty"`
+	ConversionRate      float64                `protobuf:"fixed64,6,opt,name=conversion_rate,json=conversionRate,proto3" json:"conversion_rate,omitempty"`
+	SessionsPerUser     float64                `protobuf:"fixed64,7,opt,name=sessions_per_user,json=sessionsPerUser,proto3" json:"sessions_per_user,omitempty"`
+	AvgEventsPerSession float64                `protobuf:"fixed64,8,opt,name=avg_events_per_session,json=avgEventsPerSession,proto3" json:"avg_events_per_session,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *SessionStats) Reset() {
+	*x = SessionStats{}
+	mi := &file_analytics_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionStats) ProtoMessage() {}
+
+func (x *SessionStats) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionStats.ProtoReflect.Descriptor instead.
+func (*SessionStats) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SessionStats) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *SessionStats) GetSessions() int64 {
+	if x != nil {
+		return x.Sessions
+	}
+	return 0
+}
+
+func (x *SessionStats) GetUsers() int64 {
+	if x != nil {
+		return x.Users
+	}
+	return 0
+}
+
+func (x *SessionStats) GetAvgDuration() *durationpb.Duration {
+	if x != nil {
+		return x.AvgDuration
+	}
+	return nil
+}
+
+func (x *SessionStats) GetBounceRate() float64 {
+	if x != nil {
+		return x.BounceRate
+	}
+	return 0
+}
+
+func (x *SessionStats) GetConversionRate() float64 {
+	if x != nil {
+		return x.ConversionRate
+	}
+	return 0
+}
+
+func (x *SessionStats) GetSessionsPerUser() float64 {
+	if x != nil {
+		return x.SessionsPerUser
+	}
+	return 0
+}
+
+func (x *SessionStats) GetAvgEventsPerSession() float64 {
+	if x != nil {
+		return x.AvgEventsPerSession
+	}
+	return 0
+}
+
+type GetSessionStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stats         []*SessionStats        `protobuf:"bytes,1,rep,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSessionStatsResponse) Reset() {
+	*x = GetSessionStatsResponse{}
+	mi := &file_analytics_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSessionStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSessionStatsResponse) ProtoMessage() {}
+
+func (x *GetSessionStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSessionStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetSessionStatsResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GetSessionStatsResponse) GetStats() []*SessionStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type GetRevenueStatsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	From           *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To             *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Granularity    string                 `protobuf:"bytes,3,opt,name=granularity,proto3" json:"granularity,omitempty"`                              // "hour", "day" (default), "week", "month"
+	BreakdownLimit int32                  `protobuf:"varint,4,opt,name=breakdown_limit,json=breakdownLimit,proto3" json:"breakdown_limit,omitempty"` // сколько товаров и категорий вернуть, по умолчанию 10
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetRevenueStatsRequest) Reset() {
+	*x = GetRevenueStatsRequest{}
+	mi := &file_analytics_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRevenueStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRevenueStatsRequest) ProtoMessage() {}
+
+func (x *GetRevenueStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRevenueStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetRevenueStatsRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetRevenueStatsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetRevenueStatsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *GetRevenueStatsRequest) GetGranularity() string {
+	if x != nil {
+		return x.Granularity
+	}
+	return ""
+}
+
+func (x *GetRevenueStatsRequest) GetBreakdownLimit() int32 {
+	if x != nil {
+		return x.BreakdownLimit
+	}
+	return 0
+}
+
+type RevenueStats struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Revenue           float64                `protobuf:"fixed64,2,opt,name=revenue,proto3" json:"revenue,omitempty"`
+	Orders            int64                  `protobuf:"varint,3,opt,name=orders,proto3" json:"orders,omitempty"`
+	Items             int64                  `protobuf:"varint,4,opt,name=items,proto3" json:"items,omitempty"`
+	Buyers            int64                  `protobuf:"varint,5,opt,name=buyers,proto3" json:"buyers,omitempty"` // уникальные покупатели
+	AverageOrderValue float64                `protobuf:"fixed64,6,opt,name=average_order_value,json=averageOrderValue,proto3" json:"average_order_value,omitempty"`
+	RevenuePerUser    float64                `protobuf:"fixed64,7,opt,name=revenue_per_user,json=revenuePerUser,proto3" json:"revenue_per_user,omitempty"` // выручка / уникальные покупатели
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *RevenueStats) Reset() {
+	*x = RevenueStats{}
+	mi := &file_analytics_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevenueStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevenueStats) ProtoMessage() {}
+
+func (x *RevenueStats) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevenueStats.ProtoReflect.Descriptor instead.
+func (*RevenueStats) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RevenueStats) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *RevenueStats) GetRevenue() float64 {
+	if x != nil {
+		return x.Revenue
+	}
+	return 0
+}
+
+func (x *RevenueStats) GetOrders() int64 {
+	if x != nil {
+		return x.Orders
+	}
+	return 0
+}
+
+func (x *RevenueStats) GetItems() int64 {
+	if x != nil {
+		return x.Items
+	}
+	return 0
+}
+
+func (x *RevenueStats) GetBuyers() int64 {
+	if x != nil {
+		return x.Buyers
+	}
+	return 0
+}
+
+func (x *RevenueStats) GetAverageOrderValue() float64 {
+	if x != nil {
+		return x.AverageOrderValue
+	}
+	return 0
+}
+
+func (x *RevenueStats) GetRevenuePerUser() float64 {
+	if x != nil {
+		return x.RevenuePerUser
+	}
+	return 0
+}
+
+type RevenueBreakdown struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"` // product_id или категория
+	Revenue       float64                `protobuf:"fixed64,2,opt,name=revenue,proto3" json:"revenue,omitempty"`
+	Orders        int64                  `protobuf:"varint,3,opt,name=orders,proto3" json:"orders,omitempty"` // для категории - сумма заказов по её товарам
+	Items         int64                  `protobuf:"varint,4,opt,name=items,proto3" json:"items,omitempty"`
+	RevenueShare  float64                `protobuf:"fixed64,5,opt,name=revenue_share,json=revenueShare,proto3" json:"revenue_share,omitempty"` // доля от выручки за весь период
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevenueBreakdown) Reset() {
+	*x = RevenueBreakdown{}
+	mi := &file_analytics_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevenueBreakdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevenueBreakdown) ProtoMessage() {}
+
+func (x *RevenueBreakdown) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevenueBreakdown.ProtoReflect.Descriptor instead.
+func (*RevenueBreakdown) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RevenueBreakdown) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RevenueBreakdown) GetRevenue() float64 {
+	if x != nil {
+		return x.Revenue
+	}
+	return 0
+}
+
+func (x *RevenueBreakdown) GetOrders() int64 {
+	if x != nil {
+		return x.Orders
+	}
+	return 0
+}
+
+func (x *RevenueBreakdown) GetItems() int64 {
+	if x != nil {
+		return x.Items
+	}
+	return 0
+}
+
+func (x *RevenueBreakdown) GetRevenueShare() float64 {
+	if x != nil {
+		return x.RevenueShare
+	}
+	return 0
+}
+
+type GetRevenueStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stats         []*RevenueStats        `protobuf:"bytes,1,rep,name=stats,proto3" json:"stats,omitempty"`
+	Total         *RevenueStats          `protobuf:"bytes,2,opt,name=total,proto3" json:"total,omitempty"` // за весь период, timestamp - начало периода
+	Products      []*RevenueBreakdown    `protobuf:"bytes,3,rep,name=products,proto3" json:"products,omitempty"`
+	Categories    []*RevenueBreakdown    `protobuf:"bytes,4,rep,name=categories,proto3" json:"categories,omitempty"`
+	Currency      string                 `protobuf:"bytes,5,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRevenueStatsResponse) Reset() {
+	*x = GetRevenueStatsResponse{}
+	mi := &file_analytics_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRevenueStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRevenueStatsResponse) ProtoMessage() {}
+
+func (x *GetRevenueStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRevenueStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetRevenueStatsResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *GetRevenueStatsResponse) GetStats() []*RevenueStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+func (x *GetRevenueStatsResponse) GetTotal() *RevenueStats {
+	if x != nil {
+		return x.Total
+	}
+	return nil
+}
+
+func (x *GetRevenueStatsResponse) GetProducts() []*RevenueBreakdown {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+func (x *GetRevenueStatsResponse) GetCategories() []*RevenueBreakdown {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+func (x *GetRevenueStatsResponse) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
+type HealthCheckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckRequest) Reset() {
+	*x = HealthCheckRequest{}
+	mi := &file_analytics_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckRequest) ProtoMessage() {}
+
+func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
+func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{23}
+}
+
+type HealthCheckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Dependencies  map[string]string      `protobuf:"bytes,3,rep,name=dependencies,proto3" json:"dependencies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckResponse) Reset() {
+	*x = HealthCheckResponse{}
+	mi := &file_analytics_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckResponse) ProtoMessage() {}
+
+func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_analytics_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
+func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+	return file_analytics_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *HealthCheckResponse) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *HealthCheckResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HealthCheckResponse) GetDependencies() map[string]string {
+	if x != nil {
+		return x.Dependencies
+	}
+	return nil
+}
+
+var File_analytics_proto protoreflect.FileDescriptor
+
+const file_analytics_proto_rawDesc = "" +
+	"\n" +
+	"\x0fanalytics.proto\x12\tanalytics\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1cgoogle/protobuf/struct.proto\"\xd2\x02\n" +
+	"\x14GetEventStatsRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x03 \x01(\tR\teventType\x12 \n" +
+	"\vgranularity\x18\x04 \x01(\tR\vgranularity\x12\x19\n" +
+	"\bgroup_by\x18\x05 \x03(\tR\agroupBy\x12F\n" +
+	"\afilters\x18\x06 \x03(\v2,.analytics.GetEventStatsRequest.FiltersEntryR\afilters\x1a:\n" +
+	"\fFiltersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xec\x03\n" +
+	"\n" +
+	"EventStats\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x02 \x01(\tR\teventType\x12!\n" +
+	"\ftotal_events\x18\x03 \x01(\x03R\vtotalEvents\x12!\n" +
+	"\funique_users\x18\x04 \x01(\x03R\vuniqueUsers\x12C\n" +
+	"\bmetadata\x18\x05 \x03(\v2#.analytics.EventStats.MetadataEntryB\x02\x18\x01R\bmetadata\x127\n" +
+	"\n" +
+	"properties\x18\x06 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"properties\x12E\n" +
+	"\n" +
+	"dimensions\x18\a \x03(\v2%.analytics.EventStats.DimensionsEntryR\n" +
+	"dimensions\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a=\n" +
+	"\x0fDimensionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"e\n" +
+	"\x15GetEventStatsResponse\x12+\n" +
+	"\x05stats\x18\x01 \x03(\v2\x15.analytics.EventStatsR\x05stats\x12\x1f\n" +
+	"\vtotal_count\x18\x02 \x01(\x03R\n" +
+	"totalCount\"\x9d\x01\n" +
+	"\x1aSubscribeEventStatsRequest\x12\x1f\n" +
+	"\vevent_types\x18\x01 \x03(\tR\n" +
+	"eventTypes\x12<\n" +
+	"\fmin_interval\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\vminInterval\x12 \n" +
+	"\vgranularity\x18\x03 \x01(\tR\vgranularity\"\xc6\x01\n" +
 	"\x16GetUserActivityRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12.\n" +
 	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
 	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
-	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\x9b\x02\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\x12!\n" +
+	"\fanonymous_id\x18\x05 \x01(\tR\vanonymousId\"\xfb\x02\n" +
 	"\tUserEvent\x12\x19\n" +
 	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x1d\n" +
 	"\n" +
 	"event_type\x18\x02 \x01(\tR\teventType\x128\n" +
 	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1d\n" +
 	"\n" +
-	"product_id\x18\x04 \x01(\tR\tproductId\x12>\n" +
-	"\bmetadata\x18\x05 \x03(\v2\".analytics.UserEvent.MetadataEntryR\bmetadata\x1a;\n" +
+	"product_id\x18\x04 \x01(\tR\tproductId\x12B\n" +
+	"\bmetadata\x18\x05 \x03(\v2\".analytics.UserEvent.MetadataEntryB\x02\x18\x01R\bmetadata\x127\n" +
+	"\n" +
+	"properties\x18\x06 \x01(\v2\x17.google.protobuf.StructR\n" +
+	"properties\x12!\n" +
+	"\fanonymous_id\x18\a \x01(\tR\vanonymousId\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x83\x01\n" +
 	"\x17GetUserActivityResponse\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12,\n" +
 	"\x06events\x18\x02 \x03(\v2\x14.analytics.UserEventR\x06events\x12!\n" +
-	"\ftotal_events\x18\x03 \x01(\x03R\vtotalEvents\"\xa8\x01\n" +
+	"\ftotal_events\x18\x03 \x01(\x03R\vtotalEvents\"\xc1\x01\n" +
 	"\x15GetTopProductsRequest\x12.\n" +
 	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
 	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
 	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x1d\n" +
 	"\n" +
-	"event_type\x18\x04 \x01(\tR\teventType\"\x9a\x02\n" +
+	"event_type\x18\x04 \x01(\tR\teventType\x12\x17\n" +
+	"\arank_by\x18\x05 \x01(\tR\x06rankBy\"\xb3\x03\n" +
 	"\fProductStats\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\tR\tproductId\x12\x1f\n" +
@@ -765,12 +1900,97 @@ const file_analytics_proto_rawDesc = "" +
 	"eventCount\x12!\n" +
 	"\funique_users\x18\x03 \x01(\x03R\vuniqueUsers\x12'\n" +
 	"\x0fconversion_rate\x18\x04 \x01(\x01R\x0econversionRate\x12A\n" +
-	"\bmetadata\x18\x05 \x03(\v2%.analytics.ProductStats.MetadataEntryR\bmetadata\x1a;\n" +
+	"\bmetadata\x18\x05 \x03(\v2%.analytics.ProductStats.MetadataEntryR\bmetadata\x12\x14\n" +
+	"\x05views\x18\x06 \x01(\x03R\x05views\x12 \n" +
+	"\fadd_to_carts\x18\a \x01(\x03R\n" +
+	"addToCarts\x12\x1c\n" +
+	"\tpurchases\x18\b \x01(\x03R\tpurchases\x12'\n" +
+	"\x10add_to_cart_rate\x18\t \x01(\x01R\raddToCartRate\x12\x18\n" +
+	"\arevenue\x18\n" +
+	" \x01(\x01R\arevenue\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"M\n" +
 	"\x16GetTopProductsResponse\x123\n" +
-	"\bproducts\x18\x01 \x03(\v2\x17.analytics.ProductStatsR\bproducts\"\x14\n" +
+	"\bproducts\x18\x01 \x03(\v2\x17.analytics.ProductStatsR\bproducts\"\xe7\x01\n" +
+	"\x10GetFunnelRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
+	"\x05steps\x18\x03 \x03(\tR\x05steps\x12F\n" +
+	"\x11conversion_window\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\x10conversionWindow\x12\x19\n" +
+	"\bcount_by\x18\x05 \x01(\tR\acountBy\"\xf8\x01\n" +
+	"\n" +
+	"FunnelStep\x12\x1d\n" +
+	"\n" +
+	"event_type\x18\x01 \x01(\tR\teventType\x12\x14\n" +
+	"\x05users\x18\x02 \x01(\x03R\x05users\x12'\n" +
+	"\x0fconversion_rate\x18\x03 \x01(\x01R\x0econversionRate\x126\n" +
+	"\x17overall_conversion_rate\x18\x04 \x01(\x01R\x15overallConversionRate\x12T\n" +
+	"\x19median_time_from_previous\x18\x05 \x01(\v2\x19.google.protobuf.DurationR\x16medianTimeFromPrevious\"[\n" +
+	"\x11GetFunnelResponse\x12+\n" +
+	"\x05steps\x18\x01 \x03(\v2\x15.analytics.FunnelStepR\x05steps\x12\x19\n" +
+	"\bcount_by\x18\x02 \x01(\tR\acountBy\"\x98\x02\n" +
+	"\x13GetRetentionRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x16\n" +
+	"\x06period\x18\x03 \x01(\tR\x06period\x12\x18\n" +
+	"\aperiods\x18\x04 \x01(\x05R\aperiods\x12*\n" +
+	"\x11cohort_event_type\x18\x05 \x01(\tR\x0fcohortEventType\x12*\n" +
+	"\x11return_event_type\x18\x06 \x01(\tR\x0freturnEventType\x12\x1b\n" +
+	"\tuse_cache\x18\a \x01(\bR\buseCache\"\xa9\x01\n" +
+	"\x0fRetentionCohort\x12=\n" +
+	"\fcohort_start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\vcohortStart\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x03R\x04size\x12\x1a\n" +
+	"\bretained\x18\x03 \x03(\x03R\bretained\x12'\n" +
+	"\x0fretention_rates\x18\x04 \x03(\x01R\x0eretentionRates\"\x83\x01\n" +
+	"\x14GetRetentionResponse\x124\n" +
+	"\acohorts\x18\x01 \x03(\v2\x1a.analytics.RetentionCohortR\acohorts\x12\x16\n" +
+	"\x06period\x18\x02 \x01(\tR\x06period\x12\x1d\n" +
+	"\n" +
+	"from_cache\x18\x03 \x01(\bR\tfromCache\"\x96\x01\n" +
+	"\x16GetSessionStatsRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12 \n" +
+	"\vgranularity\x18\x03 \x01(\tR\vgranularity\"\xe3\x02\n" +
+	"\fSessionStats\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1a\n" +
+	"\bsessions\x18\x02 \x01(\x03R\bsessions\x12\x14\n" +
+	"\x05users\x18\x03 \x01(\x03R\x05users\x12<\n" +
+	"\favg_duration\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\vavgDuration\x12\x1f\n" +
+	"\vbounce_rate\x18\x05 \x01(\x01R\n" +
+	"bounceRate\x12'\n" +
+	"\x0fconversion_rate\x18\x06 \x01(\x01R\x0econversionRate\x12*\n" +
+	"\x11sessions_per_user\x18\a \x01(\x01R\x0fsessionsPerUser\x123\n" +
+	"\x16avg_events_per_session\x18\b \x01(\x01R\x13avgEventsPerSession\"H\n" +
+	"\x17GetSessionStatsResponse\x12-\n" +
+	"\x05stats\x18\x01 \x03(\v2\x17.analytics.SessionStatsR\x05stats\"\xbf\x01\n" +
+	"\x16GetRevenueStatsRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12 \n" +
+	"\vgranularity\x18\x03 \x01(\tR\vgranularity\x12'\n" +
+	"\x0fbreakdown_limit\x18\x04 \x01(\x05R\x0ebreakdownLimit\"\x82\x02\n" +
+	"\fRevenueStats\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x18\n" +
+	"\arevenue\x18\x02 \x01(\x01R\arevenue\x12\x16\n" +
+	"\x06orders\x18\x03 \x01(\x03R\x06orders\x12\x14\n" +
+	"\x05items\x18\x04 \x01(\x03R\x05items\x12\x16\n" +
+	"\x06buyers\x18\x05 \x01(\x03R\x06buyers\x12.\n" +
+	"\x13average_order_value\x18\x06 \x01(\x01R\x11averageOrderValue\x12(\n" +
+	"\x10revenue_per_user\x18\a \x01(\x01R\x0erevenuePerUser\"\x91\x01\n" +
+	"\x10RevenueBreakdown\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\arevenue\x18\x02 \x01(\x01R\arevenue\x12\x16\n" +
+	"\x06orders\x18\x03 \x01(\x03R\x06orders\x12\x14\n" +
+	"\x05items\x18\x04 \x01(\x03R\x05items\x12#\n" +
+	"\rrevenue_share\x18\x05 \x01(\x01R\frevenueShare\"\x89\x02\n" +
+	"\x17GetRevenueStatsResponse\x12-\n" +
+	"\x05stats\x18\x01 \x03(\v2\x17.analytics.RevenueStatsR\x05stats\x12-\n" +
+	"\x05total\x18\x02 \x01(\v2\x17.analytics.RevenueStatsR\x05total\x127\n" +
+	"\bproducts\x18\x03 \x03(\v2\x1b.analytics.RevenueBreakdownR\bproducts\x12;\n" +
+	"\n" +
+	"categories\x18\x04 \x03(\v2\x1b.analytics.RevenueBreakdownR\n" +
+	"categories\x12\x1a\n" +
+	"\bcurrency\x18\x05 \x01(\tR\bcurrency\"\x14\n" +
 	"\x12HealthCheckRequest\"\xde\x01\n" +
 	"\x13HealthCheckResponse\x12\x18\n" +
 	"\ahealthy\x18\x01 \x01(\bR\ahealthy\x12\x16\n" +
@@ -778,11 +1998,16 @@ const file_analytics_proto_rawDesc = "" +
 	"\fdependencies\x18\x03 \x03(\v20.analytics.HealthCheckResponse.DependenciesEntryR\fdependencies\x1a?\n" +
 	"\x11DependenciesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xe1\x02\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\x85\x06\n" +
 	"\fQueryService\x12R\n" +
 	"\rGetEventStats\x12\x1f.analytics.GetEventStatsRequest\x1a .analytics.GetEventStatsResponse\x12X\n" +
 	"\x0fGetUserActivity\x12!.analytics.GetUserActivityRequest\x1a\".analytics.GetUserActivityResponse\x12U\n" +
-	"\x0eGetTopProducts\x12 .analytics.GetTopProductsRequest\x1a!.analytics.GetTopProductsResponse\x12L\n" +
+	"\x0eGetTopProducts\x12 .analytics.GetTopProductsRequest\x1a!.analytics.GetTopProductsResponse\x12F\n" +
+	"\tGetFunnel\x12\x1b.analytics.GetFunnelRequest\x1a\x1c.analytics.GetFunnelResponse\x12O\n" +
+	"\fGetRetention\x12\x1e.analytics.GetRetentionRequest\x1a\x1f.analytics.GetRetentionResponse\x12U\n" +
+	"\x13SubscribeEventStats\x12%.analytics.SubscribeEventStatsRequest\x1a\x15.analytics.EventStats0\x01\x12X\n" +
+	"\x0fGetSessionStats\x12!.analytics.GetSessionStatsRequest\x1a\".analytics.GetSessionStatsResponse\x12X\n" +
+	"\x0fGetRevenueStats\x12!.analytics.GetRevenueStatsRequest\x1a\".analytics.GetRevenueStatsResponse\x12L\n" +
 	"\vHealthCheck\x12\x1d.analytics.HealthCheckRequest\x1a\x1e.analytics.HealthCheckResponseB;Z9github.com/Wuchinator/realtime-analytics/pkg/pb/analyticsb\x06proto3"
 
 var (
@@ -797,54 +2022,108 @@ func file_analytics_proto_rawDescGZIP() []byte {
 	return file_analytics_proto_rawDescData
 }
 
-var file_analytics_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_analytics_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
 var file_analytics_proto_goTypes = []any{
-	(*GetEventStatsRequest)(nil),    // 0: analytics.GetEventStatsRequest
-	(*EventStats)(nil),              // 1: analytics.EventStats
-	(*GetEventStatsResponse)(nil),   // 2: analytics.GetEventStatsResponse
-	(*GetUserActivityRequest)(nil),  // 3: analytics.GetUserActivityRequest
-	(*UserEvent)(nil),               // 4: analytics.UserEvent
-	(*GetUserActivityResponse)(nil), // 5: analytics.GetUserActivityResponse
-	(*GetTopProductsRequest)(nil),   // 6: analytics.GetTopProductsRequest
-	(*ProductStats)(nil),            // 7: analytics.ProductStats
-	(*GetTopProductsResponse)(nil),  // 8: analytics.GetTopProductsResponse
-	(*HealthCheckRequest)(nil),      // 9: analytics.HealthCheckRequest
-	(*HealthCheckResponse)(nil),     // 10: analytics.HealthCheckResponse
-	nil,                             // 11: analytics.EventStats.MetadataEntry
-	nil,                             // 12: analytics.UserEvent.MetadataEntry
-	nil,                             // 13: analytics.ProductStats.MetadataEntry
-	nil,                             // 14: analytics.HealthCheckResponse.DependenciesEntry
-	(*timestamppb.Timestamp)(nil),   // 15: google.protobuf.Timestamp
+	(*GetEventStatsRequest)(nil),       // 0: analytics.GetEventStatsRequest
+	(*EventStats)(nil),                 // 1: analytics.EventStats
+	(*GetEventStatsResponse)(nil),      // 2: analytics.GetEventStatsResponse
+	(*SubscribeEventStatsRequest)(nil), // 3: analytics.SubscribeEventStatsRequest
+	(*GetUserActivityRequest)(nil),     // 4: analytics.GetUserActivityRequest
+	(*UserEvent)(nil),                  // 5: analytics.UserEvent
+	(*GetUserActivityResponse)(nil),    // 6: analytics.GetUserActivityResponse
+	(*GetTopProductsRequest)(nil),      // 7: analytics.GetTopProductsRequest
+	(*ProductStats)(nil),               // 8: analytics.ProductStats
+	(*GetTopProductsResponse)(nil),     // 9: analytics.GetTopProductsResponse
+	(*GetFunnelRequest)(nil),           // 10: analytics.GetFunnelRequest
+	(*FunnelStep)(nil),                 // 11: analytics.FunnelStep
+	(*GetFunnelResponse)(nil),          // 12: analytics.GetFunnelResponse
+	(*GetRetentionRequest)(nil),        // 13: analytics.GetRetentionRequest
+	(*RetentionCohort)(nil),            // 14: analytics.RetentionCohort
+	(*GetRetentionResponse)(nil),       // 15: analytics.GetRetentionResponse
+	(*GetSessionStatsRequest)(nil),     // 16: analytics.GetSessionStatsRequest
+	(*SessionStats)(nil),               // 17: analytics.SessionStats
+	(*GetSessionStatsResponse)(nil),    // 18: analytics.GetSessionStatsResponse
+	(*GetRevenueStatsRequest)(nil),     // 19: analytics.GetRevenueStatsRequest
+	(*RevenueStats)(nil),               // 20: analytics.RevenueStats
+	(*RevenueBreakdown)(nil),           // 21: analytics.RevenueBreakdown
+	(*GetRevenueStatsResponse)(nil),    // 22: analytics.GetRevenueStatsResponse
+	(*HealthCheckRequest)(nil),         // 23: analytics.HealthCheckRequest
+	(*HealthCheckResponse)(nil),        // 24: analytics.HealthCheckResponse
+	nil,                                // 25: analytics.GetEventStatsRequest.FiltersEntry
+	nil,                                // 26: analytics.EventStats.MetadataEntry
+	nil,                                // 27: analytics.EventStats.DimensionsEntry
+	nil,                                // 28: analytics.UserEvent.MetadataEntry
+	nil,                                // 29: analytics.ProductStats.MetadataEntry
+	nil,                                // 30: analytics.HealthCheckResponse.DependenciesEntry
+	(*timestamppb.Timestamp)(nil),      // 31: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),            // 32: google.protobuf.Struct
+	(*durationpb.Duration)(nil),        // 33: google.protobuf.Duration
 }
 var file_analytics_proto_depIdxs = []int32{
-	15, // 0: analytics.GetEventStatsRequest.from:type_name -> google.protobuf.Timestamp
-	15, // 1: analytics.GetEventStatsRequest.to:type_name -> google.protobuf.Timestamp
-	15, // 2: analytics.EventStats.timestamp:type_name -> google.protobuf.Timestamp
-	11, // 3: analytics.EventStats.metadata:type_name -> analytics.EventStats.MetadataEntry
-	1,  // 4: analytics.GetEventStatsResponse.stats:type_name -> analytics.EventStats
-	15, // 5: analytics.GetUserActivityRequest.from:type_name -> google.protobuf.Timestamp
-	15, // 6: analytics.GetUserActivityRequest.to:type_name -> google.protobuf.Timestamp
-	15, // 7: analytics.UserEvent.timestamp:type_name -> google.protobuf.Timestamp
-	12, // 8: analytics.UserEvent.metadata:type_name -> analytics.UserEvent.MetadataEntry
-	4,  // 9: analytics.GetUserActivityResponse.events:type_name -> analytics.UserEvent
-	15, // 10: analytics.GetTopProductsRequest.from:type_name -> google.protobuf.Timestamp
-	15, // 11: analytics.GetTopProductsRequest.to:type_name -> google.protobuf.Timestamp
-	13, // 12: analytics.ProductStats.metadata:type_name -> analytics.ProductStats.MetadataEntry
-	7,  // 13: analytics.GetTopProductsResponse.products:type_name -> analytics.ProductStats
-	14, // 14: analytics.HealthCheckResponse.dependencies:type_name -> analytics.HealthCheckResponse.DependenciesEntry
-	0,  // 15: analytics.QueryService.GetEventStats:input_type -> analytics.GetEventStatsRequest
-	3,  // 16: analytics.QueryService.GetUserActivity:input_type -> analytics.GetUserActivityRequest
-	6,  // 17: analytics.QueryService.GetTopProducts:input_type -> analytics.GetTopProductsRequest
-	9,  // 18: analytics.QueryService.HealthCheck:input_type -> analytics.HealthCheckRequest
-	2,  // 19: analytics.QueryService.GetEventStats:output_type -> analytics.GetEventStatsResponse
-	5,  // 20: analytics.QueryService.GetUserActivity:output_type -> analytics.GetUserActivityResponse
-	8,  // 21: analytics.QueryService.GetTopProducts:output_type -> analytics.GetTopProductsResponse
-	10, // 22: analytics.QueryService.HealthCheck:output_type -> analytics.HealthCheckResponse
-	19, // [19:23] is the sub-list for method output_type
-	15, // [15:19] is the sub-list for method input_type
-	15, // [15:15] is the sub-list for extension type_name
-	15, // [15:15] is the sub-list for extension extendee
-	0,  // [0:15] is the sub-list for field type_name
+	31, // 0: analytics.GetEventStatsRequest.from:type_name -> google.protobuf.Timestamp
+	31, // 1: analytics.GetEventStatsRequest.to:type_name -> google.protobuf.Timestamp
+	25, // 2: analytics.GetEventStatsRequest.filters:type_name -> analytics.GetEventStatsRequest.FiltersEntry
+	31, // 3: analytics.EventStats.timestamp:type_name -> google.protobuf.Timestamp
+	26, // 4: analytics.EventStats.metadata:type_name -> analytics.EventStats.MetadataEntry
+	32, // 5: analytics.EventStats.properties:type_name -> google.protobuf.Struct
+	27, // 6: analytics.EventStats.dimensions:type_name -> analytics.EventStats.DimensionsEntry
+	1,  // 7: analytics.GetEventStatsResponse.stats:type_name -> analytics.EventStats
+	33, // 8: analytics.SubscribeEventStatsRequest.min_interval:type_name -> google.protobuf.Duration
+	31, // 9: analytics.GetUserActivityRequest.from:type_name -> google.protobuf.Timestamp
+	31, // 10: analytics.GetUserActivityRequest.to:type_name -> google.protobuf.Timestamp
+	31, // 11: analytics.UserEvent.timestamp:type_name -> google.protobuf.Timestamp
+	28, // 12: analytics.UserEvent.metadata:type_name -> analytics.UserEvent.MetadataEntry
+	32, // 13: analytics.UserEvent.properties:type_name -> google.protobuf.Struct
+	5,  // 14: analytics.GetUserActivityResponse.events:type_name -> analytics.UserEvent
+	31, // 15: analytics.GetTopProductsRequest.from:type_name -> google.protobuf.Timestamp
+	31, // 16: analytics.GetTopProductsRequest.to:type_name -> google.protobuf.Timestamp
+	29, // 17: analytics.ProductStats.metadata:type_name -> analytics.ProductStats.MetadataEntry
+	8,  // 18: analytics.GetTopProductsResponse.products:type_name -> analytics.ProductStats
+	31, // 19: analytics.GetFunnelRequest.from:type_name -> google.protobuf.Timestamp
+	31, // 20: analytics.GetFunnelRequest.to:type_name -> google.protobuf.Timestamp
+	33, // 21: analytics.GetFunnelRequest.conversion_window:type_name -> google.protobuf.Duration
+	33, // 22: analytics.FunnelStep.median_time_from_previous:type_name -> google.protobuf.Duration
+	11, // 23: analytics.GetFunnelResponse.steps:type_name -> analytics.FunnelStep
+	31, // 24: analytics.GetRetentionRequest.from:type_name -> google.protobuf.Timestamp
+	31, // 25: analytics.GetRetentionRequest.to:type_name -> google.protobuf.Timestamp
+	31, // 26: analytics.RetentionCohort.cohort_start:type_name -> google.protobuf.Timestamp
+	14, // 27: analytics.GetRetentionResponse.cohorts:type_name -> analytics.RetentionCohort
+	31, // 28: analytics.GetSessionStatsRequest.from:type_name -> google.protobuf.Timestamp
+	31, // 29: analytics.GetSessionStatsRequest.to:type_name -> google.protobuf.Timestamp
+	31, // 30: analytics.SessionStats.timestamp:type_name -> google.protobuf.Timestamp
+	33, // 31: analytics.SessionStats.avg_duration:type_name -> google.protobuf.Duration
+	17, // 32: analytics.GetSessionStatsResponse.stats:type_name -> analytics.SessionStats
+	31, // 33: analytics.GetRevenueStatsRequest.from:type_name -> google.protobuf.Timestamp
+	31, // 34: analytics.GetRevenueStatsRequest.to:type_name -> google.protobuf.Timestamp
+	31, // 35: analytics.RevenueStats.timestamp:type_name -> google.protobuf.Timestamp
+	20, // 36: analytics.GetRevenueStatsResponse.stats:type_name -> analytics.RevenueStats
+	20, // 37: analytics.GetRevenueStatsResponse.total:type_name -> analytics.RevenueStats
+	21, // 38: analytics.GetRevenueStatsResponse.products:type_name -> analytics.RevenueBreakdown
+	21, // 39: analytics.GetRevenueStatsResponse.categories:type_name -> analytics.RevenueBreakdown
+	30, // 40: analytics.HealthCheckResponse.dependencies:type_name -> analytics.HealthCheckResponse.DependenciesEntry
+	0,  // 41: analytics.QueryService.GetEventStats:input_type -> analytics.GetEventStatsRequest
+	4,  // 42: analytics.QueryService.GetUserActivity:input_type -> analytics.GetUserActivityRequest
+	7,  // 43: analytics.QueryService.GetTopProducts:input_type -> analytics.GetTopProductsRequest
+	10, // 44: analytics.QueryService.GetFunnel:input_type -> analytics.GetFunnelRequest
+	13, // 45: analytics.QueryService.GetRetention:input_type -> analytics.GetRetentionRequest
+	3,  // 46: analytics.QueryService.SubscribeEventStats:input_type -> analytics.SubscribeEventStatsRequest
+	16, // 47: analytics.QueryService.GetSessionStats:input_type -> analytics.GetSessionStatsRequest
+	19, // 48: analytics.QueryService.GetRevenueStats:input_type -> analytics.GetRevenueStatsRequest
+	23, // 49: analytics.QueryService.HealthCheck:input_type -> analytics.HealthCheckRequest
+	2,  // 50: analytics.QueryService.GetEventStats:output_type -> analytics.GetEventStatsResponse
+	6,  // 51: analytics.QueryService.GetUserActivity:output_type -> analytics.GetUserActivityResponse
+	9,  // 52: analytics.QueryService.GetTopProducts:output_type -> analytics.GetTopProductsResponse
+	12, // 53: analytics.QueryService.GetFunnel:output_type -> analytics.GetFunnelResponse
+	15, // 54: analytics.QueryService.GetRetention:output_type -> analytics.GetRetentionResponse
+	1,  // 55: analytics.QueryService.SubscribeEventStats:output_type -> analytics.EventStats
+	18, // 56: analytics.QueryService.GetSessionStats:output_type -> analytics.GetSessionStatsResponse
+	22, // 57: analytics.QueryService.GetRevenueStats:output_type -> analytics.GetRevenueStatsResponse
+	24, // 58: analytics.QueryService.HealthCheck:output_type -> analytics.HealthCheckResponse
+	50, // [50:59] is the sub-list for method output_type
+	41, // [41:50] is the sub-list for method input_type
+	41, // [41:41] is the sub-list for extension type_name
+	41, // [41:41] is the sub-list for extension extendee
+	0,  // [0:41] is the sub-list for field type_name
 }
 
 func init() { file_analytics_proto_init() }
@@ -858,7 +2137,7 @@ func file_analytics_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_analytics_proto_rawDesc), len(file_analytics_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   15,
+			NumMessages:   31,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,10 +19,15 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	QueryService_GetEventStats_FullMethodName   = "/analytics.QueryService/GetEventStats"
-	QueryService_GetUserActivity_FullMethodName = "/analytics.QueryService/GetUserActivity"
-	QueryService_GetTopProducts_FullMethodName  = "/analytics.QueryService/GetTopProducts"
-	QueryService_HealthCheck_FullMethodName     = "/analytics.QueryService/HealthCheck"
+	QueryService_GetEventStats_FullMethodName       = "/analytics.QueryService/GetEventStats"
+	QueryService_GetUserActivity_FullMethodName     = "/analytics.QueryService/GetUserActivity"
+	QueryService_GetTopProducts_FullMethodName      = "/analytics.QueryService/GetTopProducts"
+	QueryService_GetFunnel_FullMethodName           = "/analytics.QueryService/GetFunnel"
+	QueryService_GetRetention_FullMethodName        = "/analytics.QueryService/GetRetention"
+	QueryService_SubscribeEventStats_FullMethodName = "/analytics.QueryService/SubscribeEventStats"
+	QueryService_GetSessionStats_FullMethodName     = "/analytics.QueryService/GetSessionStats"
+	QueryService_GetRevenueStats_FullMethodName     = "/analytics.QueryService/GetRevenueStats"
+	QueryService_HealthCheck_FullMethodName         = "/analytics.QueryService/HealthCheck"
 )
 
 // QueryServiceClient is the client API for QueryService service.
@@ -32,6 +37,11 @@ type QueryServiceClient interface {
 	GetEventStats(ctx context.Context, in *GetEventStatsRequest, opts ...grpc.CallOption) (*GetEventStatsResponse, error)
 	GetUserActivity(ctx context.Context, in *GetUserActivityRequest, opts ...grpc.CallOption) (*GetUserActivityResponse, error)
 	GetTopProducts(ctx context.Context, in *GetTopProductsRequest, opts ...grpc.CallOption) (*GetTopProductsResponse, error)
+	GetFunnel(ctx context.Context, in *GetFunnelRequest, opts ...grpc.CallOption) (*GetFunnelResponse, error)
+	GetRetention(ctx context.Context, in *GetRetentionRequest, opts ...grpc.CallOption) (*GetRetentionResponse, error)
+	SubscribeEventStats(ctx context.Context, in *SubscribeEventStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EventStats], error)
+	GetSessionStats(ctx context.Context, in *GetSessionStatsRequest, opts ...grpc.CallOption) (*GetSessionStatsResponse, error)
+	GetRevenueStats(ctx context.Context, in *GetRevenueStatsRequest, opts ...grpc.CallOption) (*GetRevenueStatsResponse, error)
 	HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error)
 }
 
@@ -73,6 +83,65 @@ func (c *queryServiceClient) GetTopProducts(ctx context.Context, in *GetTopProdu
 	return out, nil
 }
 
+func (c *queryServiceClient) GetFunnel(ctx context.Context, in *GetFunnelRequest, opts ...grpc.CallOption) (*GetFunnelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetFunnelResponse)
+	err := c.cc.Invoke(ctx, QueryService_GetFunnel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryServiceClient) GetRetention(ctx context.Context, in *GetRetentionRequest, opts ...grpc.CallOption) (*GetRetentionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRetentionResponse)
+	err := c.cc.Invoke(ctx, QueryService_GetRetention_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryServiceClient) SubscribeEventStats(ctx context.Context, in *SubscribeEventStatsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EventStats], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &QueryService_ServiceDesc.Streams[0], QueryService_SubscribeEventStats_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeEventStatsRequest, EventStats]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueryService_SubscribeEventStatsClient = grpc.ServerStreamingClient[EventStats]
+
+func (c *queryServiceClient) GetSessionStats(ctx context.Context, in *GetSessionStatsRequest, opts ...grpc.CallOption) (*GetSessionStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSessionStatsResponse)
+	err := c.cc.Invoke(ctx, QueryService_GetSessionStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *queryServiceClient) GetRevenueStats(ctx context.Context, in *GetRevenueStatsRequest, opts ...grpc.CallOption) (*GetRevenueStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRevenueStatsResponse)
+	err := c.cc.Invoke(ctx, QueryService_GetRevenueStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *queryServiceClient) HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(HealthCheckResponse)
@@ -90,6 +159,11 @@ type QueryServiceServer interface {
 	GetEventStats(context.Context, *GetEventStatsRequest) (*GetEventStatsResponse, error)
 	GetUserActivity(context.Context, *GetUserActivityRequest) (*GetUserActivityResponse, error)
 	GetTopProducts(context.Context, *GetTopProductsRequest) (*GetTopProductsResponse, error)
+	GetFunnel(context.Context, *GetFunnelRequest) (*GetFunnelResponse, error)
+	GetRetention(context.Context, *GetRetentionRequest) (*GetRetentionResponse, error)
+	SubscribeEventStats(*SubscribeEventStatsRequest, grpc.ServerStreamingServer[EventStats]) error
+	GetSessionStats(context.Context, *GetSessionStatsRequest) (*GetSessionStatsResponse, error)
+	GetRevenueStats(context.Context, *GetRevenueStatsRequest) (*GetRevenueStatsResponse, error)
 	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
 	mustEmbedUnimplementedQueryServiceServer()
 }
@@ -110,6 +184,21 @@ func (UnimplementedQueryServiceServer) GetUserActivity(context.Context, *GetUser
 func (UnimplementedQueryServiceServer) GetTopProducts(context.Context, *GetTopProductsRequest) (*GetTopProductsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetTopProducts not implemented")
 }
+func (UnimplementedQueryServiceServer) GetFunnel(context.Context, *GetFunnelRequest) (*GetFunnelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFunnel not implemented")
+}
+func (UnimplementedQueryServiceServer) GetRetention(context.Context, *GetRetentionRequest) (*GetRetentionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRetention not implemented")
+}
+func (UnimplementedQueryServiceServer) SubscribeEventStats(*SubscribeEventStatsRequest, grpc.ServerStreamingServer[EventStats]) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeEventStats not implemented")
+}
+func (UnimplementedQueryServiceServer) GetSessionStats(context.Context, *GetSessionStatsRequest) (*GetSessionStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSessionStats not implemented")
+}
+func (UnimplementedQueryServiceServer) GetRevenueStats(context.Context, *GetRevenueStatsRequest) (*GetRevenueStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRevenueStats not implemented")
+}
 func (UnimplementedQueryServiceServer) HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method HealthCheck not implemented")
 }
@@ -188,6 +277,89 @@ func _QueryService_GetTopProducts_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _QueryService_GetFunnel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFunnelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServiceServer).GetFunnel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueryService_GetFunnel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServiceServer).GetFunnel(ctx, req.(*GetFunnelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueryService_GetRetention_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRetentionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServiceServer).GetRetention(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueryService_GetRetention_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServiceServer).GetRetention(ctx, req.(*GetRetentionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueryService_SubscribeEventStats_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeEventStatsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(QueryServiceServer).SubscribeEventStats(m, &grpc.GenericServerStream[SubscribeEventStatsRequest, EventStats]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type QueryService_SubscribeEventStatsServer = grpc.ServerStreamingServer[EventStats]
+
+func _QueryService_GetSessionStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSessionStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServiceServer).GetSessionStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueryService_GetSessionStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServiceServer).GetSessionStats(ctx, req.(*GetSessionStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _QueryService_GetRevenueStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRevenueStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QueryServiceServer).GetRevenueStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: QueryService_GetRevenueStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QueryServiceServer).GetRevenueStats(ctx, req.(*GetRevenueStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _QueryService_HealthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(HealthCheckRequest)
 	if err := dec(in); err != nil {
@@ -225,11 +397,33 @@ var QueryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTopProducts",
 			Handler:    _QueryService_GetTopProducts_Handler,
 		},
+		{
+			MethodName: "GetFunnel",
+			Handler:    _QueryService_GetFunnel_Handler,
+		},
+		{
+			MethodName: "GetRetention",
+			Handler:    _QueryService_GetRetention_Handler,
+		},
+		{
+			MethodName: "GetSessionStats",
+			Handler:    _QueryService_GetSessionStats_Handler,
+		},
+		{
+			MethodName: "GetRevenueStats",
+			Handler:    _QueryService_GetRevenueStats_Handler,
+		},
 		{
 			MethodName: "HealthCheck",
 			Handler:    _QueryService_HealthCheck_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeEventStats",
+			Handler:       _QueryService_SubscribeEventStats_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "analytics.proto",
 }
@@ -9,6 +9,7 @@ package events
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -83,15 +84,144 @@ func (EventType) EnumDescriptor() ([]byte, []int) {
 	return file_events_proto_rawDescGZIP(), []int{0}
 }
 
+type EventStatus int32
+
+const (
+	EventStatus_EVENT_STATUS_UNSPECIFIED EventStatus = 0
+	EventStatus_EVENT_STATUS_ACCEPTED    EventStatus = 1
+	// Событие с таким id уже было принято раньше
+	EventStatus_EVENT_STATUS_DUPLICATE EventStatus = 2
+	EventStatus_EVENT_STATUS_INVALID   EventStatus = 3
+	// Событие валидно, но не сохранено и не будет опубликовано
+	EventStatus_EVENT_STATUS_FAILED EventStatus = 4
+)
+
+// Enum value maps for EventStatus.
+var (
+	EventStatus_name = map[int32]string{
+		0: "EVENT_STATUS_UNSPECIFIED",
+		1: "EVENT_STATUS_ACCEPTED",
+		2: "EVENT_STATUS_DUPLICATE",
+		3: "EVENT_STATUS_INVALID",
+		4: "EVENT_STATUS_FAILED",
+	}
+	EventStatus_value = map[string]int32{
+		"EVENT_STATUS_UNSPECIFIED": 0,
+		"EVENT_STATUS_ACCEPTED":    1,
+		"EVENT_STATUS_DUPLICATE":   2,
+		"EVENT_STATUS_INVALID":     3,
+		"EVENT_STATUS_FAILED":      4,
+	}
+)
+
+func (x EventStatus) Enum() *EventStatus {
+	p := new(EventStatus)
+	*p = x
+	return p
+}
+
+func (x EventStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_events_proto_enumTypes[1].Descriptor()
+}
+
+func (EventStatus) Type() protoreflect.EnumType {
+	return &file_events_proto_enumTypes[1]
+}
+
+func (x EventStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventStatus.Descriptor instead.
+func (EventStatus) EnumDescriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{1}
+}
+
+type FieldType int32
+
+const (
+	FieldType_FIELD_TYPE_UNSPECIFIED FieldType = 0
+	FieldType_FIELD_TYPE_STRING      FieldType = 1
+	FieldType_FIELD_TYPE_NUMBER      FieldType = 2
+	FieldType_FIELD_TYPE_BOOLEAN     FieldType = 3
+	FieldType_FIELD_TYPE_UUID        FieldType = 4
+	// RFC 3339
+	FieldType_FIELD_TYPE_TIMESTAMP FieldType = 5
+)
+
+// Enum value maps for FieldType.
+var (
+	FieldType_name = map[int32]string{
+		0: "FIELD_TYPE_UNSPECIFIED",
+		1: "FIELD_TYPE_STRING",
+		2: "FIELD_TYPE_NUMBER",
+		3: "FIELD_TYPE_BOOLEAN",
+		4: "FIELD_TYPE_UUID",
+		5: "FIELD_TYPE_TIMESTAMP",
+	}
+	FieldType_value = map[string]int32{
+		"FIELD_TYPE_UNSPECIFIED": 0,
+		"FIELD_TYPE_STRING":      1,
+		"FIELD_TYPE_NUMBER":      2,
+		"FIELD_TYPE_BOOLEAN":     3,
+		"FIELD_TYPE_UUID":        4,
+		"FIELD_TYPE_TIMESTAMP":   5,
+	}
+)
+
+func (x FieldType) Enum() *FieldType {
+	p := new(FieldType)
+	*p = x
+	return p
+}
+
+func (x FieldType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FieldType) Descriptor() protoreflect.EnumDescriptor {
+	return file_events_proto_enumTypes[2].Descriptor()
+}
+
+func (FieldType) Type() protoreflect.EnumType {
+	return &file_events_proto_enumTypes[2]
+}
+
+func (x FieldType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FieldType.Descriptor instead.
+func (FieldType) EnumDescriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{2}
+}
+
 type Event struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
-	EventType     EventType              `protobuf:"varint,2,opt,name=event_type,json=eventType,proto3,enum=events.EventType" json:"event_type,omitempty"`
-	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	SessionId     string                 `protobuf:"bytes,4,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	ProductId     string                 `protobuf:"bytes,5,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
-	Metadata      map[string]string      `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	EventId   string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	EventType EventType              `protobuf:"varint,2,opt,name=event_type,json=eventType,proto3,enum=events.EventType" json:"event_type,omitempty"`
+	UserId    string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	SessionId string                 `protobuf:"bytes,4,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ProductId string                 `protobuf:"bytes,5,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	// Только строковые значения. Оставлено для старых клиентов, вместо него
+	// используйте properties.
+	//
+	// Deprecated: Marked as deprecated in events.proto.
+	Metadata  map[string]string      `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Имя типа из реестра схем, например "video_played". Если задано,
+	// event_type игнорируется.
+	EventName string `protobuf:"bytes,8,opt,name=event_name,json=eventName,proto3" json:"event_name,omitempty"`
+	// Типизированные данные события: числа, bool, строки, списки и вложенные
+	// объекты. При совпадении ключей перекрывают metadata.
+	Properties *structpb.Struct `protobuf:"bytes,9,opt,name=properties,proto3" json:"properties,omitempty"`
+	// Id посетителя до логина, например из cookie. Нужен, если user_id ещё
+	// неизвестен; вместе с user_id связывает их в identity graph.
+	AnonymousId   string `protobuf:"bytes,10,opt,name=anonymous_id,json=anonymousId,proto3" json:"anonymous_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -161,6 +291,7 @@ func (x *Event) GetProductId() string {
 	return ""
 }
 
+// Deprecated: Marked as deprecated in events.proto.
 func (x *Event) GetMetadata() map[string]string {
 	if x != nil {
 		return x.Metadata
@@ -175,6 +306,27 @@ func (x *Event) GetTimestamp() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Event) GetEventName() string {
+	if x != nil {
+		return x.EventName
+	}
+	return ""
+}
+
+func (x *Event) GetProperties() *structpb.Struct {
+	if x != nil {
+		return x.Properties
+	}
+	return nil
+}
+
+func (x *Event) GetAnonymousId() string {
+	if x != nil {
+		return x.AnonymousId
+	}
+	return ""
+}
+
 type TrackEventRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Event         *Event                 `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
@@ -323,19 +475,84 @@ func (x *TrackEventBatchRequest) GetEvents() []*Event {
 	return nil
 }
 
+type EventResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Status        EventStatus            `protobuf:"varint,2,opt,name=status,proto3,enum=events.EventStatus" json:"status,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventResult) Reset() {
+	*x = EventResult{}
+	mi := &file_events_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventResult) ProtoMessage() {}
+
+func (x *EventResult) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventResult.ProtoReflect.Descriptor instead.
+func (*EventResult) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EventResult) GetEventId() string {
+	if x != nil {
+		return x.EventId
+	}
+	return ""
+}
+
+func (x *EventResult) GetStatus() EventStatus {
+	if x != nil {
+		return x.Status
+	}
+	return EventStatus_EVENT_STATUS_UNSPECIFIED
+}
+
+func (x *EventResult) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
 type TrackEventBatchResponse struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	Message        string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
-	Success        bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
-	ProcessedCount int32                  `protobuf:"varint,3,opt,name=processed_count,json=processedCount,proto3" json:"processed_count,omitempty"`
-	FailedEventIds []string               `protobuf:"bytes,4,rep,name=failed_event_ids,json=failedEventIds,proto3" json:"failed_event_ids,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Message string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// true, если ни одно событие не отклонено (invalid или failed)
+	Success bool `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	// Сколько событий принято впервые
+	ProcessedCount int32 `protobuf:"varint,3,opt,name=processed_count,json=processedCount,proto3" json:"processed_count,omitempty"`
+	// id событий со статусом invalid или failed
+	FailedEventIds []string `protobuf:"bytes,4,rep,name=failed_event_ids,json=failedEventIds,proto3" json:"failed_event_ids,omitempty"`
+	// Результат по каждому событию в порядке запроса
+	Results       []*EventResult `protobuf:"bytes,5,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TrackEventBatchResponse) Reset() {
 	*x = TrackEventBatchResponse{}
-	mi := &file_events_proto_msgTypes[4]
+	mi := &file_events_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -347,7 +564,7 @@ func (x *TrackEventBatchResponse) String() string {
 func (*TrackEventBatchResponse) ProtoMessage() {}
 
 func (x *TrackEventBatchResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_events_proto_msgTypes[4]
+	mi := &file_events_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -360,7 +577,7 @@ func (x *TrackEventBatchResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TrackEventBatchResponse.ProtoReflect.Descriptor instead.
 func (*TrackEventBatchResponse) Descriptor() ([]byte, []int) {
-	return file_events_proto_rawDescGZIP(), []int{4}
+	return file_events_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *TrackEventBatchResponse) GetMessage() string {
@@ -391,27 +608,35 @@ func (x *TrackEventBatchResponse) GetFailedEventIds() []string {
 	return nil
 }
 
-type HealthCheckRequest struct {
+func (x *TrackEventBatchResponse) GetResults() []*EventResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type TrackEventStreamRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*Event               `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckRequest) Reset() {
-	*x = HealthCheckRequest{}
-	mi := &file_events_proto_msgTypes[5]
+func (x *TrackEventStreamRequest) Reset() {
+	*x = TrackEventStreamRequest{}
+	mi := &file_events_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckRequest) String() string {
+func (x *TrackEventStreamRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckRequest) ProtoMessage() {}
+func (*TrackEventStreamRequest) ProtoMessage() {}
 
-func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_events_proto_msgTypes[5]
+func (x *TrackEventStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -422,35 +647,41 @@ func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
-func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
-	return file_events_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use TrackEventStreamRequest.ProtoReflect.Descriptor instead.
+func (*TrackEventStreamRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{6}
 }
 
-type HealthCheckResponse struct {
+func (x *TrackEventStreamRequest) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type RejectedEvent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
-	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
-	Dependencies  map[string]string      `protobuf:"bytes,3,rep,name=dependencies,proto3" json:"dependencies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	EventId       string                 `protobuf:"bytes,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *HealthCheckResponse) Reset() {
-	*x = HealthCheckResponse{}
-	mi := &file_events_proto_msgTypes[6]
+func (x *RejectedEvent) Reset() {
+	*x = RejectedEvent{}
+	mi := &file_events_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *HealthCheckResponse) String() string {
+func (x *RejectedEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*HealthCheckResponse) ProtoMessage() {}
+func (*RejectedEvent) ProtoMessage() {}
 
-func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_events_proto_msgTypes[6]
+func (x *RejectedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -461,64 +692,1513 @@ func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
-func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
-	return file_events_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use RejectedEvent.ProtoReflect.Descriptor instead.
+func (*RejectedEvent) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *HealthCheckResponse) GetHealthy() bool {
+func (x *RejectedEvent) GetEventId() string {
 	if x != nil {
-		return x.Healthy
+		return x.EventId
 	}
-	return false
+	return ""
 }
 
-func (x *HealthCheckResponse) GetStatus() string {
+func (x *RejectedEvent) GetReason() string {
 	if x != nil {
-		return x.Status
+		return x.Reason
 	}
 	return ""
 }
 
-func (x *HealthCheckResponse) GetDependencies() map[string]string {
+type TrackEventStreamAck struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	AcceptedEventIds []string               `protobuf:"bytes,1,rep,name=accepted_event_ids,json=acceptedEventIds,proto3" json:"accepted_event_ids,omitempty"`
+	Rejected         []*RejectedEvent       `protobuf:"bytes,2,rep,name=rejected,proto3" json:"rejected,omitempty"`
+	// Всего принято за время жизни stream
+	TotalAccepted int64 `protobuf:"varint,3,opt,name=total_accepted,json=totalAccepted,proto3" json:"total_accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackEventStreamAck) Reset() {
+	*x = TrackEventStreamAck{}
+	mi := &file_events_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackEventStreamAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackEventStreamAck) ProtoMessage() {}
+
+func (x *TrackEventStreamAck) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[8]
 	if x != nil {
-		return x.Dependencies
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackEventStreamAck.ProtoReflect.Descriptor instead.
+func (*TrackEventStreamAck) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *TrackEventStreamAck) GetAcceptedEventIds() []string {
+	if x != nil {
+		return x.AcceptedEventIds
 	}
 	return nil
 }
 
-var File_events_proto protoreflect.FileDescriptor
+func (x *TrackEventStreamAck) GetRejected() []*RejectedEvent {
+	if x != nil {
+		return x.Rejected
+	}
+	return nil
+}
 
-const file_events_proto_rawDesc = "" +
-	"\n" +
-	"\fevents.proto\x12\x06events\x1a\x1fgoogle/protobuf/timestamp.proto\"\xdb\x02\n" +
-	"\x05Event\x12\x19\n" +
-	"\bevent_id\x18\x01 \x01(\tR\aeventId\x120\n" +
-	"\n" +
-	"event_type\x18\x02 \x01(\x0e2\x11.events.EventTypeR\teventType\x12\x17\n" +
-	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1d\n" +
-	"\n" +
-	"session_id\x18\x04 \x01(\tR\tsessionId\x12\x1d\n" +
-	"\n" +
-	"product_id\x18\x05 \x01(\tR\tproductId\x127\n" +
-	"\bmetadata\x18\x06 \x03(\v2\x1b.events.Event.MetadataEntryR\bmetadata\x128\n" +
-	"\ttimestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x1a;\n" +
-	"\rMetadataEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"8\n" +
-	"\x11TrackEventRequest\x12#\n" +
-	"\x05event\x18\x01 \x01(\v2\r.events.EventR\x05event\"c\n" +
-	"\x12TrackEventResponse\x12\x19\n" +
-	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x12\x18\n" +
-	"\asuccess\x18\x03 \x01(\bR\asuccess\"?\n" +
-	"\x16TrackEventBatchRequest\x12%\n" +
-	"\x06events\x18\x01 \x03(\v2\r.events.EventR\x06events\"\xa0\x01\n" +
-	"\x17TrackEventBatchResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
-	"\asuccess\x18\x02 \x01(\bR\asuccess\x12'\n" +
-	"\x0fprocessed_count\x18\x03 \x01(\x05R\x0eprocessedCount\x12(\n" +
-	"\x10failed_event_ids\x18\x04 \x03(\tR\x0efailedEventIds\"\x14\n" +
+func (x *TrackEventStreamAck) GetTotalAccepted() int64 {
+	if x != nil {
+		return x.TotalAccepted
+	}
+	return 0
+}
+
+type SchemaField struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Name     string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Type     FieldType              `protobuf:"varint,2,opt,name=type,proto3,enum=events.FieldType" json:"type,omitempty"`
+	Required bool                   `protobuf:"varint,3,opt,name=required,proto3" json:"required,omitempty"`
+	// Если задано, значение должно совпадать с одним из списка
+	AllowedValues []string `protobuf:"bytes,4,rep,name=allowed_values,json=allowedValues,proto3" json:"allowed_values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchemaField) Reset() {
+	*x = SchemaField{}
+	mi := &file_events_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchemaField) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchemaField) ProtoMessage() {}
+
+func (x *SchemaField) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchemaField.ProtoReflect.Descriptor instead.
+func (*SchemaField) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SchemaField) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SchemaField) GetType() FieldType {
+	if x != nil {
+		return x.Type
+	}
+	return FieldType_FIELD_TYPE_UNSPECIFIED
+}
+
+func (x *SchemaField) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+func (x *SchemaField) GetAllowedValues() []string {
+	if x != nil {
+		return x.AllowedValues
+	}
+	return nil
+}
+
+type EventSchema struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Fields        []*SchemaField         `protobuf:"bytes,3,rep,name=fields,proto3" json:"fields,omitempty"`
+	Version       int32                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	BuiltIn       bool                   `protobuf:"varint,5,opt,name=built_in,json=builtIn,proto3" json:"built_in,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventSchema) Reset() {
+	*x = EventSchema{}
+	mi := &file_events_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventSchema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventSchema) ProtoMessage() {}
+
+func (x *EventSchema) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventSchema.ProtoReflect.Descriptor instead.
+func (*EventSchema) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EventSchema) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *EventSchema) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *EventSchema) GetFields() []*SchemaField {
+	if x != nil {
+		return x.Fields
+	}
+	return nil
+}
+
+func (x *EventSchema) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *EventSchema) GetBuiltIn() bool {
+	if x != nil {
+		return x.BuiltIn
+	}
+	return false
+}
+
+func (x *EventSchema) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *EventSchema) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type RegisterEventSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schema        *EventSchema           `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterEventSchemaRequest) Reset() {
+	*x = RegisterEventSchemaRequest{}
+	mi := &file_events_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterEventSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterEventSchemaRequest) ProtoMessage() {}
+
+func (x *RegisterEventSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterEventSchemaRequest.ProtoReflect.Descriptor instead.
+func (*RegisterEventSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RegisterEventSchemaRequest) GetSchema() *EventSchema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type RegisterEventSchemaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schema        *EventSchema           `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterEventSchemaResponse) Reset() {
+	*x = RegisterEventSchemaResponse{}
+	mi := &file_events_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterEventSchemaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterEventSchemaResponse) ProtoMessage() {}
+
+func (x *RegisterEventSchemaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterEventSchemaResponse.ProtoReflect.Descriptor instead.
+func (*RegisterEventSchemaResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RegisterEventSchemaResponse) GetSchema() *EventSchema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type GetEventSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventSchemaRequest) Reset() {
+	*x = GetEventSchemaRequest{}
+	mi := &file_events_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventSchemaRequest) ProtoMessage() {}
+
+func (x *GetEventSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventSchemaRequest.ProtoReflect.Descriptor instead.
+func (*GetEventSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetEventSchemaRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetEventSchemaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schema        *EventSchema           `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventSchemaResponse) Reset() {
+	*x = GetEventSchemaResponse{}
+	mi := &file_events_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventSchemaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventSchemaResponse) ProtoMessage() {}
+
+func (x *GetEventSchemaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventSchemaResponse.ProtoReflect.Descriptor instead.
+func (*GetEventSchemaResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *GetEventSchemaResponse) GetSchema() *EventSchema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type ListEventSchemasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEventSchemasRequest) Reset() {
+	*x = ListEventSchemasRequest{}
+	mi := &file_events_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEventSchemasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEventSchemasRequest) ProtoMessage() {}
+
+func (x *ListEventSchemasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEventSchemasRequest.ProtoReflect.Descriptor instead.
+func (*ListEventSchemasRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{15}
+}
+
+type ListEventSchemasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schemas       []*EventSchema         `protobuf:"bytes,1,rep,name=schemas,proto3" json:"schemas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEventSchemasResponse) Reset() {
+	*x = ListEventSchemasResponse{}
+	mi := &file_events_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEventSchemasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEventSchemasResponse) ProtoMessage() {}
+
+func (x *ListEventSchemasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEventSchemasResponse.ProtoReflect.Descriptor instead.
+func (*ListEventSchemasResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListEventSchemasResponse) GetSchemas() []*EventSchema {
+	if x != nil {
+		return x.Schemas
+	}
+	return nil
+}
+
+type DeleteEventSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteEventSchemaRequest) Reset() {
+	*x = DeleteEventSchemaRequest{}
+	mi := &file_events_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteEventSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteEventSchemaRequest) ProtoMessage() {}
+
+func (x *DeleteEventSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteEventSchemaRequest.ProtoReflect.Descriptor instead.
+func (*DeleteEventSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DeleteEventSchemaRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DeleteEventSchemaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteEventSchemaResponse) Reset() {
+	*x = DeleteEventSchemaResponse{}
+	mi := &file_events_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteEventSchemaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteEventSchemaResponse) ProtoMessage() {}
+
+func (x *DeleteEventSchemaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteEventSchemaResponse.ProtoReflect.Descriptor instead.
+func (*DeleteEventSchemaResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{18}
+}
+
+type IdentifyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AnonymousId   string                 `protobuf:"bytes,1,opt,name=anonymous_id,json=anonymousId,proto3" json:"anonymous_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IdentifyRequest) Reset() {
+	*x = IdentifyRequest{}
+	mi := &file_events_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdentifyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdentifyRequest) ProtoMessage() {}
+
+func (x *IdentifyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdentifyRequest.ProtoReflect.Descriptor instead.
+func (*IdentifyRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *IdentifyRequest) GetAnonymousId() string {
+	if x != nil {
+		return x.AnonymousId
+	}
+	return ""
+}
+
+func (x *IdentifyRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type IdentifyResponse struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	AnonymousId string                 `protobuf:"bytes,1,opt,name=anonymous_id,json=anonymousId,proto3" json:"anonymous_id,omitempty"`
+	UserId      string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// false, если связь уже была сохранена раньше
+	Created       bool `protobuf:"varint,3,opt,name=created,proto3" json:"created,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IdentifyResponse) Reset() {
+	*x = IdentifyResponse{}
+	mi := &file_events_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IdentifyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IdentifyResponse) ProtoMessage() {}
+
+func (x *IdentifyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IdentifyResponse.ProtoReflect.Descriptor instead.
+func (*IdentifyResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *IdentifyResponse) GetAnonymousId() string {
+	if x != nil {
+		return x.AnonymousId
+	}
+	return ""
+}
+
+func (x *IdentifyResponse) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *IdentifyResponse) GetCreated() bool {
+	if x != nil {
+		return x.Created
+	}
+	return false
+}
+
+type DeleteUserDataRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Ключ идемпотентности. Если не задан, генерируется сервером.
+	RequestId string `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Не используется: в журнал пишется администратор, которому принадлежит токен
+	//
+	// Deprecated: Marked as deprecated in events.proto.
+	RequestedBy   string `protobuf:"bytes,3,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
+	Reason        string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteUserDataRequest) Reset() {
+	*x = DeleteUserDataRequest{}
+	mi := &file_events_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserDataRequest) ProtoMessage() {}
+
+func (x *DeleteUserDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserDataRequest.ProtoReflect.Descriptor instead.
+func (*DeleteUserDataRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DeleteUserDataRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *DeleteUserDataRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in events.proto.
+func (x *DeleteUserDataRequest) GetRequestedBy() string {
+	if x != nil {
+		return x.RequestedBy
+	}
+	return ""
+}
+
+func (x *DeleteUserDataRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type DeleteUserDataResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	DeletedEvents int64                  `protobuf:"varint,2,opt,name=deleted_events,json=deletedEvents,proto3" json:"deleted_events,omitempty"`
+	// Удалённые связи anonymous_id -> user_id
+	DeletedIdentities int64                  `protobuf:"varint,3,opt,name=deleted_identities,json=deletedIdentities,proto3" json:"deleted_identities,omitempty"`
+	CompletedAt       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	// true, если запрос с этим request_id уже был выполнен раньше
+	AlreadyProcessed bool `protobuf:"varint,5,opt,name=already_processed,json=alreadyProcessed,proto3" json:"already_processed,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *DeleteUserDataResponse) Reset() {
+	*x = DeleteUserDataResponse{}
+	mi := &file_events_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteUserDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteUserDataResponse) ProtoMessage() {}
+
+func (x *DeleteUserDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteUserDataResponse.ProtoReflect.Descriptor instead.
+func (*DeleteUserDataResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeleteUserDataResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *DeleteUserDataResponse) GetDeletedEvents() int64 {
+	if x != nil {
+		return x.DeletedEvents
+	}
+	return 0
+}
+
+func (x *DeleteUserDataResponse) GetDeletedIdentities() int64 {
+	if x != nil {
+		return x.DeletedIdentities
+	}
+	return 0
+}
+
+func (x *DeleteUserDataResponse) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+func (x *DeleteUserDataResponse) GetAlreadyProcessed() bool {
+	if x != nil {
+		return x.AlreadyProcessed
+	}
+	return false
+}
+
+type ExportUserDataRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	UserId    string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	RequestId string                 `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Не используется, как в DeleteUserDataRequest
+	//
+	// Deprecated: Marked as deprecated in events.proto.
+	RequestedBy   string `protobuf:"bytes,3,opt,name=requested_by,json=requestedBy,proto3" json:"requested_by,omitempty"`
+	Reason        string `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportUserDataRequest) Reset() {
+	*x = ExportUserDataRequest{}
+	mi := &file_events_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportUserDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportUserDataRequest) ProtoMessage() {}
+
+func (x *ExportUserDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportUserDataRequest.ProtoReflect.Descriptor instead.
+func (*ExportUserDataRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ExportUserDataRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ExportUserDataRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in events.proto.
+func (x *ExportUserDataRequest) GetRequestedBy() string {
+	if x != nil {
+		return x.RequestedBy
+	}
+	return ""
+}
+
+func (x *ExportUserDataRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ExportUserDataChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Одна или несколько строк JSON lines, по событию на строку
+	Data          []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportUserDataChunk) Reset() {
+	*x = ExportUserDataChunk{}
+	mi := &file_events_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportUserDataChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportUserDataChunk) ProtoMessage() {}
+
+func (x *ExportUserDataChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportUserDataChunk.ProtoReflect.Descriptor instead.
+func (*ExportUserDataChunk) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ExportUserDataChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type EventArchive struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Partition     string                 `protobuf:"bytes,1,opt,name=partition,proto3" json:"partition,omitempty"`
+	From          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	ObjectKey     string                 `protobuf:"bytes,4,opt,name=object_key,json=objectKey,proto3" json:"object_key,omitempty"`
+	Format        string                 `protobuf:"bytes,5,opt,name=format,proto3" json:"format,omitempty"`
+	Events        int64                  `protobuf:"varint,6,opt,name=events,proto3" json:"events,omitempty"`
+	Bytes         int64                  `protobuf:"varint,7,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	ArchivedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=archived_at,json=archivedAt,proto3" json:"archived_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventArchive) Reset() {
+	*x = EventArchive{}
+	mi := &file_events_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventArchive) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventArchive) ProtoMessage() {}
+
+func (x *EventArchive) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventArchive.ProtoReflect.Descriptor instead.
+func (*EventArchive) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *EventArchive) GetPartition() string {
+	if x != nil {
+		return x.Partition
+	}
+	return ""
+}
+
+func (x *EventArchive) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *EventArchive) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *EventArchive) GetObjectKey() string {
+	if x != nil {
+		return x.ObjectKey
+	}
+	return ""
+}
+
+func (x *EventArchive) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *EventArchive) GetEvents() int64 {
+	if x != nil {
+		return x.Events
+	}
+	return 0
+}
+
+func (x *EventArchive) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *EventArchive) GetArchivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ArchivedAt
+	}
+	return nil
+}
+
+type ListArchivesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Необязательные границы: архивы, пересекающиеся с [from, to)
+	From          *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArchivesRequest) Reset() {
+	*x = ListArchivesRequest{}
+	mi := &file_events_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArchivesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArchivesRequest) ProtoMessage() {}
+
+func (x *ListArchivesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArchivesRequest.ProtoReflect.Descriptor instead.
+func (*ListArchivesRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListArchivesRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ListArchivesRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+type ListArchivesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Archives      []*EventArchive        `protobuf:"bytes,1,rep,name=archives,proto3" json:"archives,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArchivesResponse) Reset() {
+	*x = ListArchivesResponse{}
+	mi := &file_events_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArchivesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArchivesResponse) ProtoMessage() {}
+
+func (x *ListArchivesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArchivesResponse.ProtoReflect.Descriptor instead.
+func (*ListArchivesResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListArchivesResponse) GetArchives() []*EventArchive {
+	if x != nil {
+		return x.Archives
+	}
+	return nil
+}
+
+type RestoreArchiveRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	From  *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To    *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	// Имя таблицы с префиксом restored_, по умолчанию restored_events.
+	// Создаётся по образцу events, если её нет.
+	Table         string `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreArchiveRequest) Reset() {
+	*x = RestoreArchiveRequest{}
+	mi := &file_events_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreArchiveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreArchiveRequest) ProtoMessage() {}
+
+func (x *RestoreArchiveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreArchiveRequest.ProtoReflect.Descriptor instead.
+func (*RestoreArchiveRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RestoreArchiveRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *RestoreArchiveRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *RestoreArchiveRequest) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+type RestoreArchiveResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Table          string                 `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	RestoredEvents int64                  `protobuf:"varint,2,opt,name=restored_events,json=restoredEvents,proto3" json:"restored_events,omitempty"`
+	Archives       []*EventArchive        `protobuf:"bytes,3,rep,name=archives,proto3" json:"archives,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RestoreArchiveResponse) Reset() {
+	*x = RestoreArchiveResponse{}
+	mi := &file_events_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreArchiveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreArchiveResponse) ProtoMessage() {}
+
+func (x *RestoreArchiveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreArchiveResponse.ProtoReflect.Descriptor instead.
+func (*RestoreArchiveResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RestoreArchiveResponse) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *RestoreArchiveResponse) GetRestoredEvents() int64 {
+	if x != nil {
+		return x.RestoredEvents
+	}
+	return 0
+}
+
+func (x *RestoreArchiveResponse) GetArchives() []*EventArchive {
+	if x != nil {
+		return x.Archives
+	}
+	return nil
+}
+
+type HealthCheckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckRequest) Reset() {
+	*x = HealthCheckRequest{}
+	mi := &file_events_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckRequest) ProtoMessage() {}
+
+func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
+func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{30}
+}
+
+type HealthCheckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Dependencies  map[string]string      `protobuf:"bytes,3,rep,name=dependencies,proto3" json:"dependencies,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthCheckResponse) Reset() {
+	*x = HealthCheckResponse{}
+	mi := &file_events_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthCheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthCheckResponse) ProtoMessage() {}
+
+func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_events_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
+func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+	return file_events_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *HealthCheckResponse) GetHealthy() bool {
+	if x != nil {
+		return x.Healthy
+	}
+	return false
+}
+
+func (x *HealthCheckResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HealthCheckResponse) GetDependencies() map[string]string {
+	if x != nil {
+		return x.Dependencies
+	}
+	return nil
+}
+
+var File_events_proto protoreflect.FileDescriptor
+
+const file_events_proto_rawDesc = "" +
+	"\n" +
+	"\fevents.proto\x12\x06events\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1cgoogle/protobuf/struct.proto\"\xda\x03\n" +
+	"\x05Event\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x120\n" +
+	"\n" +
+	"event_type\x18\x02 \x01(\x0e2\x11.events.EventTypeR\teventType\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x04 \x01(\tR\tsessionId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x05 \x01(\tR\tproductId\x12;\n" +
+	"\bmetadata\x18\x06 \x03(\v2\x1b.events.Event.MetadataEntryB\x02\x18\x01R\bmetadata\x128\n" +
+	"\ttimestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1d\n" +
+	"\n" +
+	"event_name\x18\b \x01(\tR\teventName\x127\n" +
+	"\n" +
+	"properties\x18\t \x01(\v2\x17.google.protobuf.StructR\n" +
+	"properties\x12!\n" +
+	"\fanonymous_id\x18\n" +
+	" \x01(\tR\vanonymousId\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"8\n" +
+	"\x11TrackEventRequest\x12#\n" +
+	"\x05event\x18\x01 \x01(\v2\r.events.EventR\x05event\"c\n" +
+	"\x12TrackEventResponse\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x18\n" +
+	"\asuccess\x18\x03 \x01(\bR\asuccess\"?\n" +
+	"\x16TrackEventBatchRequest\x12%\n" +
+	"\x06events\x18\x01 \x03(\v2\r.events.EventR\x06events\"m\n" +
+	"\vEventResult\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12+\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x13.events.EventStatusR\x06status\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\xcf\x01\n" +
+	"\x17TrackEventBatchResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12'\n" +
+	"\x0fprocessed_count\x18\x03 \x01(\x05R\x0eprocessedCount\x12(\n" +
+	"\x10failed_event_ids\x18\x04 \x03(\tR\x0efailedEventIds\x12-\n" +
+	"\aresults\x18\x05 \x03(\v2\x13.events.EventResultR\aresults\"@\n" +
+	"\x17TrackEventStreamRequest\x12%\n" +
+	"\x06events\x18\x01 \x03(\v2\r.events.EventR\x06events\"B\n" +
+	"\rRejectedEvent\x12\x19\n" +
+	"\bevent_id\x18\x01 \x01(\tR\aeventId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\x9d\x01\n" +
+	"\x13TrackEventStreamAck\x12,\n" +
+	"\x12accepted_event_ids\x18\x01 \x03(\tR\x10acceptedEventIds\x121\n" +
+	"\brejected\x18\x02 \x03(\v2\x15.events.RejectedEventR\brejected\x12%\n" +
+	"\x0etotal_accepted\x18\x03 \x01(\x03R\rtotalAccepted\"\x8b\x01\n" +
+	"\vSchemaField\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12%\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x11.events.FieldTypeR\x04type\x12\x1a\n" +
+	"\brequired\x18\x03 \x01(\bR\brequired\x12%\n" +
+	"\x0eallowed_values\x18\x04 \x03(\tR\rallowedValues\"\x9b\x02\n" +
+	"\vEventSchema\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12+\n" +
+	"\x06fields\x18\x03 \x03(\v2\x13.events.SchemaFieldR\x06fields\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x05R\aversion\x12\x19\n" +
+	"\bbuilt_in\x18\x05 \x01(\bR\abuiltIn\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"I\n" +
+	"\x1aRegisterEventSchemaRequest\x12+\n" +
+	"\x06schema\x18\x01 \x01(\v2\x13.events.EventSchemaR\x06schema\"J\n" +
+	"\x1bRegisterEventSchemaResponse\x12+\n" +
+	"\x06schema\x18\x01 \x01(\v2\x13.events.EventSchemaR\x06schema\"+\n" +
+	"\x15GetEventSchemaRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"E\n" +
+	"\x16GetEventSchemaResponse\x12+\n" +
+	"\x06schema\x18\x01 \x01(\v2\x13.events.EventSchemaR\x06schema\"\x19\n" +
+	"\x17ListEventSchemasRequest\"I\n" +
+	"\x18ListEventSchemasResponse\x12-\n" +
+	"\aschemas\x18\x01 \x03(\v2\x13.events.EventSchemaR\aschemas\".\n" +
+	"\x18DeleteEventSchemaRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x1b\n" +
+	"\x19DeleteEventSchemaResponse\"M\n" +
+	"\x0fIdentifyRequest\x12!\n" +
+	"\fanonymous_id\x18\x01 \x01(\tR\vanonymousId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"h\n" +
+	"\x10IdentifyResponse\x12!\n" +
+	"\fanonymous_id\x18\x01 \x01(\tR\vanonymousId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x18\n" +
+	"\acreated\x18\x03 \x01(\bR\acreated\"\x8e\x01\n" +
+	"\x15DeleteUserDataRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId\x12%\n" +
+	"\frequested_by\x18\x03 \x01(\tB\x02\x18\x01R\vrequestedBy\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\xf9\x01\n" +
+	"\x16DeleteUserDataResponse\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12%\n" +
+	"\x0edeleted_events\x18\x02 \x01(\x03R\rdeletedEvents\x12-\n" +
+	"\x12deleted_identities\x18\x03 \x01(\x03R\x11deletedIdentities\x12=\n" +
+	"\fcompleted_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\x12+\n" +
+	"\x11already_processed\x18\x05 \x01(\bR\x10alreadyProcessed\"\x8e\x01\n" +
+	"\x15ExportUserDataRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId\x12%\n" +
+	"\frequested_by\x18\x03 \x01(\tB\x02\x18\x01R\vrequestedBy\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\")\n" +
+	"\x13ExportUserDataChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"\xaa\x02\n" +
+	"\fEventArchive\x12\x1c\n" +
+	"\tpartition\x18\x01 \x01(\tR\tpartition\x12.\n" +
+	"\x04from\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x1d\n" +
+	"\n" +
+	"object_key\x18\x04 \x01(\tR\tobjectKey\x12\x16\n" +
+	"\x06format\x18\x05 \x01(\tR\x06format\x12\x16\n" +
+	"\x06events\x18\x06 \x01(\x03R\x06events\x12\x14\n" +
+	"\x05bytes\x18\a \x01(\x03R\x05bytes\x12;\n" +
+	"\varchived_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"archivedAt\"q\n" +
+	"\x13ListArchivesRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"H\n" +
+	"\x14ListArchivesResponse\x120\n" +
+	"\barchives\x18\x01 \x03(\v2\x14.events.EventArchiveR\barchives\"\x89\x01\n" +
+	"\x15RestoreArchiveRequest\x12.\n" +
+	"\x04from\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\x12\x14\n" +
+	"\x05table\x18\x03 \x01(\tR\x05table\"\x89\x01\n" +
+	"\x16RestoreArchiveResponse\x12\x14\n" +
+	"\x05table\x18\x01 \x01(\tR\x05table\x12'\n" +
+	"\x0frestored_events\x18\x02 \x01(\x03R\x0erestoredEvents\x120\n" +
+	"\barchives\x18\x03 \x03(\v2\x14.events.EventArchiveR\barchives\"\x14\n" +
 	"\x12HealthCheckRequest\"\xdb\x01\n" +
 	"\x13HealthCheckResponse\x12\x18\n" +
 	"\ahealthy\x18\x01 \x01(\bR\ahealthy\x12\x16\n" +
@@ -534,12 +2214,36 @@ const file_events_proto_rawDesc = "" +
 	"\x16EVENT_TYPE_ADD_TO_CART\x10\x03\x12\x1f\n" +
 	"\x1bEVENT_TYPE_REMOVE_FROM_CART\x10\x04\x12\x17\n" +
 	"\x13EVENT_TYPE_PURCHASE\x10\x05\x12\x15\n" +
-	"\x11EVENT_TYPE_SEARCH\x10\x062\xef\x01\n" +
+	"\x11EVENT_TYPE_SEARCH\x10\x06*\x95\x01\n" +
+	"\vEventStatus\x12\x1c\n" +
+	"\x18EVENT_STATUS_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15EVENT_STATUS_ACCEPTED\x10\x01\x12\x1a\n" +
+	"\x16EVENT_STATUS_DUPLICATE\x10\x02\x12\x18\n" +
+	"\x14EVENT_STATUS_INVALID\x10\x03\x12\x17\n" +
+	"\x13EVENT_STATUS_FAILED\x10\x04*\x9c\x01\n" +
+	"\tFieldType\x12\x1a\n" +
+	"\x16FIELD_TYPE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11FIELD_TYPE_STRING\x10\x01\x12\x15\n" +
+	"\x11FIELD_TYPE_NUMBER\x10\x02\x12\x16\n" +
+	"\x12FIELD_TYPE_BOOLEAN\x10\x03\x12\x13\n" +
+	"\x0fFIELD_TYPE_UUID\x10\x04\x12\x18\n" +
+	"\x14FIELD_TYPE_TIMESTAMP\x10\x052\x84\x03\n" +
 	"\fEventService\x12C\n" +
 	"\n" +
 	"TrackEvent\x12\x19.events.TrackEventRequest\x1a\x1a.events.TrackEventResponse\x12R\n" +
-	"\x0fTrackEventBatch\x12\x1e.events.TrackEventBatchRequest\x1a\x1f.events.TrackEventBatchResponse\x12F\n" +
-	"\vHealthCheck\x12\x1a.events.HealthCheckRequest\x1a\x1b.events.HealthCheckResponseB8Z6github.com/Wuchinator/realtime-analytics/pkg/pb/eventsb\x06proto3"
+	"\x0fTrackEventBatch\x12\x1e.events.TrackEventBatchRequest\x1a\x1f.events.TrackEventBatchResponse\x12T\n" +
+	"\x10TrackEventStream\x12\x1f.events.TrackEventStreamRequest\x1a\x1b.events.TrackEventStreamAck(\x010\x01\x12=\n" +
+	"\bIdentify\x12\x17.events.IdentifyRequest\x1a\x18.events.IdentifyResponse\x12F\n" +
+	"\vHealthCheck\x12\x1a.events.HealthCheckRequest\x1a\x1b.events.HealthCheckResponse2\xad\x05\n" +
+	"\fAdminService\x12^\n" +
+	"\x13RegisterEventSchema\x12\".events.RegisterEventSchemaRequest\x1a#.events.RegisterEventSchemaResponse\x12O\n" +
+	"\x0eGetEventSchema\x12\x1d.events.GetEventSchemaRequest\x1a\x1e.events.GetEventSchemaResponse\x12U\n" +
+	"\x10ListEventSchemas\x12\x1f.events.ListEventSchemasRequest\x1a .events.ListEventSchemasResponse\x12X\n" +
+	"\x11DeleteEventSchema\x12 .events.DeleteEventSchemaRequest\x1a!.events.DeleteEventSchemaResponse\x12O\n" +
+	"\x0eDeleteUserData\x12\x1d.events.DeleteUserDataRequest\x1a\x1e.events.DeleteUserDataResponse\x12N\n" +
+	"\x0eExportUserData\x12\x1d.events.ExportUserDataRequest\x1a\x1b.events.ExportUserDataChunk0\x01\x12I\n" +
+	"\fListArchives\x12\x1b.events.ListArchivesRequest\x1a\x1c.events.ListArchivesResponse\x12O\n" +
+	"\x0eRestoreArchive\x12\x1d.events.RestoreArchiveRequest\x1a\x1e.events.RestoreArchiveResponseB8Z6github.com/Wuchinator/realtime-analytics/pkg/pb/eventsb\x06proto3"
 
 var (
 	file_events_proto_rawDescOnce sync.Once
@@ -553,39 +2257,110 @@ func file_events_proto_rawDescGZIP() []byte {
 	return file_events_proto_rawDescData
 }
 
-var file_events_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_events_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_events_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_events_proto_msgTypes = make([]protoimpl.MessageInfo, 34)
 var file_events_proto_goTypes = []any{
-	(EventType)(0),                  // 0: events.EventType
-	(*Event)(nil),                   // 1: events.Event
-	(*TrackEventRequest)(nil),       // 2: events.TrackEventRequest
-	(*TrackEventResponse)(nil),      // 3: events.TrackEventResponse
-	(*TrackEventBatchRequest)(nil),  // 4: events.TrackEventBatchRequest
-	(*TrackEventBatchResponse)(nil), // 5: events.TrackEventBatchResponse
-	(*HealthCheckRequest)(nil),      // 6: events.HealthCheckRequest
-	(*HealthCheckResponse)(nil),     // 7: events.HealthCheckResponse
-	nil,                             // 8: events.Event.MetadataEntry
-	nil,                             // 9: events.HealthCheckResponse.DependenciesEntry
-	(*timestamppb.Timestamp)(nil),   // 10: google.protobuf.Timestamp
+	(EventType)(0),                      // 0: events.EventType
+	(EventStatus)(0),                    // 1: events.EventStatus
+	(FieldType)(0),                      // 2: events.FieldType
+	(*Event)(nil),                       // 3: events.Event
+	(*TrackEventRequest)(nil),           // 4: events.TrackEventRequest
+	(*TrackEventResponse)(nil),          // 5: events.TrackEventResponse
+	(*TrackEventBatchRequest)(nil),      // 6: events.TrackEventBatchRequest
+	(*EventResult)(nil),                 // 7: events.EventResult
+	(*TrackEventBatchResponse)(nil),     // 8: events.TrackEventBatchResponse
+	(*TrackEventStreamRequest)(nil),     // 9: events.TrackEventStreamRequest
+	(*RejectedEvent)(nil),               // 10: events.RejectedEvent
+	(*TrackEventStreamAck)(nil),         // 11: events.TrackEventStreamAck
+	(*SchemaField)(nil),                 // 12: events.SchemaField
+	(*EventSchema)(nil),                 // 13: events.EventSchema
+	(*RegisterEventSchemaRequest)(nil),  // 14: events.RegisterEventSchemaRequest
+	(*RegisterEventSchemaResponse)(nil), // 15: events.RegisterEventSchemaResponse
+	(*GetEventSchemaRequest)(nil),       // 16: events.GetEventSchemaRequest
+	(*GetEventSchemaResponse)(nil),      // 17: events.GetEventSchemaResponse
+	(*ListEventSchemasRequest)(nil),     // 18: events.ListEventSchemasRequest
+	(*ListEventSchemasResponse)(nil),    // 19: events.ListEventSchemasResponse
+	(*DeleteEventSchemaRequest)(nil),    // 20: events.DeleteEventSchemaRequest
+	(*DeleteEventSchemaResponse)(nil),   // 21: events.DeleteEventSchemaResponse
+	(*IdentifyRequest)(nil),             // 22: events.IdentifyRequest
+	(*IdentifyResponse)(nil),            // 23: events.IdentifyResponse
+	(*DeleteUserDataRequest)(nil),       // 24: events.DeleteUserDataRequest
+	(*DeleteUserDataResponse)(nil),      // 25: events.DeleteUserDataResponse
+	(*ExportUserDataRequest)(nil),       // 26: events.ExportUserDataRequest
+	(*ExportUserDataChunk)(nil),         // 27: events.ExportUserDataChunk
+	(*EventArchive)(nil),                // 28: events.EventArchive
+	(*ListArchivesRequest)(nil),         // 29: events.ListArchivesRequest
+	(*ListArchivesResponse)(nil),        // 30: events.ListArchivesResponse
+	(*RestoreArchiveRequest)(nil),       // 31: events.RestoreArchiveRequest
+	(*RestoreArchiveResponse)(nil),      // 32: events.RestoreArchiveResponse
+	(*HealthCheckRequest)(nil),          // 33: events.HealthCheckRequest
+	(*HealthCheckResponse)(nil),         // 34: events.HealthCheckResponse
+	nil,                                 // 35: events.Event.MetadataEntry
+	nil,                                 // 36: events.HealthCheckResponse.DependenciesEntry
+	(*timestamppb.Timestamp)(nil),       // 37: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),             // 38: google.protobuf.Struct
 }
 var file_events_proto_depIdxs = []int32{
 	0,  // 0: events.Event.event_type:type_name -> events.EventType
-	8,  // 1: events.Event.metadata:type_name -> events.Event.MetadataEntry
-	10, // 2: events.Event.timestamp:type_name -> google.protobuf.Timestamp
-	1,  // 3: events.TrackEventRequest.event:type_name -> events.Event
-	1,  // 4: events.TrackEventBatchRequest.events:type_name -> events.Event
-	9,  // 5: events.HealthCheckResponse.dependencies:type_name -> events.HealthCheckResponse.DependenciesEntry
-	2,  // 6: events.EventService.TrackEvent:input_type -> events.TrackEventRequest
-	4,  // 7: events.EventService.TrackEventBatch:input_type -> events.TrackEventBatchRequest
-	6,  // 8: events.EventService.HealthCheck:input_type -> events.HealthCheckRequest
-	3,  // 9: events.EventService.TrackEvent:output_type -> events.TrackEventResponse
-	5,  // 10: events.EventService.TrackEventBatch:output_type -> events.TrackEventBatchResponse
-	7,  // 11: events.EventService.HealthCheck:output_type -> events.HealthCheckResponse
-	9,  // [9:12] is the sub-list for method output_type
-	6,  // [6:9] is the sub-list for method input_type
-	6,  // [6:6] is the sub-list for extension type_name
-	6,  // [6:6] is the sub-list for extension extendee
-	0,  // [0:6] is the sub-list for field type_name
+	35, // 1: events.Event.metadata:type_name -> events.Event.MetadataEntry
+	37, // 2: events.Event.timestamp:type_name -> google.protobuf.Timestamp
+	38, // 3: events.Event.properties:type_name -> google.protobuf.Struct
+	3,  // 4: events.TrackEventRequest.event:type_name -> events.Event
+	3,  // 5: events.TrackEventBatchRequest.events:type_name -> events.Event
+	1,  // 6: events.EventResult.status:type_name -> events.EventStatus
+	7,  // 7: events.TrackEventBatchResponse.results:type_name -> events.EventResult
+	3,  // 8: events.TrackEventStreamRequest.events:type_name -> events.Event
+	10, // 9: events.TrackEventStreamAck.rejected:type_name -> events.RejectedEvent
+	2,  // 10: events.SchemaField.type:type_name -> events.FieldType
+	12, // 11: events.EventSchema.fields:type_name -> events.SchemaField
+	37, // 12: events.EventSchema.created_at:type_name -> google.protobuf.Timestamp
+	37, // 13: events.EventSchema.updated_at:type_name -> google.protobuf.Timestamp
+	13, // 14: events.RegisterEventSchemaRequest.schema:type_name -> events.EventSchema
+	13, // 15: events.RegisterEventSchemaResponse.schema:type_name -> events.EventSchema
+	13, // 16: events.GetEventSchemaResponse.schema:type_name -> events.EventSchema
+	13, // 17: events.ListEventSchemasResponse.schemas:type_name -> events.EventSchema
+	37, // 18: events.DeleteUserDataResponse.completed_at:type_name -> google.protobuf.Timestamp
+	37, // 19: events.EventArchive.from:type_name -> google.protobuf.Timestamp
+	37, // 20: events.EventArchive.to:type_name -> google.protobuf.Timestamp
+	37, // 21: events.EventArchive.archived_at:type_name -> google.protobuf.Timestamp
+	37, // 22: events.ListArchivesRequest.from:type_name -> google.protobuf.Timestamp
+	37, // 23: events.ListArchivesRequest.to:type_name -> google.protobuf.Timestamp
+	28, // 24: events.ListArchivesResponse.archives:type_name -> events.EventArchive
+	37, // 25: events.RestoreArchiveRequest.from:type_name -> google.protobuf.Timestamp
+	37, // 26: events.RestoreArchiveRequest.to:type_name -> google.protobuf.Timestamp
+	28, // 27: events.RestoreArchiveResponse.archives:type_name -> events.EventArchive
+	36, // 28: events.HealthCheckResponse.dependencies:type_name -> events.HealthCheckResponse.DependenciesEntry
+	4,  // 29: events.EventService.TrackEvent:input_type -> events.TrackEventRequest
+	6,  // 30: events.EventService.TrackEventBatch:input_type -> events.TrackEventBatchRequest
+	9,  // 31: events.EventService.TrackEventStream:input_type -> events.TrackEventStreamRequest
+	22, // 32: events.EventService.Identify:input_type -> events.IdentifyRequest
+	33, // 33: events.EventService.HealthCheck:input_type -> events.HealthCheckRequest
+	14, // 34: events.AdminService.RegisterEventSchema:input_type -> events.RegisterEventSchemaRequest
+	16, // 35: events.AdminService.GetEventSchema:input_type -> events.GetEventSchemaRequest
+	18, // 36: events.AdminService.ListEventSchemas:input_type -> events.ListEventSchemasRequest
+	20, // 37: events.AdminService.DeleteEventSchema:input_type -> events.DeleteEventSchemaRequest
+	24, // 38: events.AdminService.DeleteUserData:input_type -> events.DeleteUserDataRequest
+	26, // 39: events.AdminService.ExportUserData:input_type -> events.ExportUserDataRequest
+	29, // 40: events.AdminService.ListArchives:input_type -> events.ListArchivesRequest
+	31, // 41: events.AdminService.RestoreArchive:input_type -> events.RestoreArchiveRequest
+	5,  // 42: events.EventService.TrackEvent:output_type -> events.TrackEventResponse
+	8,  // 43: events.EventService.TrackEventBatch:output_type -> events.TrackEventBatchResponse
+	11, // 44: events.EventService.TrackEventStream:output_type -> events.TrackEventStreamAck
+	23, // 45: events.EventService.Identify:output_type -> events.IdentifyResponse
+	34, // 46: events.EventService.HealthCheck:output_type -> events.HealthCheckResponse
+	15, // 47: events.AdminService.RegisterEventSchema:output_type -> events.RegisterEventSchemaResponse
+	17, // 48: events.AdminService.GetEventSchema:output_type -> events.GetEventSchemaResponse
+	19, // 49: events.AdminService.ListEventSchemas:output_type -> events.ListEventSchemasResponse
+	21, // 50: events.AdminService.DeleteEventSchema:output_type -> events.DeleteEventSchemaResponse
+	25, // 51: events.AdminService.DeleteUserData:output_type -> events.DeleteUserDataResponse
+	27, // 52: events.AdminService.ExportUserData:output_type -> events.ExportUserDataChunk
+	30, // 53: events.AdminService.ListArchives:output_type -> events.ListArchivesResponse
+	32, // 54: events.AdminService.RestoreArchive:output_type -> events.RestoreArchiveResponse
+	42, // [42:55] is the sub-list for method output_type
+	29, // [29:42] is the sub-list for method input_type
+	29, // [29:29] is the sub-list for extension type_name
+	29, // [29:29] is the sub-list for extension extendee
+	0,  // [0:29] is the sub-list for field type_name
 }
 
 func init() { file_events_proto_init() }
@@ -598,10 +2373,10 @@ func file_events_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_events_proto_rawDesc), len(file_events_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   9,
+			NumEnums:      3,
+			NumMessages:   34,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_events_proto_goTypes,
 		DependencyIndexes: file_events_proto_depIdxs,
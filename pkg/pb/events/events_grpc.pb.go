@@ -19,9 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	EventService_TrackEvent_FullMethodName      = "/events.EventService/TrackEvent"
-	EventService_TrackEventBatch_FullMethodName = "/events.EventService/TrackEventBatch"
-	EventService_HealthCheck_FullMethodName     = "/events.EventService/HealthCheck"
+	EventService_TrackEvent_FullMethodName       = "/events.EventService/TrackEvent"
+	EventService_TrackEventBatch_FullMethodName  = "/events.EventService/TrackEventBatch"
+	EventService_TrackEventStream_FullMethodName = "/events.EventService/TrackEventStream"
+	EventService_Identify_FullMethodName         = "/events.EventService/Identify"
+	EventService_HealthCheck_FullMethodName      = "/events.EventService/HealthCheck"
 )
 
 // EventServiceClient is the client API for EventService service.
@@ -30,6 +32,12 @@ const (
 type EventServiceClient interface {
 	TrackEvent(ctx context.Context, in *TrackEventRequest, opts ...grpc.CallOption) (*TrackEventResponse, error)
 	TrackEventBatch(ctx context.Context, in *TrackEventBatchRequest, opts ...grpc.CallOption) (*TrackEventBatchResponse, error)
+	// Поток событий по одному соединению. Сервер копит события в батчи и после
+	// каждого flush отвечает ack со списком принятых и отклонённых id.
+	TrackEventStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[TrackEventStreamRequest, TrackEventStreamAck], error)
+	// Связывает anonymous_id посетителя с user_id после логина. События до и
+	// после связывания аналитика считает событиями одного пользователя.
+	Identify(ctx context.Context, in *IdentifyRequest, opts ...grpc.CallOption) (*IdentifyResponse, error)
 	HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error)
 }
 
@@ -61,6 +69,29 @@ func (c *eventServiceClient) TrackEventBatch(ctx context.Context, in *TrackEvent
 	return out, nil
 }
 
+func (c *eventServiceClient) TrackEventStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[TrackEventStreamRequest, TrackEventStreamAck], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EventService_ServiceDesc.Streams[0], EventService_TrackEventStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TrackEventStreamRequest, TrackEventStreamAck]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EventService_TrackEventStreamClient = grpc.BidiStreamingClient[TrackEventStreamRequest, TrackEventStreamAck]
+
+func (c *eventServiceClient) Identify(ctx context.Context, in *IdentifyRequest, opts ...grpc.CallOption) (*IdentifyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IdentifyResponse)
+	err := c.cc.Invoke(ctx, EventService_Identify_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *eventServiceClient) HealthCheck(ctx context.Context, in *HealthCheckRequest, opts ...grpc.CallOption) (*HealthCheckResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(HealthCheckResponse)
@@ -77,6 +108,12 @@ func (c *eventServiceClient) HealthCheck(ctx context.Context, in *HealthCheckReq
 type EventServiceServer interface {
 	TrackEvent(context.Context, *TrackEventRequest) (*TrackEventResponse, error)
 	TrackEventBatch(context.Context, *TrackEventBatchRequest) (*TrackEventBatchResponse, error)
+	// Поток событий по одному соединению. Сервер копит события в батчи и после
+	// каждого flush отвечает ack со списком принятых и отклонённых id.
+	TrackEventStream(grpc.BidiStreamingServer[TrackEventStreamRequest, TrackEventStreamAck]) error
+	// Связывает anonymous_id посетителя с user_id после логина. События до и
+	// после связывания аналитика считает событиями одного пользователя.
+	Identify(context.Context, *IdentifyRequest) (*IdentifyResponse, error)
 	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
 	mustEmbedUnimplementedEventServiceServer()
 }
@@ -94,6 +131,12 @@ func (UnimplementedEventServiceServer) TrackEvent(context.Context, *TrackEventRe
 func (UnimplementedEventServiceServer) TrackEventBatch(context.Context, *TrackEventBatchRequest) (*TrackEventBatchResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method TrackEventBatch not implemented")
 }
+func (UnimplementedEventServiceServer) TrackEventStream(grpc.BidiStreamingServer[TrackEventStreamRequest, TrackEventStreamAck]) error {
+	return status.Errorf(codes.Unimplemented, "method TrackEventStream not implemented")
+}
+func (UnimplementedEventServiceServer) Identify(context.Context, *IdentifyRequest) (*IdentifyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Identify not implemented")
+}
 func (UnimplementedEventServiceServer) HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method HealthCheck not implemented")
 }
@@ -154,6 +197,31 @@ func _EventService_TrackEventBatch_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EventService_TrackEventStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EventServiceServer).TrackEventStream(&grpc.GenericServerStream[TrackEventStreamRequest, TrackEventStreamAck]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EventService_TrackEventStreamServer = grpc.BidiStreamingServer[TrackEventStreamRequest, TrackEventStreamAck]
+
+func _EventService_Identify_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IdentifyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventServiceServer).Identify(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EventService_Identify_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventServiceServer).Identify(ctx, req.(*IdentifyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _EventService_HealthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(HealthCheckRequest)
 	if err := dec(in); err != nil {
@@ -187,11 +255,416 @@ var EventService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "TrackEventBatch",
 			Handler:    _EventService_TrackEventBatch_Handler,
 		},
+		{
+			MethodName: "Identify",
+			Handler:    _EventService_Identify_Handler,
+		},
 		{
 			MethodName: "HealthCheck",
 			Handler:    _EventService_HealthCheck_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TrackEventStream",
+			Handler:       _EventService_TrackEventStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "events.proto",
+}
+
+const (
+	AdminService_RegisterEventSchema_FullMethodName = "/events.AdminService/RegisterEventSchema"
+	AdminService_GetEventSchema_FullMethodName      = "/events.AdminService/GetEventSchema"
+	AdminService_ListEventSchemas_FullMethodName    = "/events.AdminService/ListEventSchemas"
+	AdminService_DeleteEventSchema_FullMethodName   = "/events.AdminService/DeleteEventSchema"
+	AdminService_DeleteUserData_FullMethodName      = "/events.AdminService/DeleteUserData"
+	AdminService_ExportUserData_FullMethodName      = "/events.AdminService/ExportUserData"
+	AdminService_ListArchives_FullMethodName        = "/events.AdminService/ListArchives"
+	AdminService_RestoreArchive_FullMethodName      = "/events.AdminService/RestoreArchive"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Управление реестром типов событий
+type AdminServiceClient interface {
+	// Регистрирует новый тип события или обновляет схему существующего
+	RegisterEventSchema(ctx context.Context, in *RegisterEventSchemaRequest, opts ...grpc.CallOption) (*RegisterEventSchemaResponse, error)
+	GetEventSchema(ctx context.Context, in *GetEventSchemaRequest, opts ...grpc.CallOption) (*GetEventSchemaResponse, error)
+	ListEventSchemas(ctx context.Context, in *ListEventSchemasRequest, opts ...grpc.CallOption) (*ListEventSchemasResponse, error)
+	// Встроенные типы удалить нельзя
+	DeleteEventSchema(ctx context.Context, in *DeleteEventSchemaRequest, opts ...grpc.CallOption) (*DeleteEventSchemaResponse, error)
+	// Удаляет события пользователя и связанных с ним анонимных id и отправляет
+	// tombstone в Kafka, чтобы consumers удалили свои данные о пользователе.
+	// Повтор с тем же request_id возвращает результат первого вызова.
+	DeleteUserData(ctx context.Context, in *DeleteUserDataRequest, opts ...grpc.CallOption) (*DeleteUserDataResponse, error)
+	// Выгружает все события пользователя в формате JSON lines
+	ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportUserDataChunk], error)
+	// Манифест архивов партиций events в холодном хранилище
+	ListArchives(ctx context.Context, in *ListArchivesRequest, opts ...grpc.CallOption) (*ListArchivesResponse, error)
+	// Загружает события за [from, to) из архивов в таблицу для разового анализа.
+	// Повторный вызов с тем же диапазоном не создаёт дубликатов.
+	RestoreArchive(ctx context.Context, in *RestoreArchiveRequest, opts ...grpc.CallOption) (*RestoreArchiveResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) RegisterEventSchema(ctx context.Context, in *RegisterEventSchemaRequest, opts ...grpc.CallOption) (*RegisterEventSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegisterEventSchemaResponse)
+	err := c.cc.Invoke(ctx, AdminService_RegisterEventSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) GetEventSchema(ctx context.Context, in *GetEventSchemaRequest, opts ...grpc.CallOption) (*GetEventSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEventSchemaResponse)
+	err := c.cc.Invoke(ctx, AdminService_GetEventSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListEventSchemas(ctx context.Context, in *ListEventSchemasRequest, opts ...grpc.CallOption) (*ListEventSchemasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEventSchemasResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListEventSchemas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) DeleteEventSchema(ctx context.Context, in *DeleteEventSchemaRequest, opts ...grpc.CallOption) (*DeleteEventSchemaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteEventSchemaResponse)
+	err := c.cc.Invoke(ctx, AdminService_DeleteEventSchema_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) DeleteUserData(ctx context.Context, in *DeleteUserDataRequest, opts ...grpc.CallOption) (*DeleteUserDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteUserDataResponse)
+	err := c.cc.Invoke(ctx, AdminService_DeleteUserData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportUserDataChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[0], AdminService_ExportUserData_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportUserDataRequest, ExportUserDataChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportUserDataClient = grpc.ServerStreamingClient[ExportUserDataChunk]
+
+func (c *adminServiceClient) ListArchives(ctx context.Context, in *ListArchivesRequest, opts ...grpc.CallOption) (*ListArchivesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListArchivesResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListArchives_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) RestoreArchive(ctx context.Context, in *RestoreArchiveRequest, opts ...grpc.CallOption) (*RestoreArchiveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreArchiveResponse)
+	err := c.cc.Invoke(ctx, AdminService_RestoreArchive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// Управление реестром типов событий
+type AdminServiceServer interface {
+	// Регистрирует новый тип события или обновляет схему существующего
+	RegisterEventSchema(context.Context, *RegisterEventSchemaRequest) (*RegisterEventSchemaResponse, error)
+	GetEventSchema(context.Context, *GetEventSchemaRequest) (*GetEventSchemaResponse, error)
+	ListEventSchemas(context.Context, *ListEventSchemasRequest) (*ListEventSchemasResponse, error)
+	// Встроенные типы удалить нельзя
+	DeleteEventSchema(context.Context, *DeleteEventSchemaRequest) (*DeleteEventSchemaResponse, error)
+	// Удаляет события пользователя и связанных с ним анонимных id и отправляет
+	// tombstone в Kafka, чтобы consumers удалили свои данные о пользователе.
+	// Повтор с тем же request_id возвращает результат первого вызова.
+	DeleteUserData(context.Context, *DeleteUserDataRequest) (*DeleteUserDataResponse, error)
+	// Выгружает все события пользователя в формате JSON lines
+	ExportUserData(*ExportUserDataRequest, grpc.ServerStreamingServer[ExportUserDataChunk]) error
+	// Манифест архивов партиций events в холодном хранилище
+	ListArchives(context.Context, *ListArchivesRequest) (*ListArchivesResponse, error)
+	// Загружает события за [from, to) из архивов в таблицу для разового анализа.
+	// Повторный вызов с тем же диапазоном не создаёт дубликатов.
+	RestoreArchive(context.Context, *RestoreArchiveRequest) (*RestoreArchiveResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) RegisterEventSchema(context.Context, *RegisterEventSchemaRequest) (*RegisterEventSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterEventSchema not implemented")
+}
+func (UnimplementedAdminServiceServer) GetEventSchema(context.Context, *GetEventSchemaRequest) (*GetEventSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEventSchema not implemented")
+}
+func (UnimplementedAdminServiceServer) ListEventSchemas(context.Context, *ListEventSchemasRequest) (*ListEventSchemasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEventSchemas not implemented")
+}
+func (UnimplementedAdminServiceServer) DeleteEventSchema(context.Context, *DeleteEventSchemaRequest) (*DeleteEventSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteEventSchema not implemented")
+}
+func (UnimplementedAdminServiceServer) DeleteUserData(context.Context, *DeleteUserDataRequest) (*DeleteUserDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserData not implemented")
+}
+func (UnimplementedAdminServiceServer) ExportUserData(*ExportUserDataRequest, grpc.ServerStreamingServer[ExportUserDataChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportUserData not implemented")
+}
+func (UnimplementedAdminServiceServer) ListArchives(context.Context, *ListArchivesRequest) (*ListArchivesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListArchives not implemented")
+}
+func (UnimplementedAdminServiceServer) RestoreArchive(context.Context, *RestoreArchiveRequest) (*RestoreArchiveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreArchive not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_RegisterEventSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterEventSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RegisterEventSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RegisterEventSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RegisterEventSchema(ctx, req.(*RegisterEventSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_GetEventSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetEventSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetEventSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetEventSchema(ctx, req.(*GetEventSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListEventSchemas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventSchemasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListEventSchemas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListEventSchemas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListEventSchemas(ctx, req.(*ListEventSchemasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_DeleteEventSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteEventSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).DeleteEventSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_DeleteEventSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).DeleteEventSchema(ctx, req.(*DeleteEventSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_DeleteUserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).DeleteUserData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_DeleteUserData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).DeleteUserData(ctx, req.(*DeleteUserDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ExportUserData_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportUserDataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).ExportUserData(m, &grpc.GenericServerStream[ExportUserDataRequest, ExportUserDataChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_ExportUserDataServer = grpc.ServerStreamingServer[ExportUserDataChunk]
+
+func _AdminService_ListArchives_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListArchivesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListArchives(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListArchives_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListArchives(ctx, req.(*ListArchivesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_RestoreArchive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreArchiveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).RestoreArchive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_RestoreArchive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).RestoreArchive(ctx, req.(*RestoreArchiveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "events.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterEventSchema",
+			Handler:    _AdminService_RegisterEventSchema_Handler,
+		},
+		{
+			MethodName: "GetEventSchema",
+			Handler:    _AdminService_GetEventSchema_Handler,
+		},
+		{
+			MethodName: "ListEventSchemas",
+			Handler:    _AdminService_ListEventSchemas_Handler,
+		},
+		{
+			MethodName: "DeleteEventSchema",
+			Handler:    _AdminService_DeleteEventSchema_Handler,
+		},
+		{
+			MethodName: "DeleteUserData",
+			Handler:    _AdminService_DeleteUserData_Handler,
+		},
+		{
+			MethodName: "ListArchives",
+			Handler:    _AdminService_ListArchives_Handler,
+		},
+		{
+			MethodName: "RestoreArchive",
+			Handler:    _AdminService_RestoreArchive_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportUserData",
+			Handler:       _AdminService_ExportUserData_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "events.proto",
 }
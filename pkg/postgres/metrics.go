@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Ключи GetStats, которые экспортируются как gauges postgres_pool_<key>
+var poolStatKeys = []string{
+	"open_connections",
+	"in_use",
+	"idle",
+	"wait_count",
+	"wait_duration_ms",
+	"max_idle_closed",
+	"max_lifetime_closed",
+}
+
+// poolCollector снимает GetStats в момент scrape
+type poolCollector struct {
+	db    *DB
+	descs map[string]*prometheus.Desc
+}
+
+// RegisterMetrics регистрирует статистику пула соединений в registry
+func (db *DB) RegisterMetrics(reg prometheus.Registerer) error {
+	descs := make(map[string]*prometheus.Desc, len(poolStatKeys))
+	for _, key := range poolStatKeys {
+		descs[key] = prometheus.NewDesc(
+			"postgres_pool_"+key,
+			"PostgreSQL connection pool stat "+key,
+			nil, nil,
+		)
+	}
+
+	return reg.Register(&poolCollector{db: db, descs: descs})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.descs {
+		ch <- desc
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	for key, value := range c.db.GetStats() {
+		desc, ok := c.descs[key]
+		if !ok {
+			continue
+		}
+
+		var v float64
+		switch n := value.(type) {
+		case int:
+			v = float64(n)
+		case int64:
+			v = float64(n)
+		default:
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+}